@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"marketflow/internal/adapters/input/cli"
+	"marketflow/internal/adapters/output/history"
+	"marketflow/internal/adapters/output/postgres"
+	"marketflow/internal/domain/ports/output"
+	"marketflow/internal/domain/services"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func backfillCommand() cli.Command {
+	var format, exchange string
+	return cli.Command{
+		Name:    "backfill",
+		Args:    "[--format csv|jsonl|rec] [--exchange NAME] FILE...",
+		Summary: "load historical ticks into market_data",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&format, "format", "", "input format: csv, jsonl or rec (default: by file extension)")
+			fs.StringVar(&exchange, "exchange", "", "exchange name for ticks without one")
+		},
+		Run: func(ctx context.Context, opts cli.GlobalOptions, args []string) int {
+			if len(args) == 0 {
+				os.Stderr.WriteString("backfill: no input files\n")
+				return cli.ExitUsage
+			}
+
+			cfg, logger, code := setup(opts, os.Stdout)
+			if code != cli.ExitOK {
+				return code
+			}
+
+			ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			pool, err := pgxpool.New(ctx, postgresConnString(cfg))
+			if err != nil {
+				logger.Error("Unable to connect to database", "error", err)
+				return cli.ExitFailure
+			}
+			defer pool.Close()
+
+			repo := postgres.NewMarketRepo(ctx, pool, logger)
+			validator := newValidator(cfg, repo, logger)
+			// Карантин дописывается в базу до закрытия пула
+			quarantineCtx, stopQuarantine := context.WithCancel(ctx)
+			quarantined := make(chan struct{})
+			go func() {
+				defer close(quarantined)
+				validator.Run(quarantineCtx)
+			}()
+			defer func() {
+				stopQuarantine()
+				<-quarantined
+			}()
+
+			backfiller := services.NewBackfiller(repo, repo, validator, newSymbolRegistry(cfg, logger), cfg.AggregatorWindow, logger)
+
+			// Файлы читаются одним потоком по времени первого тика: окно на стыке двух файлов собирается целиком
+			sources := make([]output.TickReader, 0, len(args))
+			for _, path := range args {
+				reader, err := history.NewTickFileReader(path, format, exchange)
+				if err != nil {
+					logger.Error("Backfill failed", "file", path, "error", err)
+					return cli.ExitUsage
+				}
+				sources = append(sources, reader)
+			}
+
+			logger.Info("Backfilling", "files", args)
+			if _, err := backfiller.Run(ctx, sources...); err != nil {
+				logger.Error("Backfill failed", "error", err)
+				return cli.ExitFailure
+			}
+			return cli.ExitOK
+		},
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"marketflow/internal/adapters/input/cli"
+	redisAdapter "marketflow/internal/adapters/output/redis"
+	"marketflow/internal/config"
+)
+
+// check-config загружает конфигурацию так же, как serve (defaults < env-файл < окружение < флаги),
+// проверяет её и печатает итоговые значения. Секреты не печатаются — только set/not set.
+// Ничего не подключает: ошибки конфигурации видны до запуска.
+func checkConfigCommand() cli.Command {
+	return cli.Command{
+		Name:    "check-config",
+		Summary: "validate configuration and print the resolved settings (dry run)",
+		Run: func(ctx context.Context, opts cli.GlobalOptions, args []string) int {
+			cfg, _, code := setup(opts, os.Stderr)
+			if code != cli.ExitOK {
+				return code
+			}
+
+			printConfig(os.Stdout, cfg)
+			fmt.Println("configuration OK")
+			return cli.ExitOK
+		},
+	}
+}
+
+func printConfig(out io.Writer, cfg *config.Config) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	row := func(key, format string, args ...any) {
+		fmt.Fprintf(w, "%s\t%s\n", key, fmt.Sprintf(format, args...))
+	}
+
+	row("app env", "%s", cfg.AppEnv)
+	row("mode", "%s", cfg.Recording.Mode)
+	for _, ex := range cfg.Exchanges {
+		q := cfg.Backpressure.For(ex.Name)
+		row("exchange", "%s %s:%s (queue %d, %s)", ex.Name, ex.Host, ex.Port, q.Buffer, q.Policy)
+	}
+	if cfg.Recording.RecordDir != "" {
+		row("record dir", "%s (replay speed %g)", cfg.Recording.RecordDir, cfg.Recording.ReplaySpeed)
+	}
+	row("api port", "%d (admin token %s)", cfg.PortAPI, secretState(cfg.AdminToken))
+	row("log", "level %s, format %s, sample %d per %s", cfg.Log.Level, cfg.Log.Format, cfg.Log.SampleBurst, cfg.Log.SampleInterval)
+
+	row("postgres", "%s@%s:%d/%s (sslmode=%s, password %s)",
+		cfg.Postgres.User, cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.NameDB, cfg.Postgres.SSLMode, secretState(cfg.Postgres.Password))
+
+	r := cfg.Redis
+	switch r.Mode {
+	case redisAdapter.ModeSentinel:
+		row("redis", "sentinel master=%s sentinels=%s db=%d (sentinel password %s)",
+			r.MasterName, strings.Join(r.Addrs, ","), r.DB, secretState(r.SentinelPassword))
+	case redisAdapter.ModeCluster:
+		row("redis", "cluster nodes=%s", strings.Join(r.Addrs, ","))
+	default:
+		row("redis", "%s:%s db=%d", r.Host, r.Port, r.DB)
+	}
+	row("redis auth", "user %q, password %s", r.Username, secretState(r.Password))
+	row("redis tls", "%t (ca %q, client cert %q, server name %q, skip verify %t)",
+		r.TLS, r.TLSCAFile, r.TLSCertFile, r.TLSServerName, r.TLSInsecureSkipVerify)
+	row("redis pool", "size %d, min idle %d, timeouts dial %s read %s write %s",
+		r.PoolSize, r.MinIdleConns, r.DialTimeout, r.ReadTimeout, r.WriteTimeout)
+
+	row("aggregator window", "%s", cfg.AggregatorWindow)
+	row("redis ttl", "%s", cfg.RedisTTL)
+	row("tracked pairs", "%s", strings.Join(cfg.TrackedPairs, ","))
+	for _, exchange := range slices.Sorted(maps.Keys(cfg.SymbolAliases)) {
+		for _, symbol := range slices.Sorted(maps.Keys(cfg.SymbolAliases[exchange])) {
+			row("symbol alias", "%s:%s -> %s", exchange, symbol, cfg.SymbolAliases[exchange][symbol])
+		}
+	}
+
+	row("spread", "threshold %g bps, min duration %s, max quote age %s",
+		cfg.Spread.ThresholdBps, cfg.Spread.MinDuration, cfg.Spread.MaxQuoteAge)
+	row("staleness", "threshold %s, check every %s", cfg.Staleness.Threshold, cfg.Staleness.CheckInterval)
+	row("validation", "%s", rulesString(cfg.Validation.Default))
+	for _, pair := range slices.Sorted(maps.Keys(cfg.Validation.Pairs)) {
+		row("validation "+pair, "%s", rulesString(cfg.Validation.Pairs[pair]))
+	}
+
+	origins := "same host"
+	if len(cfg.WSOrigins) > 0 {
+		origins = strings.Join(cfg.WSOrigins, ",")
+	}
+	row("ws", "client buffer %d, origins %s", cfg.WSClientBuffer, origins)
+	for _, p := range cfg.Publishers.List() {
+		name := "publisher " + strings.ToLower(p.Name)
+		if !p.Config.Enabled {
+			row(name, "disabled")
+			continue
+		}
+		row(name, "ticks %t, aggregates %t, pairs %q, exchanges %q, buffer %d, target %q",
+			p.Config.Ticks, p.Config.Aggregates, p.Config.Pairs, p.Config.Exchanges, p.Config.Buffer, p.Config.Target)
+	}
+	if cfg.Tracing.Enabled {
+		row("tracing", "OTLP/HTTP %s (insecure %t), sample ratio %g, service %s",
+			cfg.Tracing.Endpoint, cfg.Tracing.Insecure, cfg.Tracing.SampleRatio, cfg.Tracing.ServiceName)
+	} else {
+		row("tracing", "disabled")
+	}
+	hosts := "any public"
+	if len(cfg.Alerts.WebhookHosts) > 0 {
+		hosts = strings.Join(cfg.Alerts.WebhookHosts, ",")
+	}
+	row("alert webhook", "attempts %d, backoff %s, timeout %s, hosts %s",
+		cfg.Alerts.WebhookAttempts, cfg.Alerts.WebhookBackoff, cfg.Alerts.WebhookTimeout, hosts)
+	if c := cfg.Chaos; c.Enabled {
+		row("chaos exchange", "%s, disconnect every %s", faultsString(c.Exchange), c.ExchangeDisconnectEvery)
+		row("chaos redis", "%s", faultsString(c.Redis))
+		row("chaos postgres", "%s", faultsString(c.Postgres))
+		row("chaos seed", "%d", c.Seed)
+	} else {
+		row("chaos", "disabled")
+	}
+}
+
+func faultsString(f config.ChaosFaults) string {
+	return fmt.Sprintf("latency %s, error rate %g, partial rate %g, outage %s every %s",
+		f.Latency, f.ErrorRate, f.PartialRate, f.OutageFor, f.OutageEvery)
+}
+
+func rulesString(r config.ValidationRules) string {
+	return fmt.Sprintf("positive %t, max deviation %g (median of %d), max jump %g/s, max age %s",
+		r.RequirePositive, r.MaxDeviation, r.MedianWindow, r.MaxJumpPerSec, r.MaxTickAge)
+}
+
+func secretState(s config.Secret) string {
+	if s.IsSet() {
+		return "set"
+	}
+	return "not set"
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"marketflow/internal/adapters/input/cli"
+	"marketflow/internal/adapters/output/export"
+	"marketflow/internal/adapters/output/postgres"
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+	"marketflow/internal/domain/services"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func exportCommand() cli.Command {
+	var (
+		pair, exchange, from, to string
+		format, outPath          string
+		resolution               time.Duration
+	)
+	return cli.Command{
+		Name:    "export",
+		Args:    "--pair PAIR --from TIME [--to TIME] [--exchange NAME] [--resolution 1h] [--format csv|jsonl|parquet] [--output FILE]",
+		Summary: "export aggregates from market_data",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&pair, "pair", "", "pair to export, e.g. BTCUSDT (required)")
+			fs.StringVar(&exchange, "exchange", "", "exchange name (default: all exchanges)")
+			fs.StringVar(&from, "from", "", "range start: RFC3339, YYYY-MM-DD or unix seconds")
+			fs.StringVar(&to, "to", "", "range end, exclusive (default: now)")
+			fs.DurationVar(&resolution, "resolution", 0, "bucket size, a multiple of AGGREGATOR_WINDOW (default: the window)")
+			fs.StringVar(&format, "format", export.FormatCSV, "output format: csv, jsonl or parquet")
+			fs.StringVar(&outPath, "output", "", "output file (default: stdout)")
+		},
+		Run: func(ctx context.Context, opts cli.GlobalOptions, args []string) int {
+			q := output.AggregateQuery{
+				Pair:       strings.ToUpper(pair),
+				Exchange:   exchange,
+				Resolution: resolution,
+			}
+			var err error
+			if q.From, err = export.ParseTime(from); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return cli.ExitUsage
+			}
+			if q.To, err = export.ParseTime(to); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return cli.ExitUsage
+			}
+			if !export.ValidFormat(format) {
+				fmt.Fprintln(os.Stderr, "format must be csv, jsonl or parquet")
+				return cli.ExitUsage
+			}
+
+			// stdout занят данными — логи уходят в stderr
+			var logOut io.Writer = os.Stdout
+			if outPath == "" {
+				logOut = os.Stderr
+			}
+			cfg, logger, code := setup(opts, logOut)
+			if code != cli.ExitOK {
+				return code
+			}
+
+			ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			pool, err := pgxpool.New(ctx, postgresConnString(cfg))
+			if err != nil {
+				logger.Error("Unable to connect to database", "error", err)
+				return cli.ExitFailure
+			}
+			defer pool.Close()
+
+			exports := services.NewExportService(postgres.NewMarketRepo(ctx, pool, logger), cfg.AggregatorWindow)
+
+			// Запрос проверяется до создания файла: неверные флаги не оставляют пустой CSV с заголовком
+			if q, err = exports.Validate(q); err != nil {
+				logger.Error("Invalid export query", "error", err)
+				return cli.ExitUsage
+			}
+
+			out := os.Stdout
+			if outPath != "" {
+				if out, err = os.Create(outPath); err != nil {
+					logger.Error("Export failed", "error", err)
+					return cli.ExitFailure
+				}
+				defer out.Close()
+			}
+
+			writer, err := export.NewAggregateWriter(out, format)
+			if err != nil {
+				logger.Error("Export failed", "error", err)
+				return cli.ExitFailure
+			}
+
+			rows := 0
+			err = exports.ExportAggregates(ctx, q, func(agg models.Aggregate) error {
+				rows++
+				return writer.Write(agg)
+			})
+			if err == nil {
+				err = writer.Close()
+			}
+			if err != nil {
+				logger.Error("Export failed", "error", err)
+				return cli.ExitFailure
+			}
+
+			logger.Info("Export finished", "pair", q.Pair, "rows", rows)
+			return cli.ExitOK
+		},
+	}
+}
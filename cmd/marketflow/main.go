@@ -3,93 +3,192 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"io"
 	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
 
 	"marketflow/internal/adapters/input/cli"
-	"marketflow/internal/adapters/output/console"
-	"marketflow/internal/adapters/output/postgres"
+	"marketflow/internal/adapters/output/chaos"
 	redisAdapter "marketflow/internal/adapters/output/redis"
-	"marketflow/internal/adapters/output/tcp"
 	"marketflow/internal/config"
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
 	"marketflow/internal/domain/services"
-
-	pgx "github.com/jackc/pgx/v5"
-	redis "github.com/redis/go-redis/v9"
+	applog "marketflow/pkg/logger"
 )
 
 func main() {
-	// Setup logger
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	app := cli.NewApp("marketflow", "serve",
+		serveCommand(),
+		migrateCommand(),
+		backfillCommand(),
+		exportCommand(),
+		replayCommand(),
+		checkConfigCommand(),
+		tailCommand(),
+		mockExchangeCommand(),
+	)
+	os.Exit(app.Run(context.Background(), os.Args[1:]))
+}
 
-	// Load configuration
-	cfg, err := config.NewConfig()
+// logLevel — уровень итогового логгера; serve меняет его через PUT /admin/log-level.
+var logLevel = new(slog.LevelVar)
+
+// setup загружает конфигурацию с учётом глобальных флагов и создаёт по ней логгер.
+// Логи пишутся в logOut: подкоманды, выводящие данные в stdout, передают stderr.
+func setup(opts cli.GlobalOptions, logOut io.Writer) (*config.Config, *slog.Logger, int) {
+	logger, code := bootstrapLogger(opts, logOut)
+	if code != cli.ExitOK {
+		return nil, nil, code
+	}
+
+	cfg, err := config.NewConfig(opts.ConfigFile)
 	if err != nil {
 		logger.Error("Load configuration failed", "error", err)
-		os.Exit(1)
+		return nil, nil, cli.ExitConfig
+	}
+	if err := cfg.Apply(config.Overrides{
+		Port:      opts.Port,
+		Mode:      opts.Mode,
+		Exchanges: opts.Exchanges,
+		LogLevel:  opts.LogLevel,
+		LogFormat: opts.LogFormat,
+	}); err != nil {
+		// Validate возвращает все ошибки сразу — по строке лога на каждую
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				logger.Error("Invalid configuration", "error", e)
+			}
+		} else {
+			logger.Error("Invalid configuration", "error", err)
+		}
+		return nil, nil, cli.ExitConfig
 	}
 
-	// Создаем контекст для graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	logLevel.Set(cfg.Log.Level)
+	logger = applog.New(logOut, applog.Options{
+		Level:          logLevel,
+		Format:         cfg.Log.Format,
+		SampleBurst:    cfg.Log.SampleBurst,
+		SampleInterval: cfg.Log.SampleInterval,
+	})
+	slog.SetDefault(logger)
+	return cfg, logger, cli.ExitOK
+}
 
-	addrRedis := fmt.Sprint(cfg.Redis.Host + ":" + cfg.Redis.Port)
+// bootstrapLogger строится только по флагам: им пишутся ошибки загрузки конфигурации.
+func bootstrapLogger(opts cli.GlobalOptions, logOut io.Writer) (*slog.Logger, int) {
+	level, err := applog.ParseLevel(opts.LogLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, cli.ExitUsage
+	}
+	format, err := applog.ParseFormat(opts.LogFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, cli.ExitUsage
+	}
+	logger := applog.New(logOut, applog.Options{Level: level, Format: format})
+	slog.SetDefault(logger)
+	return logger, cli.ExitOK
+}
 
-	// redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     addrRedis,          // адрес Redis-сервера
-		Password: cfg.Redis.Password, // если нет пароля
-		DB:       cfg.Redis.DB,       // номер БД
-	})
+// postgresConnString содержит пароль — не логировать.
+func postgresConnString(cfg *config.Config) string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.Postgres.User, cfg.Postgres.Password.Reveal()),
+		Host:     net.JoinHostPort(cfg.Postgres.Host, strconv.Itoa(cfg.Postgres.Port)),
+		Path:     cfg.Postgres.NameDB,
+		RawQuery: url.Values{"sslmode": {cfg.Postgres.SSLMode}}.Encode(),
+	}
+	return dsn.String()
+}
 
-	// postgres
-	connString := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		cfg.Postgres.User,
-		cfg.Postgres.Password,
-		cfg.Postgres.Host,
-		cfg.Postgres.Port,
-		cfg.Postgres.NameDB,
-		cfg.Postgres.SSLMode,
-	)
-	fmt.Println(connString)
+func redisOptions(cfg config.RedisConfig) redisAdapter.Options {
+	addrs := cfg.Addrs
+	if cfg.Mode == redisAdapter.ModeStandalone {
+		addrs = []string{net.JoinHostPort(cfg.Host, cfg.Port)}
+	}
+	return redisAdapter.Options{
+		Mode:             cfg.Mode,
+		Addrs:            addrs,
+		Username:         cfg.Username,
+		Password:         cfg.Password.Reveal(),
+		DB:               cfg.DB,
+		MasterName:       cfg.MasterName,
+		SentinelUsername: cfg.SentinelUsername,
+		SentinelPassword: cfg.SentinelPassword.Reveal(),
+		TLS: redisAdapter.TLSOptions{
+			Enabled:            cfg.TLS,
+			CAFile:             cfg.TLSCAFile,
+			CertFile:           cfg.TLSCertFile,
+			KeyFile:            cfg.TLSKeyFile,
+			ServerName:         cfg.TLSServerName,
+			InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+		},
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		PoolSize:     cfg.PoolSize,
+		MinIdleConns: cfg.MinIdleConns,
+	}
+}
 
-	conn, err := pgx.Connect(ctx, connString)
-	if err != nil {
-		log.Fatalf("Unable to connect to database: %v", err)
+func exchangeNames(exchanges []models.ExchangeConfig) []string {
+	names := make([]string, 0, len(exchanges))
+	for _, ex := range exchanges {
+		names = append(names, ex.Name)
 	}
-	defer conn.Close(ctx)
-
-	// Create output adapters
-	exchangeClient := tcp.NewTCPExchangeClient(logger)
-	pricePublisher := console.NewConsolePricePublisher(logger)
-
-	// redis repo
-	redi := redisAdapter.NewRedisAdapter(rdb)
-	// pg repo
-	repo := postgres.NewMarketRepo(ctx, conn, logger)
-
-	// Create domain service
-	marketService := services.NewMarketService(
-		ctx,
-		exchangeClient,
-		pricePublisher,
-		cfg.Exchanges,
-		logger,
-		redi,
-		repo,
-		cfg.RedisTTL,
-	)
+	return names
+}
 
-	// Create input adapter
-	cliHandler := cli.NewCLIHandler(ctx, marketService, logger)
+func newValidator(cfg *config.Config, repo output.QuarantineRepository, logger *slog.Logger) *services.TickValidator {
+	validation := services.ValidationConfig{
+		Default: validationRules(cfg.Validation.Default),
+		Pairs:   make(map[string]services.ValidationRules, len(cfg.Validation.Pairs)),
+	}
+	for pair, rules := range cfg.Validation.Pairs {
+		validation.Pairs[pair] = validationRules(rules)
+	}
+	return services.NewTickValidator(validation, repo, logger)
+}
+
+func newSymbolRegistry(cfg *config.Config, logger *slog.Logger) *services.SymbolRegistry {
+	return services.NewSymbolRegistry(services.SymbolConfig{
+		Tracked: cfg.TrackedPairs,
+		Aliases: cfg.SymbolAliases,
+	}, logger)
+}
+
+func validationRules(r config.ValidationRules) services.ValidationRules {
+	return services.ValidationRules{
+		RequirePositive: r.RequirePositive,
+		MaxDeviation:    r.MaxDeviation,
+		MedianWindow:    r.MedianWindow,
+		MaxJumpPerSec:   r.MaxJumpPerSec,
+		MaxTickAge:      r.MaxTickAge,
+	}
+}
+
+func chaosFaults(f config.ChaosFaults) chaos.Faults {
+	return chaos.Faults{
+		Latency:     f.Latency,
+		ErrorRate:   f.ErrorRate,
+		PartialRate: f.PartialRate,
+		OutageEvery: f.OutageEvery,
+		OutageFor:   f.OutageFor,
+	}
+}
 
-	// Start application
-	if err := cliHandler.Start(); err != nil {
-		logger.Error("Application failed", "error", err)
-		os.Exit(1)
+func publishFilter(pc config.PublisherConfig) services.PublishFilter {
+	return services.PublishFilter{
+		Pairs:      pc.Pairs,
+		Exchanges:  pc.Exchanges,
+		Ticks:      pc.Ticks,
+		Aggregates: pc.Aggregates,
 	}
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"marketflow/internal/adapters/input/cli"
+	"marketflow/internal/adapters/output/postgres"
+	schema "marketflow/sql"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func migrateCommand() cli.Command {
+	return cli.Command{
+		Name:    "migrate",
+		Summary: "apply pending database migrations",
+		Run: func(ctx context.Context, opts cli.GlobalOptions, args []string) int {
+			cfg, logger, code := setup(opts, os.Stdout)
+			if code != cli.ExitOK {
+				return code
+			}
+
+			pool, err := pgxpool.New(ctx, postgresConnString(cfg))
+			if err != nil {
+				logger.Error("Unable to connect to database", "error", err)
+				return cli.ExitFailure
+			}
+			defer pool.Close()
+
+			applied, err := postgres.Migrate(ctx, pool, schema.Migrations, logger)
+			if err != nil {
+				logger.Error("Migration failed", "error", err)
+				return cli.ExitFailure
+			}
+			logger.Info("Database is up to date", "applied", len(applied))
+			return cli.ExitOK
+		},
+	}
+}
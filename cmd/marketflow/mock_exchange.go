@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"marketflow/internal/adapters/input/cli"
+	"marketflow/internal/mockexchange"
+	applog "marketflow/pkg/logger"
+)
+
+func mockExchangeCommand() cli.Command {
+	var (
+		listen, pairs, format string
+		cfg                   mockexchange.Config
+	)
+	return cli.Command{
+		Name:    "mock-exchange",
+		Args:    "[--listen :40101,:40102,:40103] [--pairs BTCUSDT=43250,...] [--rate 5] [--volatility 0.0005] [--format json|colon|space] [--malformed 0.01] [--disconnect-every 1m] [--stall-every 2m --stall-for 30s]",
+		Summary: "serve a fake exchange feed for local development",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&listen, "listen", ":40101,:40102,:40103", "addresses to listen on, one exchange per address, comma separated")
+			fs.StringVar(&pairs, "pairs", "BTCUSDT,ETHUSDT,SOLUSDT,DOGEUSDT,TONUSDT", "pairs with optional start price: BTCUSDT=43250,...")
+			fs.Float64Var(&cfg.Rate, "rate", 5, "ticks per second per pair")
+			fs.Float64Var(&cfg.Volatility, "volatility", 0.0005, "std deviation of the relative price change per tick")
+			fs.StringVar(&format, "format", mockexchange.FormatJSON, "line format: json, colon or space")
+			fs.Float64Var(&cfg.MalformedRatio, "malformed", 0, "fraction of lines replaced with malformed ones, 0-1")
+			fs.DurationVar(&cfg.DisconnectEvery, "disconnect-every", 0, "drop each client after this long (0: never)")
+			fs.DurationVar(&cfg.StallEvery, "stall-every", 0, "pause the feed this often, keeping connections open (0: never)")
+			fs.DurationVar(&cfg.StallFor, "stall-for", 30*time.Second, "how long each pause lasts")
+			fs.Int64Var(&cfg.Seed, "seed", 0, "random seed; exchange N uses seed+N (0: random)")
+		},
+		Run: func(ctx context.Context, opts cli.GlobalOptions, args []string) int {
+			logger, code := bootstrapLogger(opts, os.Stderr)
+			if code != cli.ExitOK {
+				return code
+			}
+
+			var err error
+			if cfg.Pairs, err = mockexchange.ParsePairs(pairs); err != nil {
+				fmt.Fprintln(os.Stderr, "invalid --pairs:", err)
+				return cli.ExitUsage
+			}
+			cfg.Format = format
+
+			ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			// Каждый адрес — отдельная биржа со своим блужданием цен
+			var servers []*mockexchange.Server
+			for i, addr := range strings.Split(listen, ",") {
+				c := cfg
+				c.Addr = strings.TrimSpace(addr)
+				c.Name = fmt.Sprintf("mock%d", i+1)
+				if c.Seed != 0 {
+					c.Seed += int64(i)
+				}
+				server, err := mockexchange.NewServer(c, applog.Component(logger, "mock-exchange"))
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return cli.ExitUsage
+				}
+				if err := server.Listen(); err != nil {
+					logger.Error("Mock exchange failed", "error", err)
+					return cli.ExitFailure
+				}
+				servers = append(servers, server)
+			}
+
+			var wg sync.WaitGroup
+			for _, server := range servers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					server.Run(ctx)
+				}()
+			}
+			wg.Wait()
+			return cli.ExitOK
+		},
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"marketflow/internal/adapters/input/api"
+	"marketflow/internal/adapters/input/cli"
+	"marketflow/internal/adapters/output/chaos"
+	"marketflow/internal/adapters/output/console"
+	"marketflow/internal/adapters/output/file"
+	"marketflow/internal/adapters/output/postgres"
+	redisAdapter "marketflow/internal/adapters/output/redis"
+	"marketflow/internal/adapters/output/replay"
+	"marketflow/internal/adapters/output/sse"
+	"marketflow/internal/adapters/output/tcp"
+	"marketflow/internal/adapters/output/webhook"
+	"marketflow/internal/adapters/output/ws"
+	"marketflow/internal/config"
+	"marketflow/internal/domain/ports/output"
+	"marketflow/internal/domain/services"
+	applog "marketflow/pkg/logger"
+	"marketflow/pkg/tracing"
+	schema "marketflow/sql"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func serveCommand() cli.Command {
+	return cli.Command{
+		Name:    "serve",
+		Summary: "run the pipeline and the HTTP API",
+		Run: func(ctx context.Context, opts cli.GlobalOptions, args []string) int {
+			cfg, logger, code := setup(opts, os.Stdout)
+			if code != cli.ExitOK {
+				return code
+			}
+			return runServe(ctx, cfg, logger)
+		},
+	}
+}
+
+// replay — serve с биржами из записанных сессий
+func replayCommand() cli.Command {
+	var dir string
+	var speed float64
+	return cli.Command{
+		Name:    "replay",
+		Args:    "[--dir DIR] [--speed N]",
+		Summary: "run the pipeline on recorded exchange sessions",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&dir, "dir", "", "recordings directory (default: RECORD_DIR)")
+			fs.Float64Var(&speed, "speed", -1, "1 = original pace, N = N times faster, 0 = as fast as possible (default: REPLAY_SPEED)")
+		},
+		Run: func(ctx context.Context, opts cli.GlobalOptions, args []string) int {
+			cfg, logger, code := setup(opts, os.Stdout)
+			if code != cli.ExitOK {
+				return code
+			}
+			if dir != "" {
+				cfg.Recording.RecordDir = dir
+			}
+			if speed >= 0 {
+				cfg.Recording.ReplaySpeed = speed
+			}
+			if cfg.Recording.RecordDir == "" {
+				logger.Error("Replay requires --dir or RECORD_DIR")
+				return cli.ExitConfig
+			}
+			cfg.Recording.Mode = config.ExchangeModeReplay
+			return runServe(ctx, cfg, logger)
+		},
+	}
+}
+
+func runServe(ctx context.Context, cfg *config.Config, logger *slog.Logger) int {
+	// Контекст отменяется по SIGINT/SIGTERM — на нём останавливаются сервис, шина и API
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.Tracing.Enabled {
+		shutdown, err := tracing.Init(ctx, tracing.Config{
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			SampleRatio: cfg.Tracing.SampleRatio,
+			ServiceName: cfg.Tracing.ServiceName,
+		})
+		if err != nil {
+			logger.Error("Tracing setup failed", "error", err)
+			return cli.ExitConfig
+		}
+		// ctx к этому моменту уже отменён — дописываем спаны с отдельным таймаутом
+		defer func() {
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdown(flushCtx); err != nil {
+				logger.Warn("Tracing shutdown failed", "error", err)
+			}
+		}()
+		logger.Info("Tracing enabled", "endpoint", cfg.Tracing.Endpoint, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
+
+	// redis
+	rdb, err := redisAdapter.NewClient(redisOptions(cfg.Redis))
+	if err != nil {
+		logger.Error("Invalid Redis configuration", "error", err)
+		return cli.ExitConfig
+	}
+	defer rdb.Close()
+
+	pingCtx, cancelPing := context.WithTimeout(ctx, cfg.Redis.DialTimeout)
+	if err := rdb.Ping(pingCtx).Err(); err != nil {
+		// Не фатально: клиент переподключится, ошибки записи логирует адаптер
+		logger.Warn("Redis is not reachable yet", "mode", cfg.Redis.Mode, "error", err)
+	}
+	cancelPing()
+
+	// postgres
+	pool, err := pgxpool.New(ctx, postgresConnString(cfg))
+	if err != nil {
+		logger.Error("Unable to connect to database", "error", err)
+		return cli.ExitFailure
+	}
+	defer pool.Close()
+
+	// Код рассчитывает на актуальную схему (upsert агрегатов держится на уникальном
+	// индексе окна), поэтому недостающие миграции применяются до запуска конвейера
+	if _, err := postgres.Migrate(ctx, pool, schema.Migrations, applog.Component(logger, "migrate")); err != nil {
+		logger.Error("Migration failed", "error", err)
+		return cli.ExitFailure
+	}
+
+	// Create output adapters
+	var exchangeClient output.ExchangeClient
+	if cfg.Recording.Mode == config.ExchangeModeReplay {
+		exchangeClient = replay.NewReplayExchangeClient(cfg.Recording.RecordDir, cfg.Recording.ReplaySpeed, applog.Component(logger, "exchange"))
+	} else {
+		exchangeClient = tcp.NewTCPExchangeClient(applog.Component(logger, "exchange"), cfg.Recording.RecordDir)
+	}
+
+	// redis repo
+	redi := redisAdapter.NewRedisAdapter(rdb, cfg.Redis.Mode == redisAdapter.ModeCluster)
+	// pg repo
+	repo := postgres.NewMarketRepo(ctx, pool, applog.Component(logger, "repo"))
+
+	// Сбои вносятся только в порты конвейера: биржи, тики в Redis, запись агрегатов
+	var tickStore output.RedisClient = redi
+	var aggregateStore output.MarketRepository = repo
+	if c := cfg.Chaos; c.Enabled {
+		logger.Warn("Chaos fault injection enabled", "app_env", cfg.AppEnv, "seed", c.Seed)
+		// у каждого порта свой поток случайных решений: сбои портов не коррелируют
+		exchangeClient = chaos.NewExchangeClient(exchangeClient, chaosFaults(c.Exchange), c.ExchangeDisconnectEvery, c.Seed)
+		tickStore = chaos.NewRedisClient(redi, chaosFaults(c.Redis), c.Seed+1)
+		aggregateStore = chaos.NewMarketRepository(repo, chaosFaults(c.Postgres), c.Seed+2)
+	}
+
+	spreadMonitor := services.NewSpreadMonitor(services.SpreadConfig{
+		ThresholdBps: cfg.Spread.ThresholdBps,
+		MinDuration:  cfg.Spread.MinDuration,
+		MaxQuoteAge:  cfg.Spread.MaxQuoteAge,
+	}, repo, applog.Component(logger, "spread"))
+
+	validator := newValidator(cfg, repo, applog.Component(logger, "validator"))
+	symbols := newSymbolRegistry(cfg, applog.Component(logger, "symbols"))
+
+	// WebSocket-раздача живых тиков и агрегатов
+	hub := ws.NewHub(cfg.WSClientBuffer, cfg.WSOrigins, applog.Component(logger, "ws"))
+	// SSE-лента завершённых свечей
+	candles := sse.NewBroker(cfg.AggregatorWindow, repo, applog.Component(logger, "sse"))
+
+	// Шина публикации: сервис отдаёт в неё каждый тик и агрегат
+	publisherLogger := applog.Component(logger, "publisher")
+	bus := services.NewPublisherBus(publisherLogger)
+	bus.Register("websocket", hub, services.PublishFilter{Ticks: true, Aggregates: true}, 0)
+	bus.Register("sse", candles, services.PublishFilter{Aggregates: true}, 0)
+	if pc := cfg.Publishers.Console; pc.Enabled {
+		bus.Register("console", console.NewConsolePricePublisher(publisherLogger), publishFilter(pc), pc.Buffer)
+	}
+	if pc := cfg.Publishers.File; pc.Enabled {
+		filePublisher, err := file.NewFilePricePublisher(pc.Target)
+		if err != nil {
+			logger.Error("File publisher failed", "error", err)
+			return cli.ExitFailure
+		}
+		defer filePublisher.Close()
+		bus.Register("file", filePublisher, publishFilter(pc), pc.Buffer)
+	}
+	if pc := cfg.Publishers.Webhook; pc.Enabled {
+		bus.Register("webhook", webhook.NewWebhookPricePublisher(pc.Target, 5*time.Second), publishFilter(pc), pc.Buffer)
+	}
+	if pc := cfg.Publishers.Redis; pc.Enabled {
+		bus.Register("redis", redisAdapter.NewPubSubPublisher(redi, cfg.AggregatorWindow), publishFilter(pc), pc.Buffer)
+	}
+
+	// Правила оповещений проверяются на потоке тиков
+	alerts := services.NewAlertEngine(repo, webhook.NewAlertNotifier(cfg.Alerts.WebhookTimeout, cfg.Alerts.WebhookHosts), services.AlertDeliveryConfig{
+		MaxAttempts: cfg.Alerts.WebhookAttempts,
+		Backoff:     cfg.Alerts.WebhookBackoff,
+		Hosts:       cfg.Alerts.WebhookHosts,
+	}, exchangeNames(cfg.Exchanges), cfg.TrackedPairs, applog.Component(logger, "alerts"))
+	if err := alerts.Start(ctx); err != nil {
+		logger.Error("Alert engine failed", "error", err)
+		return cli.ExitFailure
+	}
+	// Правила считаются в памяти быстро, а пропущенный тик — пропущенное оповещение
+	bus.RegisterBlocking("alerts", alerts, services.PublishFilter{Ticks: true}, 0)
+	bus.Start(ctx)
+
+	// Create domain service
+	marketService := services.NewMarketService(
+		ctx,
+		exchangeClient,
+		bus,
+		cfg.Exchanges,
+		applog.Component(logger, "aggregator"),
+		tickStore,
+		aggregateStore,
+		cfg.RedisTTL,
+		cfg.AggregatorWindow,
+		spreadMonitor,
+		validator,
+		symbols,
+		services.NewStalenessMonitor(services.StalenessConfig{
+			Threshold:     cfg.Staleness.Threshold,
+			CheckInterval: cfg.Staleness.CheckInterval,
+		}, exchangeNames(cfg.Exchanges), cfg.TrackedPairs, applog.Component(logger, "staleness")),
+		cfg.Backpressure,
+	)
+
+	// HTTP API (/metrics, /health, /ws, /stream/candles, /export/aggregates; с ADMIN_TOKEN — /alerts/rules, /admin/log-level)
+	apiLogger := applog.Component(logger, "api")
+	apiServer := api.NewServer(cfg.PortAPI, apiLogger)
+	apiServer.Handle("GET /ws", hub)
+	apiServer.Handle("GET /stream/candles", candles)
+	api.NewExportHandler(services.NewExportService(repo, cfg.AggregatorWindow), apiLogger).Register(apiServer)
+	if cfg.AdminToken.IsSet() {
+		api.NewAlertHandler(cfg.AdminToken.Reveal(), alerts, apiLogger).Register(apiServer)
+		api.NewAdminHandler(cfg.AdminToken.Reveal(), logLevel, apiLogger).Register(apiServer)
+	} else {
+		apiLogger.Info("Alert rule and admin endpoints disabled: ADMIN_TOKEN is not set")
+	}
+	go func() {
+		if err := apiServer.Start(ctx); err != nil {
+			logger.Error("API server failed", "error", err)
+		}
+	}()
+
+	// Create input adapter
+	cliHandler := cli.NewCLIHandler(ctx, marketService, logger)
+
+	// Start application
+	if err := cliHandler.Start(); err != nil {
+		logger.Error("Application failed", "error", err)
+		return cli.ExitFailure
+	}
+
+	// Сервис мог остановиться сам, когда закончилось воспроизведение записей:
+	// отменяем контекст, чтобы остановились шина и API
+	stop()
+
+	// Дожидаемся, пока приёмники шины допишут очереди
+	bus.Wait()
+	logger.Info("MarketFlow stopped")
+	return cli.ExitOK
+}
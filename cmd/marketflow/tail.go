@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"marketflow/internal/adapters/input/cli"
+	"marketflow/internal/adapters/output/console"
+	"marketflow/internal/domain/ports/output"
+)
+
+func tailCommand() cli.Command {
+	var addr, pairs, exchanges, types string
+	var plain bool
+	return cli.Command{
+		Name:    "tail",
+		Args:    "[--url ws://HOST:PORT/ws] [--pairs BTCUSDT,...] [--exchange NAME,...] [--types tick,aggregate] [--plain]",
+		Summary: "live dashboard of a running instance (pairs x exchanges)",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&addr, "url", "", "WebSocket endpoint (default: ws://localhost:<API_PORT>/ws)")
+			fs.StringVar(&pairs, "pairs", "", "pairs to show, comma separated (default: all)")
+			fs.StringVar(&exchanges, "exchange", "", "exchanges to show, comma separated (default: all)")
+			fs.StringVar(&types, "types", "", "event types: tick, aggregate (default: both)")
+			fs.BoolVar(&plain, "plain", false, "print one line per event instead of the dashboard (default when stdout is not a terminal)")
+		},
+		Run: func(ctx context.Context, opts cli.GlobalOptions, args []string) int {
+			logger, code := bootstrapLogger(opts, os.Stderr)
+			if code != cli.ExitOK {
+				return code
+			}
+
+			// Адрес из конфигурации нужен, только если --url не задан
+			if addr == "" {
+				port := opts.Port
+				if port == 0 {
+					cfg, _, code := setup(opts, os.Stderr)
+					if code != cli.ExitOK {
+						return code
+					}
+					port = cfg.PortAPI
+				}
+				addr = fmt.Sprintf("ws://localhost:%d/ws", port)
+			}
+
+			u, err := url.Parse(addr)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "invalid --url:", err)
+				return cli.ExitUsage
+			}
+			query := u.Query()
+			for key, value := range map[string]string{"pairs": pairs, "exchanges": exchanges, "types": types} {
+				if value != "" {
+					query.Set(key, value)
+				}
+			}
+			u.RawQuery = query.Encode()
+
+			ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			var sink output.PricePublisher
+			if plain || !isTerminal(os.Stdout) {
+				sink = console.NewConsolePricePublisherTo(os.Stdout, logger)
+			} else {
+				dashboard := console.NewDashboard(os.Stdout)
+				go dashboard.Run(ctx)
+				sink = dashboard
+			}
+
+			if err := console.Tail(ctx, u.String(), sink, logger); err != nil {
+				logger.Error("Tail failed", "error", err)
+				return cli.ExitFailure
+			}
+			return cli.ExitOK
+		},
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	applog "marketflow/pkg/logger"
+)
+
+// AdminHandler — служебные эндпоинты. Уровень логирования меняется без перезапуска:
+//
+//	GET /admin/log-level            -> {"level":"info"}
+//	PUT /admin/log-level {"level":"debug"}
+//
+// API слушает публичный порт, поэтому каждый запрос несёт Authorization: Bearer <ADMIN_TOKEN>.
+type AdminHandler struct {
+	token  string
+	level  *slog.LevelVar
+	logger *slog.Logger
+}
+
+func NewAdminHandler(token string, level *slog.LevelVar, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{token: token, level: level, logger: logger}
+}
+
+func (h *AdminHandler) Register(s *Server) {
+	s.mux.HandleFunc("GET /admin/log-level", bearerAuth(h.token, h.logger, h.getLevel))
+	s.mux.HandleFunc("PUT /admin/log-level", bearerAuth(h.token, h.logger, h.setLevel))
+}
+
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+func (h *AdminHandler) getLevel(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, logLevelBody{Level: strings.ToLower(h.level.Level().String())})
+}
+
+func (h *AdminHandler) setLevel(w http.ResponseWriter, r *http.Request) {
+	var body logLevelBody
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	level, err := applog.ParseLevel(body.Level)
+	if err != nil || body.Level == "" {
+		writeError(w, http.StatusBadRequest, "level must be debug, info, warn or error")
+		return
+	}
+
+	previous := h.level.Level()
+	h.level.Set(level)
+	// Пишем на Warn, чтобы смена уровня была видна при любом уровне
+	h.logger.Warn("Log level changed", "from", previous, "to", level, "remote", r.RemoteAddr)
+	writeJSON(w, http.StatusOK, logLevelBody{Level: strings.ToLower(level.String())})
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const adminToken = "s3cret"
+
+func serveAdmin(t *testing.T, level *slog.LevelVar, method, auth, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	s := NewServer(0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	NewAdminHandler(adminToken, level, s.logger).Register(s)
+
+	req := httptest.NewRequest(method, "/admin/log-level", strings.NewReader(body))
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminLogLevel(t *testing.T) {
+	level := new(slog.LevelVar)
+
+	rec := serveAdmin(t, level, http.MethodGet, "Bearer "+adminToken, "")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"level":"info"}` {
+		t.Fatalf("GET = %d %s, want 200 info", rec.Code, rec.Body)
+	}
+
+	rec = serveAdmin(t, level, http.MethodPut, "Bearer "+adminToken, `{"level":"DEBUG"}`)
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"level":"debug"}` {
+		t.Fatalf("PUT = %d %s, want 200 debug", rec.Code, rec.Body)
+	}
+	if level.Level() != slog.LevelDebug {
+		t.Errorf("level = %s, want DEBUG", level.Level())
+	}
+
+	rec = serveAdmin(t, level, http.MethodGet, "Bearer "+adminToken, "")
+	if strings.TrimSpace(rec.Body.String()) != `{"level":"debug"}` {
+		t.Errorf("GET after PUT = %s, want debug", rec.Body)
+	}
+}
+
+func TestAdminRejectsInvalidLevel(t *testing.T) {
+	for name, body := range map[string]string{
+		"unknown level": `{"level":"verbose"}`,
+		"empty level":   `{"level":""}`,
+		"no level":      `{}`,
+		"bad JSON":      `{"level":`,
+		"too large":     `{"level":"` + strings.Repeat("x", 2<<10) + `"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			level := new(slog.LevelVar)
+			level.Set(slog.LevelWarn)
+
+			rec := serveAdmin(t, level, http.MethodPut, "Bearer "+adminToken, body)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", rec.Code)
+			}
+			if level.Level() != slog.LevelWarn {
+				t.Errorf("level changed to %s", level.Level())
+			}
+		})
+	}
+}
+
+func TestAdminRequiresToken(t *testing.T) {
+	for name, auth := range map[string]string{
+		"no header":    "",
+		"wrong token":  "Bearer nope",
+		"token prefix": "Bearer " + adminToken[:3],
+		"basic auth":   "Basic " + adminToken,
+		"bare token":   adminToken,
+	} {
+		t.Run(name, func(t *testing.T) {
+			level := new(slog.LevelVar)
+			for _, method := range []string{http.MethodGet, http.MethodPut} {
+				rec := serveAdmin(t, level, method, auth, `{"level":"debug"}`)
+				if rec.Code != http.StatusUnauthorized {
+					t.Errorf("%s status = %d, want 401", method, rec.Code)
+				}
+				if rec.Header().Get("WWW-Authenticate") == "" {
+					t.Errorf("%s: no WWW-Authenticate header", method)
+				}
+			}
+			if level.Level() != slog.LevelInfo {
+				t.Errorf("level changed to %s without a valid token", level.Level())
+			}
+		})
+	}
+}
+
+func TestAdminEmptyTokenRejectsEveryone(t *testing.T) {
+	s := NewServer(0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	NewAdminHandler("", new(slog.LevelVar), s.logger).Register(s)
+
+	for _, auth := range []string{"", "Bearer ", "Bearer"} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/log-level", nil)
+		req.Header.Set("Authorization", auth)
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status = %d, want 401", auth, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/input"
+)
+
+// AlertHandler — CRUD правил оповещений:
+//
+//	GET    /alerts/rules
+//	POST   /alerts/rules
+//	GET    /alerts/rules/{id}
+//	PUT    /alerts/rules/{id}
+//	DELETE /alerts/rules/{id}
+//
+// Правила задают адреса, на которые сервер шлёт POST, поэтому, как и /admin/*,
+// каждый запрос несёт Authorization: Bearer <ADMIN_TOKEN>.
+type AlertHandler struct {
+	token  string
+	alerts input.AlertService
+	logger *slog.Logger
+}
+
+func NewAlertHandler(token string, alerts input.AlertService, logger *slog.Logger) *AlertHandler {
+	return &AlertHandler{token: token, alerts: alerts, logger: logger}
+}
+
+func (h *AlertHandler) Register(s *Server) {
+	s.mux.HandleFunc("GET /alerts/rules", bearerAuth(h.token, h.logger, h.list))
+	s.mux.HandleFunc("POST /alerts/rules", bearerAuth(h.token, h.logger, h.create))
+	s.mux.HandleFunc("GET /alerts/rules/{id}", bearerAuth(h.token, h.logger, h.get))
+	s.mux.HandleFunc("PUT /alerts/rules/{id}", bearerAuth(h.token, h.logger, h.update))
+	s.mux.HandleFunc("DELETE /alerts/rules/{id}", bearerAuth(h.token, h.logger, h.delete))
+}
+
+func (h *AlertHandler) list(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.alerts.ListRules(r.Context())
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	if rules == nil {
+		rules = []models.AlertRule{}
+	}
+	writeJSON(w, http.StatusOK, rules)
+}
+
+func (h *AlertHandler) get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	rule, err := h.alerts.GetRule(r.Context(), id)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rule)
+}
+
+func (h *AlertHandler) create(w http.ResponseWriter, r *http.Request) {
+	rule := models.AlertRule{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	created, err := h.alerts.CreateRule(r.Context(), rule)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *AlertHandler) update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	// PUT заменяет правило целиком; без поля enabled правило включено, как при создании
+	rule := models.AlertRule{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	rule.ID = id
+	updated, err := h.alerts.UpdateRule(r.Context(), rule)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (h *AlertHandler) delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.alerts.DeleteRule(r.Context(), id); err != nil {
+		h.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AlertHandler) fail(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		writeError(w, http.StatusNotFound, "rule not found")
+	case errors.Is(err, models.ErrInvalidRule):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error("Alert API failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return 0, false
+	}
+	return id, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/input"
+)
+
+// ruleCalls — input.AlertService, который только считает вызовы.
+type ruleCalls struct {
+	input.AlertService
+	calls int
+}
+
+func (s *ruleCalls) ListRules(context.Context) ([]models.AlertRule, error) {
+	s.calls++
+	return nil, nil
+}
+
+func (s *ruleCalls) GetRule(_ context.Context, id int64) (models.AlertRule, error) {
+	s.calls++
+	return models.AlertRule{ID: id}, nil
+}
+
+func (s *ruleCalls) CreateRule(_ context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	s.calls++
+	return rule, nil
+}
+
+func (s *ruleCalls) UpdateRule(_ context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	s.calls++
+	return rule, nil
+}
+
+func (s *ruleCalls) DeleteRule(context.Context, int64) error {
+	s.calls++
+	return nil
+}
+
+// Правила задают адреса вебхуков, поэтому каждый маршрут требует токен.
+func TestAlertRoutesRequireToken(t *testing.T) {
+	routes := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/alerts/rules", http.StatusOK},
+		{http.MethodPost, "/alerts/rules", http.StatusCreated},
+		{http.MethodGet, "/alerts/rules/1", http.StatusOK},
+		{http.MethodPut, "/alerts/rules/1", http.StatusOK},
+		{http.MethodDelete, "/alerts/rules/1", http.StatusNoContent},
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			alerts := &ruleCalls{}
+			s := NewServer(0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			NewAlertHandler(adminToken, alerts, s.logger).Register(s)
+
+			for _, auth := range []string{"", "Bearer nope", adminToken} {
+				req := httptest.NewRequest(route.method, route.path, strings.NewReader(`{"webhook_url":"http://hooks.local"}`))
+				if auth != "" {
+					req.Header.Set("Authorization", auth)
+				}
+				rec := httptest.NewRecorder()
+				s.mux.ServeHTTP(rec, req)
+				if rec.Code != http.StatusUnauthorized {
+					t.Errorf("Authorization %q: status = %d, want 401", auth, rec.Code)
+				}
+			}
+			if alerts.calls != 0 {
+				t.Fatalf("service called %d times without a valid token", alerts.calls)
+			}
+
+			req := httptest.NewRequest(route.method, route.path, strings.NewReader(`{"webhook_url":"http://hooks.local"}`))
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			rec := httptest.NewRecorder()
+			s.mux.ServeHTTP(rec, req)
+			if rec.Code != route.status || alerts.calls != 1 {
+				t.Errorf("with token: status = %d, calls = %d; want %d, 1", rec.Code, alerts.calls, route.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"marketflow/internal/adapters/output/export"
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/input"
+	"marketflow/internal/domain/ports/output"
+)
+
+// ExportHandler отдаёт историю агрегатов файлом:
+//
+//	GET /export/aggregates?pair=BTCUSDT&from=2024-01-01&to=2024-04-01[&exchange=exchange1][&resolution=1h][&format=csv|jsonl|parquet]
+//
+// Строки пишутся в ответ по мере чтения из базы.
+type ExportHandler struct {
+	exports input.ExportService
+	logger  *slog.Logger
+}
+
+func NewExportHandler(exports input.ExportService, logger *slog.Logger) *ExportHandler {
+	return &ExportHandler{exports: exports, logger: logger}
+}
+
+func (h *ExportHandler) Register(s *Server) {
+	s.mux.HandleFunc("GET /export/aggregates", h.aggregates)
+}
+
+func (h *ExportHandler) aggregates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+
+	q := output.AggregateQuery{
+		Pair:     strings.ToUpper(query.Get("pair")),
+		Exchange: query.Get("exchange"),
+	}
+	var err error
+	if q.From, err = export.ParseTime(query.Get("from")); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.To, err = export.ParseTime(query.Get("to")); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if raw := query.Get("resolution"); raw != "" {
+		if q.Resolution, err = time.ParseDuration(raw); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid resolution")
+			return
+		}
+	}
+
+	// Проверяем формат до запроса, а сам writer создаём на первой строке:
+	// пока ничего не записано, ошибку запроса ещё можно вернуть статусом.
+	if !export.ValidFormat(format) {
+		writeError(w, http.StatusBadRequest, "format must be csv, jsonl or parquet")
+		return
+	}
+
+	var writer export.AggregateWriter
+	start := func() error {
+		w.Header().Set("Content-Type", export.ContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, strings.ToLower(q.Pair), format))
+		writer, err = export.NewAggregateWriter(w, format)
+		return err
+	}
+
+	err = h.exports.ExportAggregates(r.Context(), q, func(agg models.Aggregate) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return writer.Write(agg)
+	})
+	if err != nil {
+		if writer == nil {
+			if errors.Is(err, models.ErrInvalidQuery) {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			h.logger.Error("Export failed", "pair", q.Pair, "error", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		// Заголовки уже отправлены — обрываем ответ, клиент увидит неполный файл
+		h.logger.Error("Export interrupted", "pair", q.Pair, "error", err)
+		return
+	}
+
+	if writer == nil {
+		if err := start(); err != nil {
+			h.logger.Error("Export failed", "pair", q.Pair, "error", err)
+			return
+		}
+	}
+	if err := writer.Close(); err != nil {
+		h.logger.Error("Export failed", "pair", q.Pair, "error", err)
+	}
+}
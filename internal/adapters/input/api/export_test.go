@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+// exportFunc — input.ExportService из функции.
+type exportFunc func(ctx context.Context, q output.AggregateQuery, fn func(models.Aggregate) error) error
+
+func (f exportFunc) ExportAggregates(ctx context.Context, q output.AggregateQuery, fn func(models.Aggregate) error) error {
+	return f(ctx, q, fn)
+}
+
+func serveExport(t *testing.T, exports exportFunc, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	s := NewServer(0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	NewExportHandler(exports, s.logger).Register(s)
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export/aggregates?"+query, nil))
+	return rec
+}
+
+func TestExportHandlerErrors(t *testing.T) {
+	invalid := exportFunc(func(context.Context, output.AggregateQuery, func(models.Aggregate) error) error {
+		return fmt.Errorf("%w: from is required", models.ErrInvalidQuery)
+	})
+	broken := exportFunc(func(context.Context, output.AggregateQuery, func(models.Aggregate) error) error {
+		return errors.New("connection reset")
+	})
+
+	tests := []struct {
+		name    string
+		exports exportFunc
+		query   string
+		status  int
+	}{
+		{"bad from", invalid, "pair=BTCUSDT&from=yesterday", http.StatusBadRequest},
+		{"bad to", invalid, "pair=BTCUSDT&from=2024-01-01&to=soon", http.StatusBadRequest},
+		{"bad resolution", invalid, "pair=BTCUSDT&from=2024-01-01&resolution=hourly", http.StatusBadRequest},
+		{"bad format", invalid, "pair=BTCUSDT&from=2024-01-01&format=xlsx", http.StatusBadRequest},
+		{"invalid query", invalid, "pair=BTCUSDT", http.StatusBadRequest},
+		// Причина сбоя базы клиенту не показывается
+		{"repository error", broken, "pair=BTCUSDT&from=2024-01-01", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		rec := serveExport(t, tt.exports, tt.query)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		if strings.Contains(rec.Body.String(), "connection reset") {
+			t.Errorf("%s: body leaks the repository error: %s", tt.name, rec.Body)
+		}
+	}
+}
+
+func TestExportHandlerStreamsCSV(t *testing.T) {
+	var got output.AggregateQuery
+	exports := exportFunc(func(ctx context.Context, q output.AggregateQuery, fn func(models.Aggregate) error) error {
+		got = q
+		price := models.MustDecimal("100.5")
+		return fn(models.Aggregate{Exchange: "exchange1", Pair: q.Pair, Average: price, Min: price, Max: price, Count: 1, Timestamp: q.From})
+	})
+
+	rec := serveExport(t, exports, "pair=btcusdt&from=2024-01-01&resolution=1h")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got.Pair != "BTCUSDT" || got.Resolution != time.Hour || !got.From.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("query = %+v", got)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.Contains(cd, `filename="btcusdt.csv"`) {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	if !strings.Contains(rec.Body.String(), "100.5") {
+		t.Errorf("body has no row: %s", rec.Body)
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Server — HTTP API сервиса: метрики и служебные эндпоинты.
+type Server struct {
+	server *http.Server
+	mux    *http.ServeMux
+	logger *slog.Logger
+}
+
+func NewServer(port int, logger *slog.Logger) *Server {
+	mux := http.NewServeMux()
+	s := &Server{
+		mux:    mux,
+		logger: logger,
+		server: &http.Server{
+			Addr:              fmt.Sprintf(":%d", port),
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+
+	mux.Handle("GET /metrics", promhttp.Handler())
+	mux.HandleFunc("GET /health", s.health)
+
+	return s
+}
+
+// Handle регистрирует дополнительный обработчик (используется другими адаптерами).
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Start запускает сервер и останавливает его при отмене контекста.
+func (s *Server) Start(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			s.logger.Error("API server shutdown failed", "error", err)
+		}
+	}()
+
+	s.logger.Info("Starting API server", "addr", s.server.Addr)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("api server: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"ok"}`))
+}
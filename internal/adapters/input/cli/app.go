@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Коды выхода
+const (
+	ExitOK      = 0 // успешно
+	ExitFailure = 1 // ошибка во время работы (нет соединения с БД, сбой записи...)
+	ExitUsage   = 2 // неверные аргументы
+	ExitConfig  = 3 // конфигурация не загружается или не проходит проверку
+)
+
+// GlobalOptions — флаги, общие для всех подкоманд. Их можно указывать
+// как до имени подкоманды, так и после: marketflow --port 9090 serve == marketflow serve --port 9090.
+type GlobalOptions struct {
+	ConfigFile string
+	Port       int
+	Mode       string
+	LogLevel   string
+	LogFormat  string
+	Exchanges  string
+}
+
+func (o *GlobalOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.ConfigFile, "config", "", "env file to load (default: .env)")
+	fs.IntVar(&o.Port, "port", 0, "API port, overrides API_PORT")
+	fs.StringVar(&o.Mode, "mode", "", "exchange mode: live or replay, overrides EXCHANGE_MODE")
+	fs.StringVar(&o.LogLevel, "log-level", "", "log level: debug, info, warn or error, overrides LOG_LEVEL")
+	fs.StringVar(&o.LogFormat, "log-format", "", "log format: text or json, overrides LOG_FORMAT")
+	fs.StringVar(&o.Exchanges, "exchanges", "", "exchanges to use: names from config or name=host:port, comma separated")
+}
+
+// merge переносит флаги, заданные после подкоманды; они важнее заданных до неё.
+func (o *GlobalOptions) merge(other GlobalOptions) {
+	if other.ConfigFile != "" {
+		o.ConfigFile = other.ConfigFile
+	}
+	if other.Port != 0 {
+		o.Port = other.Port
+	}
+	if other.Mode != "" {
+		o.Mode = other.Mode
+	}
+	if other.LogLevel != "" {
+		o.LogLevel = other.LogLevel
+	}
+	if other.LogFormat != "" {
+		o.LogFormat = other.LogFormat
+	}
+	if other.Exchanges != "" {
+		o.Exchanges = other.Exchanges
+	}
+}
+
+type Command struct {
+	Name    string
+	Args    string // строка аргументов для справки, например "[flags] FILE..."
+	Summary string
+	Flags   func(fs *flag.FlagSet) // собственные флаги подкоманды
+	Run     func(ctx context.Context, opts GlobalOptions, args []string) int
+}
+
+// App разбирает командную строку и запускает подкоманду; без подкоманды — defaultCommand.
+type App struct {
+	name           string
+	defaultCommand string
+	commands       []Command
+	stdout         io.Writer // справка по команде help
+	stderr         io.Writer
+}
+
+func NewApp(name, defaultCommand string, commands ...Command) *App {
+	return &App{
+		name:           name,
+		defaultCommand: defaultCommand,
+		commands:       commands,
+		stdout:         os.Stdout,
+		stderr:         os.Stderr,
+	}
+}
+
+// Run возвращает код выхода.
+func (a *App) Run(ctx context.Context, args []string) int {
+	var opts GlobalOptions
+
+	global := flag.NewFlagSet(a.name, flag.ContinueOnError)
+	global.SetOutput(a.stderr)
+	global.Usage = func() { a.Usage(a.stderr) }
+	opts.register(global)
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	args = global.Args()
+	name := a.defaultCommand
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		a.Usage(a.stdout)
+		return ExitOK
+	}
+
+	cmd, ok := a.command(name)
+	if !ok {
+		fmt.Fprintf(a.stderr, "unknown command %q\n\n", name)
+		a.Usage(a.stderr)
+		return ExitUsage
+	}
+
+	fs := flag.NewFlagSet(a.name+" "+cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage:\n  %s %s %s\n\n%s\n\nFlags:\n", a.name, cmd.Name, cmd.Args, cmd.Summary)
+		fs.PrintDefaults()
+	}
+	// Отдельная структура: регистрация флага сбрасывает переменную в значение по умолчанию
+	var after GlobalOptions
+	after.register(fs)
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	opts.merge(after)
+
+	return cmd.Run(ctx, opts, fs.Args())
+}
+
+func (a *App) command(name string) (Command, bool) {
+	for _, cmd := range a.commands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+	}
+	return Command{}, false
+}
+
+func (a *App) Usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage:\n  %s [global flags] <command> [flags]\n\nCommands:\n", a.name)
+	for _, cmd := range a.commands {
+		suffix := ""
+		if cmd.Name == a.defaultCommand {
+			suffix = " (default)"
+		}
+		fmt.Fprintf(w, "  %-13s %s%s\n", cmd.Name, cmd.Summary, suffix)
+	}
+
+	var opts GlobalOptions
+	fs := flag.NewFlagSet(a.name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	opts.register(fs)
+	fmt.Fprintln(w, "\nGlobal flags:")
+	fs.PrintDefaults()
+
+	fmt.Fprintf(w, "\nExit codes: %d ok, %d runtime failure, %d usage error, %d configuration error\n",
+		ExitOK, ExitFailure, ExitUsage, ExitConfig)
+	fmt.Fprintf(w, "Run '%s <command> --help' for command flags.\n", a.name)
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"io"
+	"slices"
+	"testing"
+)
+
+// testApp — приложение с командами serve (по умолчанию) и export; run запоминает,
+// с чем была вызвана команда.
+type testApp struct {
+	*App
+	command string
+	opts    GlobalOptions
+	args    []string
+	pair    string
+	code    int // что возвращает команда
+}
+
+func newTestApp() *testApp {
+	t := &testApp{}
+	run := func(name string) func(context.Context, GlobalOptions, []string) int {
+		return func(_ context.Context, opts GlobalOptions, args []string) int {
+			t.command, t.opts, t.args = name, opts, args
+			return t.code
+		}
+	}
+	t.App = NewApp("marketflow", "serve",
+		Command{Name: "serve", Run: run("serve")},
+		Command{Name: "export", Run: run("export"), Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&t.pair, "pair", "", "")
+		}},
+	)
+	t.stdout, t.stderr = io.Discard, io.Discard
+	return t
+}
+
+func TestRunMergesGlobalFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		command string
+		opts    GlobalOptions
+		rest    []string
+	}{
+		{nil, "serve", GlobalOptions{}, []string{}},
+		{[]string{"--port", "9090"}, "serve", GlobalOptions{Port: 9090}, []string{}},
+		{[]string{"--port", "9090", "serve"}, "serve", GlobalOptions{Port: 9090}, []string{}},
+		{[]string{"serve", "--port", "9090"}, "serve", GlobalOptions{Port: 9090}, []string{}},
+		// После подкоманды — важнее, чем до неё
+		{[]string{"--port", "1", "--mode", "replay", "serve", "--port", "2"}, "serve", GlobalOptions{Port: 2, Mode: "replay"}, []string{}},
+		{[]string{"--log-level", "debug", "serve", "--log-format", "json", "--config", "prod.env"}, "serve",
+			GlobalOptions{LogLevel: "debug", LogFormat: "json", ConfigFile: "prod.env"}, []string{}},
+		{[]string{"--exchanges", "exchange1", "export", "--pair", "BTCUSDT", "--exchanges", "test=127.0.0.1:1", "a.csv", "b.csv"}, "export",
+			GlobalOptions{Exchanges: "test=127.0.0.1:1"}, []string{"a.csv", "b.csv"}},
+	}
+	for _, tt := range tests {
+		app := newTestApp()
+		if code := app.Run(context.Background(), tt.args); code != ExitOK {
+			t.Errorf("%v: exit %d", tt.args, code)
+			continue
+		}
+		if app.command != tt.command || app.opts != tt.opts || !slices.Equal(app.args, tt.rest) {
+			t.Errorf("%v: ran %s with %+v %v, want %s with %+v %v", tt.args, app.command, app.opts, app.args, tt.command, tt.opts, tt.rest)
+		}
+	}
+
+	app := newTestApp()
+	app.Run(context.Background(), []string{"export", "--pair", "ETHUSDT"})
+	if app.pair != "ETHUSDT" {
+		t.Errorf("command flag pair = %q, want ETHUSDT", app.pair)
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int // код, который вернёт сама команда
+		want int
+	}{
+		{[]string{"unknown"}, ExitOK, ExitUsage},
+		{[]string{"--no-such-flag"}, ExitOK, ExitUsage},
+		{[]string{"--port", "x"}, ExitOK, ExitUsage},
+		{[]string{"serve", "--no-such-flag"}, ExitOK, ExitUsage},
+		{[]string{"serve", "--pair", "BTCUSDT"}, ExitOK, ExitUsage}, // флаг другой команды
+		{[]string{"--help"}, ExitFailure, ExitOK},
+		{[]string{"export", "--help"}, ExitFailure, ExitOK},
+		{[]string{"help"}, ExitFailure, ExitOK},
+		{[]string{"serve"}, ExitConfig, ExitConfig},
+		{[]string{"export"}, ExitFailure, ExitFailure},
+	}
+	for _, tt := range tests {
+		app := newTestApp()
+		app.code = tt.code
+		if got := app.Run(context.Background(), tt.args); got != tt.want {
+			t.Errorf("%v: exit %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
@@ -2,11 +2,7 @@ package cli
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
-	"os"
-	"os/signal"
-	"syscall"
 
 	"marketflow/internal/domain/ports/input"
 )
@@ -17,7 +13,7 @@ type CLIHandler struct {
 	ctx           context.Context
 }
 
-// NEW METHOD
+// ctx отменяется по SIGINT/SIGTERM (см. serve)
 func NewCLIHandler(ctx context.Context, marketService input.MarketService, logger *slog.Logger) *CLIHandler {
 	return &CLIHandler{
 		marketService: marketService,
@@ -26,31 +22,16 @@ func NewCLIHandler(ctx context.Context, marketService input.MarketService, logge
 	}
 }
 
-// логика обработки сигналов
+// Start запускает сервис и останавливает его при отмене контекста.
+// Возвращается, когда все обработчики сервиса завершились.
 func (h *CLIHandler) Start() error {
-	// Handle command line arguments
-	if len(os.Args) > 1 && os.Args[1] == "--help" {
-		fmt.Println("Usage:")
-		fmt.Println("  marketflow [--port <N>]")
-		fmt.Println("  marketflow --help")
-		fmt.Println()
-		fmt.Println("Options:")
-		fmt.Println("  --port N     Port number")
-		return nil
-	}
-
-	//
-
-	// Setup graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
 	go func() {
-		<-sigChan
-		fmt.Println("\nReceived shutdown signal, stopping gracefully...")
-		h.marketService.Stop()
+		<-h.ctx.Done()
+		h.logger.Info("Received shutdown signal, stopping gracefully...")
+		if err := h.marketService.Stop(); err != nil {
+			h.logger.Error("Stop failed", "error", err)
+		}
 	}()
 
-	// Start processing
 	return h.marketService.Start(h.ctx)
 }
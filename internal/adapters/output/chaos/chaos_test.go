@@ -0,0 +1,169 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/output/memory"
+	"marketflow/internal/adapters/output/tcp"
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+var (
+	_ output.ExchangeClient   = (*ExchangeClient)(nil)
+	_ output.RedisClient      = (*RedisClient)(nil)
+	_ output.MarketRepository = (*MarketRepository)(nil)
+)
+
+func TestOutageSchedule(t *testing.T) {
+	inj := newInjector(Faults{OutageEvery: 10 * time.Second, OutageFor: 2 * time.Second}, 1)
+	var now time.Time
+	inj.now = func() time.Time { return now }
+
+	tests := []struct {
+		elapsed time.Duration
+		outage  bool
+	}{
+		{0, false},
+		{9 * time.Second, false},
+		{10 * time.Second, true},
+		{11 * time.Second, true},
+		{12 * time.Second, false},
+		{21 * time.Second, true},
+		{25 * time.Second, false},
+	}
+	for _, tt := range tests {
+		now = inj.start.Add(tt.elapsed)
+		if got := inj.inOutage(); got != tt.outage {
+			t.Errorf("at %s: outage = %t, want %t", tt.elapsed, got, tt.outage)
+		}
+	}
+}
+
+func TestErrorsAreDeterministic(t *testing.T) {
+	ctx := context.Background()
+	run := func() []bool {
+		c := NewRedisClient(memory.NewRedisClient(), Faults{ErrorRate: 0.5}, 42)
+		var failed []bool
+		for range 50 {
+			failed = append(failed, errors.Is(c.ZAdd(ctx, "k", 1, "m"), ErrInjected))
+		}
+		return failed
+	}
+
+	first, second := run(), run()
+	failures := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("call %d: runs with the same seed differ", i)
+		}
+		if first[i] {
+			failures++
+		}
+	}
+	if failures == 0 || failures == len(first) {
+		t.Errorf("%d of %d calls failed with rate 0.5", failures, len(first))
+	}
+}
+
+func TestPartialWriteIsApplied(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewRedisClient()
+	c := NewRedisClient(store, Faults{PartialRate: 1}, 1)
+
+	if err := c.ZAdd(ctx, "k", 1, "m"); !errors.Is(err, ErrInjected) {
+		t.Fatalf("ZAdd error = %v, want ErrInjected", err)
+	}
+	if store.ZCard("k") != 1 {
+		t.Error("partial write was not applied")
+	}
+	// чтения частичными не бывают
+	if members, err := c.ZRangeByScore(ctx, "k", "-inf", "+inf"); err != nil || len(members) != 1 {
+		t.Errorf("ZRangeByScore = %v, %v", members, err)
+	}
+}
+
+func TestErrorSkipsWrite(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMarketRepo()
+	r := NewMarketRepository(repo, Faults{ErrorRate: 1}, 1)
+
+	if err := r.InsertMarketData(ctx, aggregateFixture()); !errors.Is(err, ErrInjected) {
+		t.Fatalf("InsertMarketData error = %v, want ErrInjected", err)
+	}
+	if len(repo.Aggregates()) != 0 {
+		t.Error("failed write was applied")
+	}
+}
+
+func TestLatencyHonorsContext(t *testing.T) {
+	c := NewRedisClient(memory.NewRedisClient(), Faults{Latency: time.Minute}, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.Get(ctx, "k"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get error = %v, want deadline exceeded", err)
+	}
+}
+
+// Внедрённый обрыв закрывает и настоящее TCP-соединение, а не только перестаёт читать.
+func TestInjectedDisconnectClosesConnection(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	ex := models.ExchangeConfig{Name: "ex1", Host: host, Port: port}
+	c := NewExchangeClient(tcp.NewTCPExchangeClient(slog.New(slog.NewTextHandler(io.Discard, nil)), ""), Faults{}, 100*time.Millisecond, 1)
+	defer c.Close()
+	if err := c.Connect(ex); err != nil {
+		t.Fatal(err)
+	}
+	server := <-accepted
+	defer server.Close()
+
+	if err := c.Listen(context.Background(), make(chan models.PriceUpdate), ex); !errors.Is(err, ErrInjected) {
+		t.Fatalf("Listen error = %v, want ErrInjected", err)
+	}
+
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := server.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("server read after injected disconnect: %v, want EOF", err)
+	}
+}
+
+func TestConnectLatencyStopsOnClose(t *testing.T) {
+	c := NewExchangeClient(memory.NewExchangeClient(), Faults{Latency: time.Minute}, 0, 1)
+	done := make(chan error, 1)
+	go func() { done <- c.Connect(models.ExchangeConfig{Name: "ex1"}) }()
+
+	time.Sleep(20 * time.Millisecond)
+	c.Close()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Connect error = %v, want canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Connect kept waiting out the latency after Close")
+	}
+}
+
+func aggregateFixture() models.Aggregate {
+	agg, _ := models.NewAggregate("ex1", "BTCUSDT", []models.Decimal{models.MustDecimal("100")}, time.Unix(1_700_000_000, 0))
+	return agg
+}
@@ -0,0 +1,114 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+// outagePoll — как часто Listen проверяет расписание недоступности.
+const outagePoll = 50 * time.Millisecond
+
+// disconnecter — клиент, умеющий оборвать сессию одной биржи (TCP, memory).
+type disconnecter interface {
+	Disconnect(exchange string)
+}
+
+// ExchangeClient вносит сбои в работу с биржами: ErrorRate и недоступность —
+// отказ Connect, Latency — пауза перед каждым тиком, обрыв сессии через
+// DisconnectEvery и в начале каждого окна недоступности.
+type ExchangeClient struct {
+	next            output.ExchangeClient
+	inj             *injector
+	disconnectEvery time.Duration
+
+	// У Connect нет контекста: задержку прерывает Close
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewExchangeClient(next output.ExchangeClient, faults Faults, disconnectEvery time.Duration, seed int64) *ExchangeClient {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ExchangeClient{
+		next:            next,
+		inj:             newInjector(faults, seed),
+		disconnectEvery: disconnectEvery,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+}
+
+func (c *ExchangeClient) Connect(config models.ExchangeConfig) error {
+	if err := c.inj.before(c.ctx); err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", config.Name, err)
+	}
+	return c.next.Connect(config)
+}
+
+// Listen пропускает тики через себя. При внедрённом обрыве рвёт и настоящую сессию
+// биржи (если вложенный клиент это умеет) и возвращает ErrInjected, когда вложенный
+// Listen вышел: иначе старое соединение жило бы рядом с новым до таймаута чтения.
+func (c *ExchangeClient) Listen(ctx context.Context, updates chan<- models.PriceUpdate, exchange models.ExchangeConfig) error {
+	innerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	inner := make(chan models.PriceUpdate)
+	errc := make(chan error, 1)
+	go func() { errc <- c.next.Listen(innerCtx, inner, exchange) }()
+
+	var disconnect <-chan time.Time
+	if c.disconnectEvery > 0 {
+		timer := time.NewTimer(c.disconnectEvery)
+		defer timer.Stop()
+		disconnect = timer.C
+	}
+	var outage <-chan time.Time
+	if c.inj.faults.OutageEvery > 0 {
+		ticker := time.NewTicker(outagePoll)
+		defer ticker.Stop()
+		outage = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return <-errc
+		case err := <-errc:
+			return err
+		case <-disconnect:
+			c.drop(exchange.Name, cancel, errc)
+			return fmt.Errorf("disconnect: %w", ErrInjected)
+		case <-outage:
+			if c.inj.inOutage() {
+				c.drop(exchange.Name, cancel, errc)
+				return fmt.Errorf("outage: %w", ErrInjected)
+			}
+		case update := <-inner:
+			if !c.inj.wait(ctx) {
+				return <-errc
+			}
+			select {
+			case updates <- update:
+			case <-ctx.Done():
+				return <-errc
+			}
+		}
+	}
+}
+
+// drop обрывает сессию биржи и ждёт выхода вложенного Listen.
+func (c *ExchangeClient) drop(exchange string, cancel context.CancelFunc, errc <-chan error) {
+	cancel()
+	if d, ok := c.next.(disconnecter); ok {
+		d.Disconnect(exchange)
+	}
+	<-errc
+}
+
+func (c *ExchangeClient) Close() error {
+	c.cancel()
+	return c.next.Close()
+}
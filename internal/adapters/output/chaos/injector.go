@@ -0,0 +1,102 @@
+// Package chaos — декораторы output-портов, внедряющие сбои: задержки, ошибки,
+// частичные записи, медленное чтение, обрывы и периодическую недоступность.
+// Только для тестов и стендов: serve включает их только при APP_ENV=development|dev|local|test|staging.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrInjected возвращается вместо результата при внедрённом сбое.
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Faults — сбои одного порта. Нулевое значение ничего не меняет.
+type Faults struct {
+	Latency     time.Duration // задержка каждого вызова (для биржи — каждого тика: медленное чтение)
+	ErrorRate   float64       // доля вызовов, которые не выполняются и возвращают ErrInjected
+	PartialRate float64       // доля записей, которые выполняются, но возвращают ErrInjected
+	// Расписание недоступности: начиная с OutageEvery, первые OutageFor каждого
+	// периода OutageEvery все вызовы завершаются ErrInjected.
+	OutageEvery time.Duration
+	OutageFor   time.Duration
+}
+
+func (f Faults) Enabled() bool {
+	return f != Faults{}
+}
+
+// injector принимает решения о сбоях. С одинаковым seed и порядком вызовов
+// решения повторяются; расписание отсчитывается от создания.
+type injector struct {
+	faults Faults
+	start  time.Time
+	now    func() time.Time
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newInjector(faults Faults, seed int64) *injector {
+	return &injector{
+		faults: faults,
+		start:  time.Now(),
+		now:    time.Now,
+		rnd:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// wait выдерживает Latency; false — контекст отменён раньше.
+func (i *injector) wait(ctx context.Context) bool {
+	if i.faults.Latency <= 0 {
+		return true
+	}
+	timer := time.NewTimer(i.faults.Latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (i *injector) inOutage() bool {
+	every := i.faults.OutageEvery
+	if every <= 0 || i.faults.OutageFor <= 0 {
+		return false
+	}
+	elapsed := i.now().Sub(i.start)
+	return elapsed >= every && elapsed%every < i.faults.OutageFor
+}
+
+func (i *injector) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rnd.Float64() < rate
+}
+
+// before вызывается перед операцией: задержка, затем недоступность или случайная ошибка.
+func (i *injector) before(ctx context.Context) error {
+	if !i.wait(ctx) {
+		return ctx.Err()
+	}
+	if i.inOutage() || i.roll(i.faults.ErrorRate) {
+		return ErrInjected
+	}
+	return nil
+}
+
+// after для записей: операция выполнена, но иногда об этом не узнаёт вызывающий.
+func (i *injector) after(err error) error {
+	if err == nil && i.roll(i.faults.PartialRate) {
+		return ErrInjected
+	}
+	return err
+}
@@ -0,0 +1,25 @@
+package chaos
+
+import (
+	"context"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+// MarketRepository вносит сбои в запись агрегатов.
+type MarketRepository struct {
+	next output.MarketRepository
+	inj  *injector
+}
+
+func NewMarketRepository(next output.MarketRepository, faults Faults, seed int64) *MarketRepository {
+	return &MarketRepository{next: next, inj: newInjector(faults, seed)}
+}
+
+func (r *MarketRepository) InsertMarketData(ctx context.Context, agg models.Aggregate) error {
+	if err := r.inj.before(ctx); err != nil {
+		return err
+	}
+	return r.inj.after(r.next.InsertMarketData(ctx, agg))
+}
@@ -0,0 +1,60 @@
+package chaos
+
+import (
+	"context"
+	"time"
+
+	"marketflow/internal/domain/ports/output"
+)
+
+// RedisClient вносит сбои в вызовы Redis; PartialRate действует на записи.
+type RedisClient struct {
+	next output.RedisClient
+	inj  *injector
+}
+
+func NewRedisClient(next output.RedisClient, faults Faults, seed int64) *RedisClient {
+	return &RedisClient{next: next, inj: newInjector(faults, seed)}
+}
+
+func (c *RedisClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	if err := c.inj.before(ctx); err != nil {
+		return err
+	}
+	return c.inj.after(c.next.Set(ctx, key, value, expiration))
+}
+
+func (c *RedisClient) Get(ctx context.Context, key string) (string, error) {
+	if err := c.inj.before(ctx); err != nil {
+		return "", err
+	}
+	return c.next.Get(ctx, key)
+}
+
+func (c *RedisClient) ZAdd(ctx context.Context, key string, score float64, member interface{}) error {
+	if err := c.inj.before(ctx); err != nil {
+		return err
+	}
+	return c.inj.after(c.next.ZAdd(ctx, key, score, member))
+}
+
+func (c *RedisClient) ZRangeByScore(ctx context.Context, key string, min, max string) ([]string, error) {
+	if err := c.inj.before(ctx); err != nil {
+		return nil, err
+	}
+	return c.next.ZRangeByScore(ctx, key, min, max)
+}
+
+func (c *RedisClient) ZRemRangeByScore(ctx context.Context, key string, min, max string) error {
+	if err := c.inj.before(ctx); err != nil {
+		return err
+	}
+	return c.inj.after(c.next.ZRemRangeByScore(ctx, key, min, max))
+}
+
+func (c *RedisClient) Publish(ctx context.Context, channel string, message interface{}) error {
+	if err := c.inj.before(ctx); err != nil {
+		return err
+	}
+	return c.inj.after(c.next.Publish(ctx, channel, message))
+}
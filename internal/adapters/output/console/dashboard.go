@@ -0,0 +1,212 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+const (
+	dashboardRefresh = 500 * time.Millisecond
+	tickRateWindow   = 10 * time.Second
+	quoteStaleAfter  = 5 * time.Second // биржа без тиков дольше — stale
+)
+
+// ANSI
+const (
+	ansiClear = "\033[H\033[2J"
+	ansiBold  = "\033[1m"
+	ansiGreen = "\033[32m"
+	ansiRed   = "\033[31m"
+	ansiDim   = "\033[2m"
+	ansiReset = "\033[0m"
+)
+
+type dashboardRow struct {
+	last     models.Decimal
+	lastAt   time.Time
+	open     models.Decimal // первая цена за сессию tail, от неё считается изменение
+	opened   bool
+	arrivals []time.Time // времена тиков за tickRateWindow
+}
+
+// Dashboard — таблица пар × бирж, обновляемая по потоку тиков.
+// Подключается к Tail как обычный PricePublisher.
+type Dashboard struct {
+	out io.Writer
+
+	mu        sync.Mutex
+	rows      map[string]map[string]*dashboardRow // pair -> exchange -> row
+	connected bool
+	lastErr   error
+	started   time.Time
+}
+
+func NewDashboard(out io.Writer) *Dashboard {
+	return &Dashboard{
+		out:     out,
+		rows:    make(map[string]map[string]*dashboardRow),
+		started: time.Now(),
+	}
+}
+
+func (d *Dashboard) PublishTick(update models.PriceUpdate) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	row := d.row(update.Pair, update.Exchange)
+	now := time.Now()
+	if !row.opened {
+		row.open, row.opened = update.Price, true
+	}
+	row.last = update.Price
+	row.lastAt = now
+	row.arrivals = append(row.arrivals, now)
+	return nil
+}
+
+// PublishAggregate — изменение считается от открытия сессии, агрегаты не нужны.
+func (d *Dashboard) PublishAggregate(models.Aggregate) error { return nil }
+
+// SetConnected вызывается Tail при подключении и обрыве соединения.
+func (d *Dashboard) SetConnected(connected bool, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.connected = connected
+	d.lastErr = err
+}
+
+func (d *Dashboard) row(pair, exchange string) *dashboardRow {
+	byExchange, ok := d.rows[pair]
+	if !ok {
+		byExchange = make(map[string]*dashboardRow)
+		d.rows[pair] = byExchange
+	}
+	row, ok := byExchange[exchange]
+	if !ok {
+		row = &dashboardRow{}
+		byExchange[exchange] = row
+	}
+	return row
+}
+
+// Run перерисовывает таблицу, пока не отменён контекст.
+func (d *Dashboard) Run(ctx context.Context) {
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+
+	for {
+		d.render(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dashboard) render(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString(ansiClear)
+
+	status := ansiGreen + "connected" + ansiReset
+	if !d.connected {
+		status = ansiRed + "disconnected" + ansiReset
+		if d.lastErr != nil {
+			status += ansiDim + " (" + d.lastErr.Error() + ")" + ansiReset
+		}
+	}
+	fmt.Fprintf(&b, "%sMarketFlow%s  %s  %s\n\n", ansiBold, ansiReset, now.Format("15:04:05"), status)
+	fmt.Fprintf(&b, "%s%-10s %-12s %16s %11s %10s %8s  %-8s%s\n", ansiBold,
+		"PAIR", "EXCHANGE", "LAST", "SESSION CHG", "SPREAD", "TICK/S", "STATUS", ansiReset)
+
+	pairs := make([]string, 0, len(d.rows))
+	for pair := range d.rows {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+
+	for _, pair := range pairs {
+		byExchange := d.rows[pair]
+		exchanges := make([]string, 0, len(byExchange))
+		for ex := range byExchange {
+			exchanges = append(exchanges, ex)
+		}
+		sort.Strings(exchanges)
+
+		spread := spreadOf(byExchange, now)
+		for i, ex := range exchanges {
+			row := byExchange[ex]
+
+			// Тики старше окна больше не участвуют в частоте
+			cutoff := now.Add(-tickRateWindow)
+			for len(row.arrivals) > 0 && row.arrivals[0].Before(cutoff) {
+				row.arrivals = row.arrivals[1:]
+			}
+			window := math.Min(tickRateWindow.Seconds(), math.Max(now.Sub(d.started).Seconds(), 1))
+			rate := float64(len(row.arrivals)) / window
+
+			change, color := "", ""
+			if open := row.open.Float64(); open > 0 {
+				pct := (row.last.Float64() - open) / open * 100
+				change = fmt.Sprintf("%+.2f%%", pct)
+				switch {
+				case pct > 0:
+					color = ansiGreen
+				case pct < 0:
+					color = ansiRed
+				}
+			}
+
+			pairCol, spreadCol := "", ""
+			if i == 0 {
+				pairCol = pair
+				if spread >= 0 {
+					spreadCol = fmt.Sprintf("%.1f bps", spread)
+				}
+			}
+
+			state := ansiGreen + "live" + ansiReset
+			if now.Sub(row.lastAt) > quoteStaleAfter {
+				state = ansiRed + "stale " + now.Sub(row.lastAt).Round(time.Second).String() + ansiReset
+			}
+
+			fmt.Fprintf(&b, "%-10s %-12s %16s %s%11s%s %10s %8.1f  %s\n",
+				pairCol, ex, row.last, color, change, ansiReset, spreadCol, rate, state)
+		}
+	}
+
+	if len(pairs) == 0 {
+		b.WriteString(ansiDim + "waiting for ticks..." + ansiReset + "\n")
+	}
+
+	io.WriteString(d.out, b.String())
+}
+
+// spreadOf — разница между максимальной и минимальной свежей ценой пары в bps;
+// -1, если свежих котировок меньше двух.
+func spreadOf(byExchange map[string]*dashboardRow, now time.Time) float64 {
+	low, high, n := math.MaxFloat64, 0.0, 0
+	for _, row := range byExchange {
+		if now.Sub(row.lastAt) > quoteStaleAfter {
+			continue
+		}
+		p := row.last.Float64()
+		low, high = math.Min(low, p), math.Max(high, p)
+		n++
+	}
+	if n < 2 || low <= 0 {
+		return -1
+	}
+	return (high - low) / ((high + low) / 2) * 10000
+}
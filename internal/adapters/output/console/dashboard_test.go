@@ -0,0 +1,122 @@
+package console
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+var ansi = regexp.MustCompile("\033\\[[0-9;]*[A-Za-z]")
+
+// screen рисует таблицу на момент now и возвращает строки без ANSI-кодов.
+func screen(d *Dashboard, now time.Time) []string {
+	var b strings.Builder
+	d.out = &b
+	d.render(now)
+	return strings.Split(ansi.ReplaceAllString(b.String(), ""), "\n")
+}
+
+// line возвращает строку таблицы биржи exchange; поля через пробелы.
+func line(t *testing.T, lines []string, exchange string) []string {
+	t.Helper()
+	for _, l := range lines {
+		if fields := strings.Fields(l); len(fields) > 1 && (fields[0] == exchange || fields[1] == exchange) {
+			return fields
+		}
+	}
+	t.Fatalf("no row for %s in\n%s", exchange, strings.Join(lines, "\n"))
+	return nil
+}
+
+func tickOf(exchange, price string) models.PriceUpdate {
+	return models.PriceUpdate{Exchange: exchange, Pair: "BTCUSDT", Price: models.MustDecimal(price)}
+}
+
+// Изменение считается от первой цены сессии, агрегаты его не сдвигают.
+func TestDashboardChangeSinceSessionOpen(t *testing.T) {
+	d := NewDashboard(nil)
+	d.PublishTick(tickOf("exchange1", "100"))
+	d.PublishAggregate(models.Aggregate{Exchange: "exchange1", Pair: "BTCUSDT", Average: models.MustDecimal("108")})
+	d.PublishTick(tickOf("exchange1", "110"))
+
+	lines := screen(d, time.Now())
+	if !strings.Contains(lines[2], "SESSION CHG") {
+		t.Errorf("header = %q, want a SESSION CHG column", lines[2])
+	}
+	if row := line(t, lines, "exchange1"); row[3] != "+10.00%" {
+		t.Errorf("change = %s, want +10.00%%", row[3])
+	}
+
+	d.PublishTick(tickOf("exchange1", "95"))
+	if row := line(t, screen(d, time.Now()), "exchange1"); row[3] != "-5.00%" {
+		t.Errorf("change = %s, want -5.00%%", row[3])
+	}
+}
+
+func TestDashboardSpread(t *testing.T) {
+	d := NewDashboard(nil)
+	d.PublishTick(tickOf("exchange1", "100"))
+	now := time.Now()
+
+	// Одна котировка — спреда нет
+	if row := line(t, screen(d, now), "BTCUSDT"); strings.Contains(strings.Join(row, " "), "bps") {
+		t.Errorf("row = %v, want no spread for a single quote", row)
+	}
+
+	d.PublishTick(tickOf("exchange2", "101"))
+	if row := line(t, screen(d, time.Now()), "BTCUSDT"); row[4]+" "+row[5] != "99.5 bps" {
+		t.Errorf("spread = %v, want 99.5 bps", row[4:6])
+	}
+
+	// Устаревшая котировка в спред не входит
+	if row := line(t, screen(d, time.Now().Add(2*quoteStaleAfter)), "BTCUSDT"); strings.Contains(strings.Join(row, " "), "bps") {
+		t.Errorf("row = %v, want no spread for stale quotes", row)
+	}
+}
+
+func TestDashboardTickRate(t *testing.T) {
+	d := NewDashboard(nil)
+	d.started = time.Now().Add(-tickRateWindow)
+	for range 20 {
+		d.PublishTick(tickOf("exchange1", "100"))
+	}
+
+	if row := line(t, screen(d, time.Now()), "exchange1"); row[4] != "2.0" {
+		t.Errorf("rate = %s, want 2.0 (20 ticks in %s)", row[4], tickRateWindow)
+	}
+	// Тики вышли из окна
+	if row := line(t, screen(d, time.Now().Add(tickRateWindow+time.Second)), "exchange1"); row[4] != "0.0" {
+		t.Errorf("rate = %s, want 0.0", row[4])
+	}
+}
+
+func TestDashboardStatus(t *testing.T) {
+	d := NewDashboard(nil)
+	if lines := screen(d, time.Now()); !strings.Contains(lines[0], "disconnected") || !strings.Contains(lines[3], "waiting for ticks") {
+		t.Errorf("screen before connecting:\n%s", strings.Join(lines, "\n"))
+	}
+
+	d.SetConnected(true, nil)
+	d.PublishTick(tickOf("exchange1", "100"))
+	now := time.Now()
+	lines := screen(d, now)
+	if !strings.Contains(lines[0], "connected") || strings.Contains(lines[0], "disconnected") {
+		t.Errorf("status line = %q, want connected", lines[0])
+	}
+	if row := line(t, lines, "exchange1"); row[len(row)-1] != "live" {
+		t.Errorf("row = %v, want live", row)
+	}
+
+	d.SetConnected(false, errors.New("connection refused"))
+	lines = screen(d, now.Add(quoteStaleAfter+2*time.Second))
+	if !strings.Contains(lines[0], "disconnected (connection refused)") {
+		t.Errorf("status line = %q, want the disconnect reason", lines[0])
+	}
+	if row := line(t, lines, "exchange1"); strings.Join(row[len(row)-2:], " ") != "stale 7s" {
+		t.Errorf("row = %v, want stale 7s", row)
+	}
+}
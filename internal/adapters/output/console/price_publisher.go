@@ -2,41 +2,47 @@ package console
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
-	"strconv"
+	"os"
 
 	"marketflow/internal/domain/models"
 )
 
+// ConsolePricePublisher печатает тики и агрегаты в stdout.
 type ConsolePricePublisher struct {
 	logger *slog.Logger
+	out    io.Writer
 }
 
 func NewConsolePricePublisher(logger *slog.Logger) *ConsolePricePublisher {
+	return NewConsolePricePublisherTo(os.Stdout, logger)
+}
+
+func NewConsolePricePublisherTo(out io.Writer, logger *slog.Logger) *ConsolePricePublisher {
 	return &ConsolePricePublisher{
 		logger: logger,
+		out:    out,
 	}
 }
 
-func (p *ConsolePricePublisher) PublishRedis(key, value string, update models.PriceUpdate) error {
-	fValue, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		p.logger.Error("Ошибка:", err)
-		return err
-	}
-
-	fmt.Printf("[%s] %s: $%.6f\n",
+func (p *ConsolePricePublisher) PublishTick(update models.PriceUpdate) error {
+	_, err := fmt.Fprintf(p.out, "[%s] %s - %s: $%s\n",
 		update.Timestamp.Format("15:04:05.000"),
-		key,
-		fValue)
-	return nil
+		update.Exchange,
+		update.Pair,
+		update.Price)
+	return err
 }
 
-// func (p *ConsolePricePublisher) Publish(update models.PriceUpdate) error {
-// 	fmt.Printf("[%s] %s - %s: $%.6f\n",
-// 		update.Timestamp.Format("15:04:05.000"),
-// 		update.Exchange,
-// 		update.Symbol,
-// 		update.Price)
-// 	return nil
-// }
+func (p *ConsolePricePublisher) PublishAggregate(agg models.Aggregate) error {
+	_, err := fmt.Fprintf(p.out, "[%s] %s - %s: avg $%s min $%s max $%s (%d ticks)\n",
+		agg.Timestamp.Format("15:04:05"),
+		agg.Exchange,
+		agg.Pair,
+		agg.Average,
+		agg.Min,
+		agg.Max,
+		agg.Count)
+	return err
+}
@@ -0,0 +1,115 @@
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"marketflow/internal/adapters/output/ws"
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	tailReconnectDelay = 2 * time.Second
+	tailMaxAttempts    = 30 // подряд неудачных подключений, около минуты
+)
+
+// connectionAware — приёмник, которому важно состояние соединения (Dashboard).
+type connectionAware interface {
+	SetConnected(connected bool, err error)
+}
+
+// Tail подключается к /ws запущенного сервиса и отдаёт тики и агрегаты в sink:
+// построчный ConsolePricePublisher или Dashboard. Фильтры задаются в url.
+// Если сервис недоступен с самого начала, сразу возвращает ошибку. При обрыве
+// переподключается, пока не отменён контекст, и сдаётся после tailMaxAttempts
+// неудачных попыток подряд.
+func Tail(ctx context.Context, url string, sink output.PricePublisher, logger *slog.Logger) error {
+	everConnected := false
+	for failures := 0; ; {
+		connected, err := tailOnce(ctx, url, sink)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			everConnected, failures = true, 0
+		} else {
+			failures++
+		}
+		if !everConnected {
+			return err
+		}
+		if failures >= tailMaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", failures, err)
+		}
+
+		if aware, ok := sink.(connectionAware); ok {
+			aware.SetConnected(false, err)
+		} else {
+			logger.Warn("Stream disconnected, reconnecting", "url", url, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(tailReconnectDelay):
+		}
+	}
+}
+
+// tailOnce читает поток до обрыва; connected — удалось ли подключиться.
+func tailOnce(ctx context.Context, url string, sink output.PricePublisher) (connected bool, err error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("connect %s: %w", url, err)
+	}
+	defer conn.Close()
+
+	if aware, ok := sink.(connectionAware); ok {
+		aware.SetConnected(true, nil)
+	}
+
+	// ReadMessage не смотрит на контекст — закрываем соединение сами
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var msg struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			return true, fmt.Errorf("read: %w", err)
+		}
+
+		switch msg.Type {
+		case ws.TypeTick:
+			var update models.PriceUpdate
+			if err := json.Unmarshal(msg.Data, &update); err != nil {
+				return true, err
+			}
+			err = sink.PublishTick(update)
+		case ws.TypeAggregate:
+			var agg models.Aggregate
+			if err := json.Unmarshal(msg.Data, &agg); err != nil {
+				return true, err
+			}
+			err = sink.PublishAggregate(agg)
+		}
+		if err != nil {
+			return true, err
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package console
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTailFailsWhenServiceIsDown(t *testing.T) {
+	// Свободный порт: слушаем и сразу закрываем
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	done := make(chan error, 1)
+	go func() {
+		done <- Tail(context.Background(), "ws://"+addr+"/ws", NewConsolePricePublisherTo(io.Discard, logger), logger)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Tail returned nil for an unreachable service")
+		}
+	case <-time.After(tailReconnectDelay):
+		t.Fatal("Tail kept retrying the first connection")
+	}
+}
@@ -0,0 +1,118 @@
+// Package exchangeproto разбирает строки бирж; его используют живой клиент tcp,
+// повтор записей и чтение истории.
+package exchangeproto
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/utils"
+)
+
+// ParseMessage разбирает строку биржи: JSON, "SYMBOL:PRICE" или "SYMBOL PRICE".
+func ParseMessage(message, exchangeName string) (models.PriceUpdate, error) {
+	// Try to parse as JSON first
+	// UseNumber сохраняет цену строкой, чтобы не терять точность на float64
+	var jsonData map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(message))
+	decoder.UseNumber()
+	if err := decoder.Decode(&jsonData); err == nil {
+		return parseJSONMessage(jsonData, exchangeName)
+	}
+
+	// Try to parse as simple format: SYMBOL:PRICE
+	parts := strings.Split(message, ":")
+	if len(parts) == 2 {
+		symbol := strings.TrimSpace(parts[0])
+		priceStr := strings.TrimSpace(parts[1])
+
+		price, err := models.ParseDecimal(priceStr)
+		if err != nil {
+			return models.PriceUpdate{}, fmt.Errorf("invalid price format: %s", priceStr)
+		}
+
+		return models.PriceUpdate{
+			Exchange:  exchangeName,
+			Pair:      symbol,
+			Price:     price,
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	// Try to parse as space-separated: SYMBOL PRICE
+	parts = strings.Fields(message)
+	if len(parts) >= 2 {
+		symbol := parts[0]
+		priceStr := parts[1]
+
+		price, err := models.ParseDecimal(priceStr)
+		if err != nil {
+			return models.PriceUpdate{}, fmt.Errorf("invalid price format: %s", priceStr)
+		}
+
+		return models.PriceUpdate{
+			Exchange:  exchangeName,
+			Pair:      symbol,
+			Price:     price,
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	return models.PriceUpdate{}, fmt.Errorf("unknown message format: %s", message)
+}
+
+func parseJSONMessage(data map[string]interface{}, exchangeName string) (models.PriceUpdate, error) {
+	symbol, ok := data["symbol"].(string)
+	if !ok {
+		if s, ok := data["pair"].(string); ok {
+			symbol = s
+		} else {
+			return models.PriceUpdate{}, fmt.Errorf("missing symbol/pair field")
+		}
+	}
+
+	var priceStr string
+	if p, ok := data["price"].(json.Number); ok {
+		priceStr = p.String()
+	} else if p, ok := data["price"].(string); ok {
+		priceStr = p
+	} else {
+		return models.PriceUpdate{}, fmt.Errorf("missing or invalid price field")
+	}
+
+	price, err := models.ParseDecimal(priceStr)
+	if err != nil {
+		return models.PriceUpdate{}, fmt.Errorf("invalid price format: %s", priceStr)
+	}
+
+	return models.PriceUpdate{
+		Exchange:     exchangeName,
+		Pair:         symbol,
+		Price:        price,
+		Timestamp:    time.Now(),
+		ExchangeTime: exchangeTime(data),
+	}, nil
+}
+
+// exchangeTime достаёт время биржи из полей timestamp, ts или time
+// (unix-время в с/мс/мкс/нс или RFC3339). Без него или при ошибке — нулевое время.
+func exchangeTime(data map[string]interface{}) time.Time {
+	for _, field := range []string{"timestamp", "ts", "time"} {
+		var raw string
+		switch v := data[field].(type) {
+		case json.Number:
+			raw = v.String()
+		case string:
+			raw = v
+		default:
+			continue
+		}
+		if ts, err := utils.ParseTimestamp(raw); err == nil {
+			return ts
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,97 @@
+package exchangeproto
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		pair    string
+		price   string
+	}{
+		{"json", `{"symbol":"BTCUSDT","price":65000.12345678}`, "BTCUSDT", "65000.12345678"},
+		{"json pair field", `{"pair":"ETHUSDT","price":3000}`, "ETHUSDT", "3000"},
+		{"json quoted price", `{"symbol":"BTCUSDT","price":"0.00000001"}`, "BTCUSDT", "0.00000001"},
+		{"json exponent", `{"symbol":"BTCUSDT","price":6.5e4}`, "BTCUSDT", "65000"},
+		{"colon", "BTCUSDT:65000.5", "BTCUSDT", "65000.5"},
+		{"colon with spaces", " SOLUSDT : 150.25 ", "SOLUSDT", "150.25"},
+		{"space", "DOGEUSDT 0.1234", "DOGEUSDT", "0.1234"},
+		{"space extra fields", "TONUSDT 5.5 1714564800", "TONUSDT", "5.5"},
+		// Знаки сверх восьмого округляются, а не режут строку
+		{"overlong fraction", "BTCUSDT:65000.123456789123", "BTCUSDT", "65000.12345679"},
+		{"overlong fraction json", `{"symbol":"BTCUSDT","price":"0.000000015"}`, "BTCUSDT", "0.00000002"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			update, err := ParseMessage(tt.message, "exchange1")
+			if err != nil {
+				t.Fatalf("ParseMessage(%q): %v", tt.message, err)
+			}
+			if update.Exchange != "exchange1" || update.Pair != tt.pair || update.Price.String() != tt.price {
+				t.Errorf("got %s %s %s, want exchange1 %s %s", update.Exchange, update.Pair, update.Price, tt.pair, tt.price)
+			}
+			if update.Timestamp.Before(before) {
+				t.Errorf("timestamp %s is not the receive time", update.Timestamp)
+			}
+		})
+	}
+}
+
+func TestParseMessageExchangeTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		want  time.Time
+	}{
+		{"seconds", `"ts":1714564800`, time.Unix(1714564800, 0)},
+		{"milliseconds", `"timestamp":1714564800123`, time.UnixMilli(1714564800123)},
+		{"microseconds", `"time":1714564800123456`, time.UnixMicro(1714564800123456)},
+		{"nanoseconds", `"ts":1714564800123456789`, time.Unix(0, 1714564800123456789)},
+		{"fractional seconds", `"ts":1714564800.25`, time.UnixMilli(1714564800250)},
+		{"quoted milliseconds", `"ts":"1714564800123"`, time.UnixMilli(1714564800123)},
+		{"rfc3339", `"time":"2024-05-01T12:00:00.5Z"`, time.Date(2024, 5, 1, 12, 0, 0, 5e8, time.UTC)},
+		{"invalid", `"ts":"yesterday"`, time.Time{}},
+		{"missing", `"seq":1`, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := `{"symbol":"BTCUSDT","price":1,` + tt.field + `}`
+			update, err := ParseMessage(message, "exchange1")
+			if err != nil {
+				t.Fatalf("ParseMessage(%q): %v", message, err)
+			}
+			if !update.ExchangeTime.Equal(tt.want) {
+				t.Errorf("exchange time = %s, want %s", update.ExchangeTime, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMessageRejects(t *testing.T) {
+	for _, message := range []string{
+		"",
+		"BTCUSDT",
+		"BTCUSDT:",
+		"BTCUSDT:abc",
+		"BTCUSDT:1:2",
+		"BTCUSDT 12,5",
+		`{"symbol":"BTCUSDT"}`,
+		`{"price":100}`,
+		`{"symbol":"BTCUSDT","price":true}`,
+		`{"symbol":"BTCUSDT","price":"1.2.3"}`,
+		`{"symbol":"BTCUSDT","price":`,
+		`{"symbol":"BTCUSDT"`,
+		// Не помещается в DECIMAL(20,8)
+		"BTCUSDT:123456789012345",
+		`{"symbol":"BTCUSDT","price":1e400}`,
+		"BTCUSDT 99999999999999999999.99999999",
+	} {
+		if update, err := ParseMessage(message, "exchange1"); err == nil {
+			t.Errorf("ParseMessage(%q) = %+v, want error", message, update)
+		}
+	}
+}
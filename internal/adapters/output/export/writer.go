@@ -0,0 +1,164 @@
+// Package export кодирует поток агрегатов в CSV, JSONL или Parquet.
+package export
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"marketflow/internal/domain/models"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+)
+
+// Parquet держит в памяти одну группу строк, дальше сбрасывает её в w.
+const parquetRowGroupSize = 50_000
+
+// AggregateWriter пишет агрегаты по одному; Close дописывает хвост формата.
+type AggregateWriter interface {
+	Write(agg models.Aggregate) error
+	Close() error
+}
+
+func NewAggregateWriter(w io.Writer, format string) (AggregateWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatJSONL:
+		buf := bufio.NewWriter(w)
+		return &jsonlWriter{buf: buf, enc: json.NewEncoder(buf)}, nil
+	case FormatParquet:
+		return &parquetWriter{w: parquet.NewGenericWriter[parquetRow](w, parquet.MaxRowsPerRowGroup(parquetRowGroupSize))}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q: expected csv, jsonl or parquet", format)
+}
+
+func ValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatJSONL || format == FormatParquet
+}
+
+// ContentType — MIME-тип формата для HTTP-ответа.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	}
+	return "application/vnd.apache.parquet"
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"timestamp", "exchange", "symbol", "average_price", "min_price", "max_price", "count"}); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw}, nil
+}
+
+func (c *csvWriter) Write(agg models.Aggregate) error {
+	return c.w.Write([]string{
+		agg.Timestamp.UTC().Format(time.RFC3339),
+		agg.Exchange,
+		agg.Pair,
+		agg.Average.String(),
+		agg.Min.String(),
+		agg.Max.String(),
+		strconv.Itoa(agg.Count),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(agg models.Aggregate) error {
+	return j.enc.Encode(agg)
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.buf.Flush()
+}
+
+// parquetRow — цены в DECIMAL(20,8), как в market_data: без потерь на float.
+// Parquet хранит такой DECIMAL в 9 байтах big-endian с дополнительным кодом.
+type parquetRow struct {
+	Timestamp time.Time      `parquet:"timestamp,timestamp(millisecond)"`
+	Exchange  string         `parquet:"exchange,dict"`
+	Pair      string         `parquet:"symbol,dict"`
+	Average   parquetDecimal `parquet:"average_price,decimal(8:20)"`
+	Min       parquetDecimal `parquet:"min_price,decimal(8:20)"`
+	Max       parquetDecimal `parquet:"max_price,decimal(8:20)"`
+	Count     int64          `parquet:"count"`
+}
+
+type parquetDecimal [9]byte
+
+func newParquetDecimal(d models.Decimal) parquetDecimal {
+	var b parquetDecimal
+	units := d.Units()
+	binary.BigEndian.PutUint64(b[1:], uint64(units))
+	if units < 0 {
+		b[0] = 0xff
+	}
+	return b
+}
+
+type parquetWriter struct {
+	w *parquet.GenericWriter[parquetRow]
+}
+
+func (p *parquetWriter) Write(agg models.Aggregate) error {
+	_, err := p.w.Write([]parquetRow{{
+		Timestamp: agg.Timestamp.UTC(),
+		Exchange:  agg.Exchange,
+		Pair:      agg.Pair,
+		Average:   newParquetDecimal(agg.Average),
+		Min:       newParquetDecimal(agg.Min),
+		Max:       newParquetDecimal(agg.Max),
+		Count:     int64(agg.Count),
+	}})
+	return err
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
+
+// ParseTime разбирает границу диапазона: RFC3339, дату 2006-01-02 или unix-секунды.
+func ParseTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if sec, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected RFC3339, YYYY-MM-DD or unix seconds", raw)
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParquetKeepsExactPrices(t *testing.T) {
+	aggs := []models.Aggregate{
+		{Exchange: "exchange1", Pair: "BTCUSDT", Average: models.MustDecimal("65000.12345678"),
+			Min: models.MustDecimal("0.00000001"), Max: models.MustDecimal("92233720368.54775807"), Count: 3,
+			Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{Exchange: "exchange2", Pair: "BTCUSDT", Average: models.MustDecimal("-0.1"),
+			Min: models.MustDecimal("-92233720368.54775807"), Max: models.MustDecimal("0"), Count: 1,
+			Timestamp: time.Date(2024, 5, 1, 12, 1, 0, 0, time.UTC)},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewAggregateWriter(&buf, FormatParquet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, agg := range aggs {
+		if err := w.Write(agg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, column := range []string{"average_price", "min_price", "max_price"} {
+		field, ok := file.Schema().Lookup(column)
+		if !ok {
+			t.Fatalf("no column %s", column)
+		}
+		if logical := field.Node.Type().LogicalType(); logical == nil || logical.Decimal == nil ||
+			logical.Decimal.Scale != 8 || logical.Decimal.Precision != 20 {
+			t.Errorf("%s logical type = %v, want DECIMAL(20,8)", column, logical)
+		}
+	}
+
+	rows := make([]parquetRow, len(aggs))
+	n, err := parquet.NewGenericReader[parquetRow](file).Read(rows)
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if n != len(aggs) {
+		t.Fatalf("read %d rows, want %d", n, len(aggs))
+	}
+	for i, agg := range aggs {
+		for _, price := range []struct {
+			got  parquetDecimal
+			want models.Decimal
+		}{{rows[i].Average, agg.Average}, {rows[i].Min, agg.Min}, {rows[i].Max, agg.Max}} {
+			if got := decodeDecimal(price.got); got != price.want.String() {
+				t.Errorf("row %d: price %s, want %s", i, got, price.want)
+			}
+		}
+	}
+}
+
+// decodeDecimal читает DECIMAL(20,8) так же, как сторонний читатель Parquet.
+func decodeDecimal(b parquetDecimal) string {
+	units := new(big.Int).SetBytes(b[:])
+	if b[0]&0x80 != 0 {
+		units.Sub(units, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+	s := new(big.Rat).SetFrac(units, big.NewInt(1e8)).FloatString(8)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
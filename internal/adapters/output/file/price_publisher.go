@@ -0,0 +1,100 @@
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+const flushInterval = time.Second
+
+type record struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// FilePricePublisher дописывает тики и агрегаты в файл, по одному JSON на строку.
+// Буфер сбрасывается на диск раз в flushInterval, даже если новых записей нет.
+type FilePricePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func NewFilePricePublisher(path string) (*FilePricePublisher, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open publisher file: %w", err)
+	}
+	p := &FilePricePublisher{
+		file:    f,
+		w:       bufio.NewWriter(f),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go p.flushLoop()
+	return p, nil
+}
+
+func (p *FilePricePublisher) PublishTick(update models.PriceUpdate) error {
+	return p.write(record{Type: "tick", Data: update})
+}
+
+func (p *FilePricePublisher) PublishAggregate(agg models.Aggregate) error {
+	return p.write(record{Type: "aggregate", Data: agg})
+}
+
+func (p *FilePricePublisher) write(r record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err = p.w.Write(append(line, '\n'))
+	return err
+}
+
+// flushLoop сбрасывает буфер по таймеру, а не на каждый тик: последние записи
+// попадают на диск и тогда, когда поток затих.
+func (p *FilePricePublisher) flushLoop() {
+	defer close(p.stopped)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.w.Flush()
+			p.mu.Unlock()
+		}
+	}
+}
+
+func (p *FilePricePublisher) Close() error {
+	close(p.done)
+	<-p.stopped
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.w.Flush(); err != nil {
+		p.file.Close()
+		return err
+	}
+	return p.file.Close()
+}
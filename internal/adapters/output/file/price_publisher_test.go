@@ -0,0 +1,37 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+func TestFilePricePublisherFlushesWithoutNewWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.jsonl")
+	p, err := NewFilePricePublisher(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if err := p.PublishTick(models.PriceUpdate{Exchange: "exchange1", Pair: "BTCUSDT"}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(3 * flushInterval)
+	for time.Now().Before(deadline) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), `"type":"tick"`) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("tick was not flushed to disk")
+}
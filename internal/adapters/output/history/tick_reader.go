@@ -0,0 +1,208 @@
+package history
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"marketflow/internal/adapters/output/exchangeproto"
+	"marketflow/internal/adapters/output/recording"
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/utils"
+)
+
+const (
+	FormatCSV       = "csv"
+	FormatJSONL     = "jsonl"
+	FormatRecording = "rec"
+)
+
+// TickFileReader читает тики из файла:
+//   - csv: заголовок с колонками timestamp, exchange, symbol (или pair), price;
+//   - jsonl: PriceUpdate на строку или записи файлового публикатора {"type":"tick","data":{...}};
+//   - rec: запись сессии биржи (RECORD_DIR), строки разбираются парсером TCP-адаптера.
+//
+// exchange подставляется в тики, где биржа не указана.
+type TickFileReader struct {
+	path     string
+	format   string
+	exchange string
+}
+
+func NewTickFileReader(path, format, exchange string) (*TickFileReader, error) {
+	if format == "" {
+		format = DetectFormat(path)
+	}
+	switch format {
+	case FormatCSV, FormatJSONL, FormatRecording:
+	default:
+		return nil, fmt.Errorf("unknown format %q for %s: expected csv, jsonl or rec", format, path)
+	}
+	return &TickFileReader{path: path, format: format, exchange: exchange}, nil
+}
+
+// DetectFormat определяет формат по расширению файла.
+func DetectFormat(path string) string {
+	switch {
+	case strings.HasSuffix(path, recording.Extension):
+		return FormatRecording
+	case strings.EqualFold(filepath.Ext(path), ".csv"):
+		return FormatCSV
+	default:
+		return FormatJSONL
+	}
+}
+
+func (r *TickFileReader) ReadTicks(ctx context.Context, fn func(models.PriceUpdate) error) error {
+	if r.format == FormatRecording {
+		return r.readRecording(fn)
+	}
+
+	f, err := os.Open(r.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if r.format == FormatCSV {
+		return r.readCSV(f, fn)
+	}
+	return r.readJSONL(f, fn)
+}
+
+func (r *TickFileReader) readCSV(f io.Reader, fn func(models.PriceUpdate) error) error {
+	reader := csv.NewReader(bufio.NewReader(f))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%s: read header: %w", r.path, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["symbol"]; !ok {
+		if i, ok := columns["pair"]; ok {
+			columns["symbol"] = i
+		}
+	}
+	for _, required := range []string{"timestamp", "symbol", "price"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("%s: missing column %q", r.path, required)
+		}
+	}
+	exchangeCol, hasExchange := columns["exchange"]
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", r.path, line, err)
+		}
+
+		ts, err := utils.ParseTimestamp(row[columns["timestamp"]])
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", r.path, line, err)
+		}
+		price, err := models.ParseDecimal(row[columns["price"]])
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", r.path, line, err)
+		}
+		exchange := r.exchange
+		if hasExchange && row[exchangeCol] != "" {
+			exchange = row[exchangeCol]
+		}
+
+		if err := fn(models.PriceUpdate{
+			Exchange:  exchange,
+			Pair:      row[columns["symbol"]],
+			Price:     price,
+			Timestamp: ts,
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *TickFileReader) readJSONL(f io.Reader, fn func(models.PriceUpdate) error) error {
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		var record struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(raw), &record); err != nil {
+			return fmt.Errorf("%s:%d: %w", r.path, line, err)
+		}
+		data := []byte(raw)
+		if record.Type != "" {
+			// Запись файлового публикатора: агрегаты пропускаем
+			if record.Type != "tick" {
+				continue
+			}
+			data = record.Data
+		}
+
+		var update models.PriceUpdate
+		if err := json.Unmarshal(data, &update); err != nil {
+			return fmt.Errorf("%s:%d: %w", r.path, line, err)
+		}
+		if update.Exchange == "" {
+			update.Exchange = r.exchange
+		}
+		if err := fn(update); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (r *TickFileReader) readRecording(fn func(models.PriceUpdate) error) error {
+	reader, err := recording.Open(r.path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	exchange := reader.Exchange()
+	if exchange == "" {
+		exchange = r.exchange
+	}
+
+	for {
+		record, ok, err := reader.Next()
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.path, err)
+		}
+		if !ok {
+			return nil
+		}
+
+		update, err := exchangeproto.ParseMessage(record.Line, exchange)
+		if err != nil {
+			continue // битые строки пропускаются так же, как в живом потоке
+		}
+		update.Timestamp = record.At
+		update.ReceivedAt = record.At
+		if err := fn(update); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+var errDisconnected = errors.New("connection closed")
+
+type feed struct {
+	ticks chan models.PriceUpdate
+	done  chan struct{} // закрывается при Disconnect
+}
+
+// ExchangeClient — output.ExchangeClient без сети: тики подаются через Send.
+// Как и TCP-клиент, он один на все биржи; после Disconnect сервис переподключится.
+type ExchangeClient struct {
+	mu          sync.Mutex
+	feeds       map[string]*feed
+	failConnect map[string]error
+	connects    map[string]int
+}
+
+func NewExchangeClient() *ExchangeClient {
+	return &ExchangeClient{
+		feeds:       make(map[string]*feed),
+		failConnect: make(map[string]error),
+		connects:    make(map[string]int),
+	}
+}
+
+func (c *ExchangeClient) Connect(config models.ExchangeConfig) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.connects[config.Name]++
+	if err := c.failConnect[config.Name]; err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", config.Name, err)
+	}
+	// Новая сессия заменяет старую: слушатель старой должен выйти, как при обрыве
+	if old := c.feeds[config.Name]; old != nil {
+		close(old.done)
+	}
+	c.feeds[config.Name] = &feed{
+		ticks: make(chan models.PriceUpdate, 64),
+		done:  make(chan struct{}),
+	}
+	return nil
+}
+
+func (c *ExchangeClient) Listen(ctx context.Context, updates chan<- models.PriceUpdate, exchange models.ExchangeConfig) error {
+	c.mu.Lock()
+	f := c.feeds[exchange.Name]
+	c.mu.Unlock()
+	if f == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-f.done:
+			return errDisconnected
+		case update := <-f.ticks:
+			now := time.Now()
+			update.Exchange = exchange.Name
+			if update.Timestamp.IsZero() {
+				update.Timestamp = now
+			}
+			update.ReceivedAt = now
+			select {
+			case updates <- update:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// Send отдаёт тик слушателю биржи; блокируется, пока биржа не подключена.
+func (c *ExchangeClient) Send(ctx context.Context, exchange string, update models.PriceUpdate) error {
+	for {
+		c.mu.Lock()
+		f := c.feeds[exchange]
+		c.mu.Unlock()
+		if f != nil {
+			select {
+			case f.ticks <- update:
+				return nil
+			case <-f.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// FailConnect заставляет Connect к бирже возвращать err; nil снимает сбой.
+func (c *ExchangeClient) FailConnect(exchange string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failConnect[exchange] = err
+}
+
+// Disconnect обрывает текущую сессию биржи: Listen вернёт ошибку.
+func (c *ExchangeClient) Disconnect(exchange string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f := c.feeds[exchange]; f != nil {
+		close(f.done)
+		delete(c.feeds, exchange)
+	}
+}
+
+// Connects — число попыток Connect к бирже.
+func (c *ExchangeClient) Connects(exchange string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connects[exchange]
+}
+
+func (c *ExchangeClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, f := range c.feeds {
+		close(f.done)
+		delete(c.feeds, name)
+	}
+	return nil
+}
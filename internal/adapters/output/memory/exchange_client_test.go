@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+func listen(c *ExchangeClient, name string, updates chan models.PriceUpdate) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- c.Listen(context.Background(), updates, models.ExchangeConfig{Name: name}) }()
+	return done
+}
+
+func wait(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("Listen did not return")
+		return nil
+	}
+}
+
+func TestExchangeClientSendAndDisconnect(t *testing.T) {
+	c := NewExchangeClient()
+	ex := models.ExchangeConfig{Name: "ex1"}
+	if err := c.Connect(ex); err != nil {
+		t.Fatal(err)
+	}
+	updates := make(chan models.PriceUpdate, 1)
+	done := listen(c, "ex1", updates)
+
+	if err := c.Send(context.Background(), "ex1", models.PriceUpdate{Pair: "BTCUSDT"}); err != nil {
+		t.Fatal(err)
+	}
+	got := <-updates
+	if got.Exchange != "ex1" || got.Timestamp.IsZero() || got.ReceivedAt.IsZero() {
+		t.Errorf("update = %+v, want exchange and times filled in", got)
+	}
+
+	c.Disconnect("ex1")
+	if err := wait(t, done); err == nil {
+		t.Error("Listen returned nil after Disconnect")
+	}
+}
+
+func TestExchangeClientReconnectEndsOldSession(t *testing.T) {
+	c := NewExchangeClient()
+	ex := models.ExchangeConfig{Name: "ex1"}
+	c.Connect(ex)
+	updates := make(chan models.PriceUpdate)
+	done := listen(c, "ex1", updates)
+	// Тик дошёл — слушатель точно держит первую сессию
+	c.Send(context.Background(), "ex1", models.PriceUpdate{})
+	<-updates
+
+	if err := c.Connect(ex); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(t, done); err == nil {
+		t.Error("old session kept listening after a new Connect")
+	}
+	if got := c.Connects("ex1"); got != 2 {
+		t.Errorf("Connects = %d, want 2", got)
+	}
+}
+
+func TestExchangeClientFailConnect(t *testing.T) {
+	c := NewExchangeClient()
+	refused := errors.New("refused")
+	c.FailConnect("ex1", refused)
+	if err := c.Connect(models.ExchangeConfig{Name: "ex1"}); !errors.Is(err, refused) {
+		t.Errorf("Connect err = %v, want %v", err, refused)
+	}
+
+	// Send ждёт подключения и уважает контекст
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Send(ctx, "ex1", models.PriceUpdate{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Send err = %v, want deadline exceeded", err)
+	}
+
+	c.FailConnect("ex1", nil)
+	if err := c.Connect(models.ExchangeConfig{Name: "ex1"}); err != nil {
+		t.Errorf("Connect after clearing the failure: %v", err)
+	}
+}
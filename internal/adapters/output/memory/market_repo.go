@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+type aggregateKey struct {
+	exchange string
+	pair     string
+	ts       int64
+}
+
+// MarketRepo — все репозитории Postgres-адаптера в памяти: агрегаты (с upsert
+// по окну, как ON CONFLICT), выборки для экспорта, карантин, спреды и правила оповещений.
+type MarketRepo struct {
+	mu         sync.Mutex
+	aggregates map[aggregateKey]models.Aggregate
+	quarantine []models.QuarantinedTick
+	spreads    []models.SpreadEvent
+	rules      map[int64]models.AlertRule
+	nextRuleID int64
+}
+
+func NewMarketRepo() *MarketRepo {
+	return &MarketRepo{
+		aggregates: make(map[aggregateKey]models.Aggregate),
+		rules:      make(map[int64]models.AlertRule),
+	}
+}
+
+func (r *MarketRepo) InsertMarketData(ctx context.Context, agg models.Aggregate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aggregates[aggregateKey{agg.Exchange, agg.Pair, agg.Timestamp.UnixNano()}] = agg
+	return nil
+}
+
+// Aggregates возвращает сохранённые агрегаты по времени, затем по бирже и паре.
+func (r *MarketRepo) Aggregates() []models.Aggregate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	aggs := make([]models.Aggregate, 0, len(r.aggregates))
+	for _, agg := range r.aggregates {
+		aggs = append(aggs, agg)
+	}
+	sortAggregates(aggs)
+	return aggs
+}
+
+// QueryAggregates повторяет свёртку rollupQuery: бакеты по Resolution от эпохи,
+// среднее взвешено по числу тиков.
+func (r *MarketRepo) QueryAggregates(ctx context.Context, q output.AggregateQuery, fn func(models.Aggregate) error) error {
+	to := q.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	buckets := make(map[aggregateKey][]models.Aggregate)
+	for _, agg := range r.Aggregates() {
+		if agg.Pair != q.Pair || (q.Exchange != "" && agg.Exchange != q.Exchange) {
+			continue
+		}
+		if agg.Timestamp.Before(q.From) || !agg.Timestamp.Before(to) {
+			continue
+		}
+		bucket := agg.Timestamp
+		if q.Resolution > 0 {
+			bucket = time.Unix(0, agg.Timestamp.UnixNano()/int64(q.Resolution)*int64(q.Resolution))
+		}
+		key := aggregateKey{agg.Exchange, agg.Pair, bucket.UnixNano()}
+		buckets[key] = append(buckets[key], agg)
+	}
+
+	rolled := make([]models.Aggregate, 0, len(buckets))
+	for key, aggs := range buckets {
+		if merged, ok := models.MergeAggregates(aggs, time.Unix(0, key.ts)); ok {
+			rolled = append(rolled, merged)
+		}
+	}
+	sortAggregates(rolled)
+
+	for _, agg := range rolled {
+		if err := fn(agg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MarketRepo) InsertQuarantinedTick(tick models.QuarantinedTick) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.quarantine = append(r.quarantine, tick)
+	return nil
+}
+
+func (r *MarketRepo) Quarantined() []models.QuarantinedTick {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.QuarantinedTick(nil), r.quarantine...)
+}
+
+func (r *MarketRepo) InsertSpreadEvent(event models.SpreadEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spreads = append(r.spreads, event)
+	return nil
+}
+
+func (r *MarketRepo) SpreadEvents() []models.SpreadEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.SpreadEvent(nil), r.spreads...)
+}
+
+func (r *MarketRepo) ListAlertRules(ctx context.Context) ([]models.AlertRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := make([]models.AlertRule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+func (r *MarketRepo) GetAlertRule(ctx context.Context, id int64) (models.AlertRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rule, ok := r.rules[id]
+	if !ok {
+		return models.AlertRule{}, models.ErrNotFound
+	}
+	return rule, nil
+}
+
+func (r *MarketRepo) CreateAlertRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextRuleID++
+	rule.ID = r.nextRuleID
+	rule.CreatedAt = time.Now()
+	r.rules[rule.ID] = rule
+	return rule, nil
+}
+
+func (r *MarketRepo) UpdateAlertRule(ctx context.Context, rule models.AlertRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.rules[rule.ID]
+	if !ok {
+		return models.ErrNotFound
+	}
+	rule.CreatedAt = existing.CreatedAt
+	r.rules[rule.ID] = rule
+	return nil
+}
+
+func (r *MarketRepo) DeleteAlertRule(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rules[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(r.rules, id)
+	return nil
+}
+
+func sortAggregates(aggs []models.Aggregate) {
+	sort.Slice(aggs, func(i, j int) bool {
+		a, b := aggs[i], aggs[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		if a.Exchange != b.Exchange {
+			return a.Exchange < b.Exchange
+		}
+		return a.Pair < b.Pair
+	})
+}
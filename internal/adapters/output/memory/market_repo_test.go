@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+func aggregate(exchange string, ts time.Time, prices ...string) models.Aggregate {
+	decimals := make([]models.Decimal, len(prices))
+	for i, p := range prices {
+		decimals[i] = models.MustDecimal(p)
+	}
+	agg, _ := models.NewAggregate(exchange, "BTCUSDT", decimals, ts)
+	return agg
+}
+
+func TestMarketRepoQueryAggregates(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMarketRepo()
+	base := time.Unix(1_700_000_040, 0) // кратно минуте
+
+	repo.InsertMarketData(ctx, aggregate("ex1", base, "100", "200"))
+	repo.InsertMarketData(ctx, aggregate("ex1", base.Add(time.Second), "300"))
+	repo.InsertMarketData(ctx, aggregate("ex2", base, "50"))
+	repo.InsertMarketData(ctx, aggregate("ex1", base.Add(time.Minute), "400"))
+	// upsert того же окна
+	repo.InsertMarketData(ctx, aggregate("ex2", base, "60"))
+
+	var got []models.Aggregate
+	err := repo.QueryAggregates(ctx, output.AggregateQuery{
+		Pair:       "BTCUSDT",
+		From:       base,
+		To:         base.Add(2 * time.Minute),
+		Resolution: time.Minute,
+	}, func(agg models.Aggregate) error {
+		got = append(got, agg)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		exchange string
+		ts       time.Time
+		count    int
+		min, max string
+	}{
+		{"ex1", base, 3, "100", "300"},
+		{"ex2", base, 1, "60", "60"},
+		{"ex1", base.Add(time.Minute), 1, "400", "400"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d aggregates, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Exchange != w.exchange || !g.Timestamp.Equal(w.ts) || g.Count != w.count ||
+			g.Min.String() != w.min || g.Max.String() != w.max {
+			t.Errorf("aggregate %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestMarketRepoAlertRules(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMarketRepo()
+
+	rule, err := repo.CreateAlertRule(ctx, models.AlertRule{Pair: "BTCUSDT"})
+	if err != nil || rule.ID != 1 {
+		t.Fatalf("CreateAlertRule = %+v, %v", rule, err)
+	}
+	if err := repo.DeleteAlertRule(ctx, rule.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetAlertRule(ctx, rule.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetAlertRule after delete: %v, want ErrNotFound", err)
+	}
+	if err := repo.UpdateAlertRule(ctx, rule); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("UpdateAlertRule after delete: %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+// Publisher запоминает всё, что опубликовал конвейер.
+type Publisher struct {
+	mu         sync.Mutex
+	ticks      []models.PriceUpdate
+	aggregates []models.Aggregate
+}
+
+func NewPublisher() *Publisher {
+	return &Publisher{}
+}
+
+func (p *Publisher) PublishTick(update models.PriceUpdate) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ticks = append(p.ticks, update)
+	return nil
+}
+
+func (p *Publisher) PublishAggregate(agg models.Aggregate) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.aggregates = append(p.aggregates, agg)
+	return nil
+}
+
+func (p *Publisher) Ticks() []models.PriceUpdate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]models.PriceUpdate(nil), p.ticks...)
+}
+
+func (p *Publisher) Aggregates() []models.Aggregate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]models.Aggregate(nil), p.aggregates...)
+}
+
+type Notification struct {
+	URL   string
+	Event models.AlertEvent
+	At    time.Time // когда пришла попытка, для проверки пауз между повторами
+}
+
+// Notifier — output.AlertNotifier, запоминающий доставки вместо HTTP.
+// Err, если задан, возвращается из каждого Notify; Errs — только для своих адресов.
+type Notifier struct {
+	Err  error
+	Errs map[string]error
+
+	mu            sync.Mutex
+	notifications []Notification
+}
+
+func NewNotifier() *Notifier {
+	return &Notifier{}
+}
+
+func (n *Notifier) Notify(ctx context.Context, url string, event models.AlertEvent) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notifications = append(n.notifications, Notification{URL: url, Event: event, At: time.Now()})
+	if err, ok := n.Errs[url]; ok {
+		return err
+	}
+	return n.Err
+}
+
+func (n *Notifier) Notifications() []Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Notification(nil), n.notifications...)
+}
@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"marketflow/internal/domain/models"
+)
+
+func TestNotifierRecordsDeliveries(t *testing.T) {
+	n := NewNotifier()
+	event := models.AlertEvent{RuleID: 1, DedupKey: "1:ex1:0"}
+	if err := n.Notify(context.Background(), "http://hooks/1", event); err != nil {
+		t.Fatal(err)
+	}
+
+	n.Err = errors.New("webhook down")
+	if err := n.Notify(context.Background(), "http://hooks/1", event); !errors.Is(err, n.Err) {
+		t.Errorf("Notify err = %v, want %v", err, n.Err)
+	}
+
+	got := n.Notifications()
+	if len(got) != 2 || got[0].URL != "http://hooks/1" || got[0].Event.DedupKey != event.DedupKey {
+		t.Errorf("notifications = %+v, want both attempts recorded", got)
+	}
+}
@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+type zmember struct {
+	score  float64
+	member string
+}
+
+type stringValue struct {
+	value     string
+	expiresAt time.Time // нулевое — без срока
+}
+
+type Message struct {
+	Channel string
+	Payload string
+}
+
+// RedisClient — output.RedisClient в памяти: строки с TTL, отсортированные множества
+// и журнал Pub/Sub. Границы ZRangeByScore понимают "(" для строгого сравнения и ±inf, как Redis.
+type RedisClient struct {
+	mu       sync.Mutex
+	strings  map[string]stringValue
+	zsets    map[string][]zmember // отсортированы по (score, member)
+	messages []Message
+}
+
+func NewRedisClient() *RedisClient {
+	return &RedisClient{
+		strings: make(map[string]stringValue),
+		zsets:   make(map[string][]zmember),
+	}
+}
+
+func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v := stringValue{value: fmt.Sprint(value)}
+	if expiration > 0 {
+		v.expiresAt = time.Now().Add(expiration)
+	}
+	r.strings[key] = v
+	return nil
+}
+
+// Get возвращает models.ErrNotFound для отсутствующего или истёкшего ключа.
+func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.strings[key]
+	if !ok || (!v.expiresAt.IsZero() && time.Now().After(v.expiresAt)) {
+		delete(r.strings, key)
+		return "", models.ErrNotFound
+	}
+	return v.value, nil
+}
+
+func (r *RedisClient) ZAdd(ctx context.Context, key string, score float64, member interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := fmt.Sprint(member)
+	set := r.zsets[key]
+	for i := range set {
+		if set[i].member == m {
+			set = append(set[:i], set[i+1:]...)
+			break
+		}
+	}
+	set = append(set, zmember{score: score, member: m})
+	sort.Slice(set, func(i, j int) bool {
+		if set[i].score != set[j].score {
+			return set[i].score < set[j].score
+		}
+		return set[i].member < set[j].member
+	})
+	r.zsets[key] = set
+	return nil
+}
+
+func (r *RedisClient) ZRangeByScore(ctx context.Context, key string, min, max string) ([]string, error) {
+	in, err := scoreRange(min, max)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var members []string
+	for _, m := range r.zsets[key] {
+		if in(m.score) {
+			members = append(members, m.member)
+		}
+	}
+	return members, nil
+}
+
+func (r *RedisClient) ZRemRangeByScore(ctx context.Context, key string, min, max string) error {
+	in, err := scoreRange(min, max)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.zsets[key][:0]
+	for _, m := range r.zsets[key] {
+		if !in(m.score) {
+			kept = append(kept, m)
+		}
+	}
+	r.zsets[key] = kept
+	return nil
+}
+
+func (r *RedisClient) Publish(ctx context.Context, channel string, message interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var payload string
+	switch m := message.(type) {
+	case []byte:
+		payload = string(m)
+	default:
+		payload = fmt.Sprint(m)
+	}
+	r.messages = append(r.messages, Message{Channel: channel, Payload: payload})
+	return nil
+}
+
+// Messages возвращает копию всех опубликованных сообщений по порядку.
+func (r *RedisClient) Messages() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Message(nil), r.messages...)
+}
+
+// ZCard — число элементов множества.
+func (r *RedisClient) ZCard(key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.zsets[key])
+}
+
+func scoreRange(min, max string) (func(float64) bool, error) {
+	lo, loExcl, err := parseScore(min)
+	if err != nil {
+		return nil, err
+	}
+	hi, hiExcl, err := parseScore(max)
+	if err != nil {
+		return nil, err
+	}
+	return func(s float64) bool {
+		if s < lo || (loExcl && s == lo) {
+			return false
+		}
+		if s > hi || (hiExcl && s == hi) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+func parseScore(raw string) (float64, bool, error) {
+	exclusive := strings.HasPrefix(raw, "(")
+	raw = strings.TrimPrefix(raw, "(")
+	switch raw {
+	case "-inf":
+		return math.Inf(-1), exclusive, nil
+	case "+inf", "inf":
+		return math.Inf(1), exclusive, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid score %q", raw)
+	}
+	return v, exclusive, nil
+}
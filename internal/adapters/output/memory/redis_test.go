@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+var (
+	_ output.RedisClient          = (*RedisClient)(nil)
+	_ output.MarketRepository     = (*MarketRepo)(nil)
+	_ output.AggregateReader      = (*MarketRepo)(nil)
+	_ output.QuarantineRepository = (*MarketRepo)(nil)
+	_ output.SpreadRepository     = (*MarketRepo)(nil)
+	_ output.AlertRuleRepository  = (*MarketRepo)(nil)
+	_ output.PricePublisher       = (*Publisher)(nil)
+	_ output.AlertNotifier        = (*Notifier)(nil)
+	_ output.ExchangeClient       = (*ExchangeClient)(nil)
+	_ output.TickReader           = (*TickReader)(nil)
+)
+
+func TestRedisZRangeByScore(t *testing.T) {
+	ctx := context.Background()
+	r := NewRedisClient()
+	for i, member := range []string{"a", "b", "c", "d"} {
+		r.ZAdd(ctx, "k", float64(10+i), member)
+	}
+	r.ZAdd(ctx, "k", 9, "d") // повторный член меняет score
+
+	tests := []struct {
+		min, max string
+		want     []string
+	}{
+		{"10", "12", []string{"a", "b", "c"}},
+		{"10", "(12", []string{"a", "b"}},
+		{"(10", "12", []string{"b", "c"}},
+		{"-inf", "+inf", []string{"d", "a", "b", "c"}},
+		{"0", "9", []string{"d"}},
+		{"20", "+inf", nil},
+	}
+	for _, tt := range tests {
+		got, err := r.ZRangeByScore(ctx, "k", tt.min, tt.max)
+		if err != nil {
+			t.Fatalf("[%s, %s]: %v", tt.min, tt.max, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("[%s, %s] = %v, want %v", tt.min, tt.max, got, tt.want)
+		}
+	}
+
+	if _, err := r.ZRangeByScore(ctx, "k", "x", "1"); err == nil {
+		t.Error("invalid score accepted")
+	}
+}
+
+func TestRedisZRemRangeByScore(t *testing.T) {
+	ctx := context.Background()
+	r := NewRedisClient()
+	for i, member := range []string{"a", "b", "c"} {
+		r.ZAdd(ctx, "k", float64(i), member)
+	}
+	if err := r.ZRemRangeByScore(ctx, "k", "0", "(2"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := r.ZRangeByScore(ctx, "k", "-inf", "+inf")
+	if !slices.Equal(got, []string{"c"}) {
+		t.Errorf("left %v, want [c]", got)
+	}
+}
+
+func TestRedisGetExpires(t *testing.T) {
+	ctx := context.Background()
+	r := NewRedisClient()
+	r.Set(ctx, "forever", 42, 0)
+	r.Set(ctx, "short", "x", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if v, err := r.Get(ctx, "forever"); err != nil || v != "42" {
+		t.Errorf("Get(forever) = %q, %v", v, err)
+	}
+	if _, err := r.Get(ctx, "short"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Get(short) error = %v, want ErrNotFound", err)
+	}
+}
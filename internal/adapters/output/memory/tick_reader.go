@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"context"
+
+	"marketflow/internal/domain/models"
+)
+
+// TickReader отдаёт заранее заданные тики по порядку.
+type TickReader struct {
+	Ticks []models.PriceUpdate
+}
+
+func NewTickReader(ticks ...models.PriceUpdate) *TickReader {
+	return &TickReader{Ticks: ticks}
+}
+
+func (r *TickReader) ReadTicks(ctx context.Context, fn func(models.PriceUpdate) error) error {
+	for _, tick := range r.Ticks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(tick); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"marketflow/internal/domain/models"
+)
+
+func TestTickReader(t *testing.T) {
+	r := NewTickReader(models.PriceUpdate{Pair: "BTCUSDT"}, models.PriceUpdate{Pair: "ETHUSDT"}, models.PriceUpdate{Pair: "SOLUSDT"})
+
+	var pairs []string
+	stop := errors.New("stop")
+	err := r.ReadTicks(context.Background(), func(u models.PriceUpdate) error {
+		pairs = append(pairs, u.Pair)
+		if len(pairs) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || len(pairs) != 2 || pairs[0] != "BTCUSDT" || pairs[1] != "ETHUSDT" {
+		t.Errorf("pairs = %v, err = %v; want the first two ticks in order and fn's error", pairs, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.ReadTicks(ctx, func(models.PriceUpdate) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadTicks with a cancelled context: err = %v", err)
+	}
+}
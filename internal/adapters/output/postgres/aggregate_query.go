@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+	"marketflow/pkg/metrics"
+)
+
+// Окна агрегатора сворачиваются в более крупные бакеты прямо в SQL.
+// Среднее взвешивается по числу тиков; для старых строк без tick_count — простое среднее.
+const rollupQuery = `
+SELECT exchange,
+       pair_name,
+       to_timestamp(floor(extract(epoch FROM timestamp) / $5) * $5) AS bucket,
+       COALESCE(SUM(average_price * tick_count) / NULLIF(SUM(tick_count), 0), AVG(average_price)),
+       MIN(min_price),
+       MAX(max_price),
+       SUM(tick_count)
+FROM market_data
+WHERE pair_name = $1
+  AND ($2 = '' OR exchange = $2)
+  AND timestamp >= $3
+  AND timestamp < $4
+GROUP BY exchange, pair_name, bucket
+ORDER BY bucket, exchange`
+
+// QueryAggregates построчно отдаёт агрегаты в fn, не загружая всю выборку в память.
+func (r *MarketRepo) QueryAggregates(ctx context.Context, q output.AggregateQuery, fn func(models.Aggregate) error) error {
+	defer metrics.ObserveSince(metrics.PostgresLatency, "query_aggregates", time.Now())
+
+	to := q.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	rows, err := r.pool.Query(ctx, rollupQuery, q.Pair, q.Exchange, q.From, to, q.Resolution.Seconds())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var agg models.Aggregate
+		var count int64
+		if err := rows.Scan(&agg.Exchange, &agg.Pair, &agg.Timestamp, &agg.Average, &agg.Min, &agg.Max, &count); err != nil {
+			return err
+		}
+		agg.Count = int(count)
+		if err := fn(agg); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/metrics"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const alertRuleColumns = `id, name, type, pair_name, exchange, direction, price, percent,
+	window_seconds, spread_bps, max_age_seconds, cooldown_seconds, webhook_url, enabled, created_at`
+
+func (r *MarketRepo) ListAlertRules(ctx context.Context) ([]models.AlertRule, error) {
+	defer metrics.ObserveSince(metrics.PostgresLatency, "list_alert_rules", time.Now())
+
+	rows, err := r.pool.Query(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.AlertRule
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *MarketRepo) GetAlertRule(ctx context.Context, id int64) (models.AlertRule, error) {
+	defer metrics.ObserveSince(metrics.PostgresLatency, "get_alert_rule", time.Now())
+
+	rule, err := scanAlertRule(r.pool.QueryRow(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return rule, models.ErrNotFound
+	}
+	return rule, err
+}
+
+func (r *MarketRepo) CreateAlertRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	defer metrics.ObserveSince(metrics.PostgresLatency, "create_alert_rule", time.Now())
+
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO alert_rules (name, type, pair_name, exchange, direction, price, percent,
+			window_seconds, spread_bps, max_age_seconds, cooldown_seconds, webhook_url, enabled)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 RETURNING id, created_at`,
+		rule.Name, rule.Type, rule.Pair, rule.Exchange, rule.Direction, rule.Price, rule.Percent,
+		seconds(rule.Window), rule.SpreadBps, seconds(rule.MaxAge), seconds(rule.Cooldown),
+		rule.WebhookURL, rule.Enabled,
+	).Scan(&rule.ID, &rule.CreatedAt)
+	return rule, err
+}
+
+func (r *MarketRepo) UpdateAlertRule(ctx context.Context, rule models.AlertRule) error {
+	defer metrics.ObserveSince(metrics.PostgresLatency, "update_alert_rule", time.Now())
+
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE alert_rules SET name = $2, type = $3, pair_name = $4, exchange = $5, direction = $6,
+			price = $7, percent = $8, window_seconds = $9, spread_bps = $10, max_age_seconds = $11,
+			cooldown_seconds = $12, webhook_url = $13, enabled = $14
+		 WHERE id = $1`,
+		rule.ID, rule.Name, rule.Type, rule.Pair, rule.Exchange, rule.Direction, rule.Price, rule.Percent,
+		seconds(rule.Window), rule.SpreadBps, seconds(rule.MaxAge), seconds(rule.Cooldown),
+		rule.WebhookURL, rule.Enabled,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+func (r *MarketRepo) DeleteAlertRule(ctx context.Context, id int64) error {
+	defer metrics.ObserveSince(metrics.PostgresLatency, "delete_alert_rule", time.Now())
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+func scanAlertRule(row pgx.Row) (models.AlertRule, error) {
+	var (
+		rule                     models.AlertRule
+		window, maxAge, cooldown int64
+	)
+	err := row.Scan(&rule.ID, &rule.Name, &rule.Type, &rule.Pair, &rule.Exchange, &rule.Direction,
+		&rule.Price, &rule.Percent, &window, &rule.SpreadBps, &maxAge, &cooldown,
+		&rule.WebhookURL, &rule.Enabled, &rule.CreatedAt)
+	rule.Window = models.Duration(time.Duration(window) * time.Second)
+	rule.MaxAge = models.Duration(time.Duration(maxAge) * time.Second)
+	rule.Cooldown = models.Duration(time.Duration(cooldown) * time.Second)
+	return rule, err
+}
+
+func seconds(d models.Duration) int64 {
+	return int64(time.Duration(d) / time.Second)
+}
@@ -2,57 +2,50 @@ package postgres
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"time"
 
-	"github.com/jackc/pgx/v5"
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/metrics"
+	"marketflow/pkg/tracing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type MarketRepo struct {
-	conn *pgx.Conn
+	pool *pgxpool.Pool
 	ctx  context.Context
 	log  *slog.Logger
 }
 
-func NewMarketRepo(ctx context.Context, conn *pgx.Conn, log *slog.Logger) *MarketRepo {
-	return &MarketRepo{conn: conn, ctx: ctx, log: log}
+func NewMarketRepo(ctx context.Context, pool *pgxpool.Pool, log *slog.Logger) *MarketRepo {
+	return &MarketRepo{pool: pool, ctx: ctx, log: log}
 }
 
-func (r *MarketRepo) InsertMarketData(exchange, symbol string, prices []float64, ts time.Time) error {
-	avg, min, max, ok := calculateStats(prices)
-	if !ok {
-		r.log.Warn("No prices to calculate statistics")
-		return fmt.Errorf("no prices to calculate statistics")
-	}
-
-	_, err := r.conn.Exec(
-		context.Background(),
-		`INSERT INTO market_data (exchange, pair_name, average_price, min_price, max_price, timestamp) VALUES ($1, $2, $3, $4, $5, $6)`,
-		exchange, symbol, avg, min, max, ts,
+// InsertMarketData записывает агрегат окна; повторная запись того же окна
+// (бэкфилл, перезапуск) заменяет его, а не дублирует.
+func (r *MarketRepo) InsertMarketData(ctx context.Context, agg models.Aggregate) (err error) {
+	defer metrics.ObserveSince(metrics.PostgresLatency, "insert_market_data", time.Now())
+
+	ctx, span := tracing.Start(ctx, tracing.SpanPostgresInsert,
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.sql.table", "market_data"),
+		attribute.String("exchange", agg.Exchange),
+		attribute.String("pair", agg.Pair),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = r.pool.Exec(
+		ctx,
+		`INSERT INTO market_data (exchange, pair_name, average_price, min_price, max_price, tick_count, timestamp)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 ON CONFLICT (exchange, pair_name, timestamp) DO UPDATE SET
+		     average_price = EXCLUDED.average_price,
+		     min_price = EXCLUDED.min_price,
+		     max_price = EXCLUDED.max_price,
+		     tick_count = EXCLUDED.tick_count`,
+		agg.Exchange, agg.Pair, agg.Average, agg.Min, agg.Max, agg.Count, agg.Timestamp,
 	)
 	return err
 }
-
-func calculateStats(prices []float64) (avg, min, max float64, ok bool) {
-	if len(prices) == 0 {
-		return 0, 0, 0, false
-	}
-
-	min = prices[0]
-	max = prices[0]
-	sum := 0.0
-
-	for _, p := range prices {
-		if p < min {
-			min = p
-		}
-		if p > max {
-			max = p
-		}
-		sum += p
-	}
-
-	avg = sum / float64(len(prices))
-	return avg, min, max, true
-}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationsLock — ключ advisory lock: экземпляры, стартующие одновременно, применяют миграции по очереди.
+const migrationsLock = 4_210_001
+
+const migrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version VARCHAR(255) PRIMARY KEY,
+    applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+)`
+
+// Migrate применяет ещё не применённые миграции *.sql из fsys по порядку имён,
+// каждую в своей транзакции. Возвращает имена применённых.
+func Migrate(ctx context.Context, pool *pgxpool.Pool, fsys fs.FS, log *slog.Logger) ([]string, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationsLock); err != nil {
+		return nil, fmt.Errorf("lock schema_migrations: %w", err)
+	}
+	defer func() {
+		// ctx может быть уже отменён, а блокировка держится до конца сессии
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationsLock); err != nil {
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, migrationsTable); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	applied := make(map[string]bool)
+	rows, err := conn.Query(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var done []string
+	for _, name := range names {
+		version := strings.TrimSuffix(path.Base(name), ".sql")
+		if applied[version] {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return done, err
+		}
+
+		err = pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, stripMetaCommands(string(data))); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %s: %w", version, err)
+		}
+
+		log.Info("Applied migration", "version", version)
+		done = append(done, version)
+	}
+	return done, nil
+}
+
+// stripMetaCommands убирает команды psql (\c marketflow;) — они нужны только
+// при инициализации контейнера, а мы уже подключены к нужной базе.
+func stripMetaCommands(script string) string {
+	lines := strings.Split(script, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), `\`) {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
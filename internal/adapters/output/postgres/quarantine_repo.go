@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/metrics"
+)
+
+func (r *MarketRepo) InsertQuarantinedTick(tick models.QuarantinedTick) error {
+	defer metrics.ObserveSince(metrics.PostgresLatency, "insert_quarantined_tick", time.Now())
+
+	_, err := r.pool.Exec(
+		context.Background(),
+		`INSERT INTO quarantined_ticks (pair_name, exchange, price, reason, detail, timestamp, rejected_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		tick.Update.Pair, tick.Update.Exchange, tick.Update.Price,
+		tick.Reason, tick.Detail, tick.Update.Timestamp, tick.RejectedAt,
+	)
+	return err
+}
@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/metrics"
+)
+
+func (r *MarketRepo) InsertSpreadEvent(event models.SpreadEvent) error {
+	defer metrics.ObserveSince(metrics.PostgresLatency, "insert_spread_event", time.Now())
+
+	_, err := r.pool.Exec(
+		context.Background(),
+		`INSERT INTO spread_history (pair_name, buy_exchange, sell_exchange, buy_price, sell_price, spread_bps, peak_bps, status, started_at, timestamp)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		event.Pair, event.BuyExchange, event.SellExchange, event.BuyPrice, event.SellPrice,
+		event.SpreadBps, event.PeakBps, event.Status, event.StartedAt, event.Timestamp,
+	)
+	return err
+}
@@ -0,0 +1,262 @@
+// Package recording хранит сырые строки бирж вместе со временем получения.
+//
+// Файл — gzip с текстом:
+//
+//	# marketflow-recording v1 exchange=exchange1 start=1700000000000000000
+//	0	{"symbol":"BTCUSDT","price":43250.5}
+//	1532	BTCUSDT:43251.0
+//
+// Первое поле записи — микросекунды с предыдущей записи (у первой — со start).
+//
+// Recorder сбрасывает буфер и gzip-блок каждые flushLines строк и не позже чем через
+// flushInterval после первой несброшенной, так что при аварийном завершении теряется
+// только хвост. Reader читает такой обрезанный файл до последней целой строки.
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	header    = "# marketflow-recording v1"
+	Extension = ".rec.gz"
+
+	flushLines    = 256
+	flushInterval = time.Second
+)
+
+type Record struct {
+	At   time.Time
+	Line string
+}
+
+// Recorder пишет одну сессию соединения в отдельный файл.
+type Recorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	gz      *gzip.Writer
+	w       *bufio.Writer
+	last    time.Time
+	pending int         // строк после последнего сброса
+	timer   *time.Timer // сброс по времени, пока pending > 0
+	err     error       // ошибка фонового сброса, вернётся из Write или Close
+}
+
+// NewRecorder создаёт файл <dir>/<exchange>-<время>.rec.gz.
+func NewRecorder(dir, exchange string, start time.Time) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create recording dir: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s%s", exchange, start.UTC().Format("20060102T150405.000000000"), Extension)
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("create recording: %w", err)
+	}
+
+	gz := gzip.NewWriter(f)
+	r := &Recorder{file: f, gz: gz, w: bufio.NewWriter(gz), last: start}
+	if _, err := fmt.Fprintf(r.w, "%s exchange=%s start=%d\n", header, exchange, start.UnixNano()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recorder) Write(line string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+
+	delta := at.Sub(r.last).Microseconds()
+	if delta < 0 {
+		delta = 0
+	}
+	r.last = r.last.Add(time.Duration(delta) * time.Microsecond)
+
+	if _, err := fmt.Fprintf(r.w, "%d\t%s\n", delta, line); err != nil {
+		return err
+	}
+	r.pending++
+	switch {
+	case r.pending >= flushLines:
+		return r.flush()
+	case r.pending == 1:
+		r.timer = time.AfterFunc(flushInterval, r.flushPending)
+	}
+	return nil
+}
+
+func (r *Recorder) flushPending() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending > 0 && r.err == nil {
+		r.err = r.flush()
+	}
+}
+
+// flush сбрасывает bufio в gzip, а gzip — в файл. Вызывается под mu.
+func (r *Recorder) flush() error {
+	r.pending = 0
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	if err := r.w.Flush(); err != nil {
+		return err
+	}
+	return r.gz.Flush()
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	if r.err != nil {
+		r.file.Close()
+		return r.err
+	}
+	if err := r.w.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	if err := r.gz.Close(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// Reader последовательно читает записи одного файла.
+type Reader struct {
+	file     *os.File
+	gz       *gzip.Reader
+	scanner  *bufio.Scanner
+	exchange string
+	at       time.Time
+}
+
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	r := &Reader{file: f, gz: gz, scanner: bufio.NewScanner(truncated{gz})}
+	r.scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	r.scanner.Split(completeLines)
+
+	if !r.scanner.Scan() || !strings.HasPrefix(r.scanner.Text(), header) {
+		r.Close()
+		return nil, fmt.Errorf("%s: not a marketflow recording", path)
+	}
+	for _, field := range strings.Fields(strings.TrimPrefix(r.scanner.Text(), header)) {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "exchange":
+			r.exchange = value
+		case "start":
+			nanos, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				r.Close()
+				return nil, fmt.Errorf("%s: invalid start: %w", path, err)
+			}
+			r.at = time.Unix(0, nanos)
+		}
+	}
+	return r, nil
+}
+
+// truncated превращает обрыв gzip-потока в обычный конец файла: запись
+// прерванной сессии заканчивается на последнем сброшенном блоке.
+type truncated struct{ r io.Reader }
+
+func (t truncated) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// completeLines — bufio.ScanLines без последней строки без перевода строки:
+// Recorder завершает каждую запись "\n", так что такая строка — оборванная.
+func completeLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) > 0 && bytes.IndexByte(data, '\n') < 0 {
+		return len(data), nil, nil
+	}
+	return bufio.ScanLines(data, atEOF)
+}
+
+func (r *Reader) Exchange() string {
+	return r.exchange
+}
+
+// Next возвращает следующую запись; ok=false в конце файла.
+func (r *Reader) Next() (Record, bool, error) {
+	if !r.scanner.Scan() {
+		return Record{}, false, r.scanner.Err()
+	}
+
+	deltaStr, line, found := strings.Cut(r.scanner.Text(), "\t")
+	if !found {
+		return Record{}, false, fmt.Errorf("malformed record %q", r.scanner.Text())
+	}
+	delta, err := strconv.ParseInt(deltaStr, 10, 64)
+	if err != nil {
+		return Record{}, false, fmt.Errorf("malformed record delta %q", deltaStr)
+	}
+
+	r.at = r.at.Add(time.Duration(delta) * time.Microsecond)
+	return Record{At: r.at, Line: line}, true, nil
+}
+
+func (r *Reader) Close() error {
+	r.gz.Close()
+	return r.file.Close()
+}
+
+// Files возвращает записи биржи в каталоге в хронологическом порядке.
+// Имя файла — "<биржа>-<время>", в самом времени дефисов нет, поэтому биржа
+// сравнивается с частью до последнего дефиса целиком: записи "ex1" не попадут
+// к "ex", а "ex-2" — к "ex".
+func Files(dir, exchange string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), Extension)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if i := strings.LastIndex(name, "-"); i >= 0 && name[:i] == exchange {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,113 @@
+package recording
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func line(i int) string {
+	return fmt.Sprintf(`{"symbol":"BTCUSDT","price":%d}`, i)
+}
+
+// readAll читает записи файла до конца; ошибка чтения валит тест.
+func readAll(t *testing.T, path string) []Record {
+	t.Helper()
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var records []Record
+	for {
+		rec, ok, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next after %d records: %v", len(records), err)
+		}
+		if !ok {
+			return records
+		}
+		records = append(records, rec)
+	}
+}
+
+// Файл незакрытого Recorder — то, что останется после падения процесса.
+func recorderFile(t *testing.T, dir string) string {
+	t.Helper()
+	files, err := Files(dir, "ex")
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Files = %v, %v; want one recording", files, err)
+	}
+	return files[0]
+}
+
+func TestRecorderFlushesBeforeClose(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, "ex", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Close()
+
+	for i := range flushLines {
+		if err := rec.Write(line(i), start.Add(time.Duration(i)*time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := readAll(t, recorderFile(t, dir)); len(got) != flushLines {
+		t.Fatalf("after %d lines read %d records, want all of them", flushLines, len(got))
+	}
+
+	if err := rec.Write(line(flushLines), start.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(flushInterval + 2*time.Second)
+	for len(readAll(t, recorderFile(t, dir))) != flushLines+1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("last line not flushed %s after the write", flushInterval+2*time.Second)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestReaderStopsAtTruncation(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, "ex", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const lines = 3 * flushLines
+	for i := range lines {
+		if err := rec.Write(line(i), start.Add(time.Duration(i)*time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(recorderFile(t, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cut"+Extension)
+	for _, cut := range []int{1, 8, 9, 100, len(data) / 2, len(data) - 200} {
+		if err := os.WriteFile(path, data[:len(data)-cut], 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got := readAll(t, path)
+		if len(got) == 0 || len(got) > lines {
+			t.Fatalf("cut %d bytes: read %d records, want 1..%d", cut, len(got), lines)
+		}
+		for i, r := range got {
+			if r.Line != line(i) || !r.At.Equal(start.Add(time.Duration(i)*time.Millisecond)) {
+				t.Fatalf("cut %d bytes: record %d = %q at %s, want %q", cut, i, r.Line, r.At, line(i))
+			}
+		}
+	}
+}
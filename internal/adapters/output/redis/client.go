@@ -0,0 +1,139 @@
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// Режимы развёртывания — значения REDIS_MODE; config проверяет их по этим же константам.
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+type TLSOptions struct {
+	Enabled            bool
+	CAFile             string // свой CA; пусто — системные корневые сертификаты
+	CertFile           string // клиентский сертификат (mTLS)
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+type Options struct {
+	Mode     string
+	Addrs    []string // standalone — один адрес; sentinel — адреса sentinel'ов; cluster — узлы
+	Username string   // ACL-пользователь, пусто — default
+	Password string
+	DB       int
+
+	MasterName       string
+	SentinelUsername string
+	SentinelPassword string
+
+	TLS TLSOptions
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolSize     int
+	MinIdleConns int
+}
+
+// NewClient создаёт клиент под режим развёртывания: одиночный сервер,
+// мастер под управлением Sentinel или Redis Cluster.
+func NewClient(opts Options) (redis.UniversalClient, error) {
+	tlsConfig, err := newTLSConfig(opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.Mode {
+	case ModeStandalone, "":
+		if len(opts.Addrs) != 1 {
+			return nil, fmt.Errorf("standalone mode needs exactly one address, got %d", len(opts.Addrs))
+		}
+		return redis.NewClient(&redis.Options{
+			Addr:         opts.Addrs[0],
+			Username:     opts.Username,
+			Password:     opts.Password,
+			DB:           opts.DB,
+			TLSConfig:    tlsConfig,
+			DialTimeout:  opts.DialTimeout,
+			ReadTimeout:  opts.ReadTimeout,
+			WriteTimeout: opts.WriteTimeout,
+			PoolSize:     opts.PoolSize,
+			MinIdleConns: opts.MinIdleConns,
+		}), nil
+
+	case ModeSentinel:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       opts.MasterName,
+			SentinelAddrs:    opts.Addrs,
+			SentinelUsername: opts.SentinelUsername,
+			SentinelPassword: opts.SentinelPassword,
+			Username:         opts.Username,
+			Password:         opts.Password,
+			DB:               opts.DB,
+			TLSConfig:        tlsConfig,
+			DialTimeout:      opts.DialTimeout,
+			ReadTimeout:      opts.ReadTimeout,
+			WriteTimeout:     opts.WriteTimeout,
+			PoolSize:         opts.PoolSize,
+			MinIdleConns:     opts.MinIdleConns,
+		}), nil
+
+	case ModeCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        opts.Addrs,
+			Username:     opts.Username,
+			Password:     opts.Password,
+			TLSConfig:    tlsConfig,
+			DialTimeout:  opts.DialTimeout,
+			ReadTimeout:  opts.ReadTimeout,
+			WriteTimeout: opts.WriteTimeout,
+			PoolSize:     opts.PoolSize,
+			MinIdleConns: opts.MinIdleConns,
+		}), nil
+	}
+	return nil, fmt.Errorf("unknown redis mode %q", opts.Mode)
+}
+
+func newTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	if !opts.Enabled {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read redis CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", opts.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load redis client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
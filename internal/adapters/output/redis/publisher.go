@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+const publishTimeout = 2 * time.Second
+
+// PubSubPublisher рассылает нормализованные тики и свечи через Redis Pub/Sub:
+//
+//	prices:{exchange}:{pair}   — тики биржи по паре
+//	prices:all                 — все тики
+//	candles:{resolution}:{pair} — агрегаты, например candles:1m:BTCUSDT
+type PubSubPublisher struct {
+	client     output.RedisClient
+	resolution string
+}
+
+func NewPubSubPublisher(client output.RedisClient, window time.Duration) *PubSubPublisher {
+	return &PubSubPublisher{
+		client:     client,
+		resolution: formatResolution(window),
+	}
+}
+
+func (p *PubSubPublisher) PublishTick(update models.PriceUpdate) error {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	if err := p.client.Publish(ctx, "prices:"+update.Exchange+":"+update.Pair, data); err != nil {
+		return fmt.Errorf("publish tick: %w", err)
+	}
+	if err := p.client.Publish(ctx, "prices:all", data); err != nil {
+		return fmt.Errorf("publish tick: %w", err)
+	}
+	return nil
+}
+
+func (p *PubSubPublisher) PublishAggregate(agg models.Aggregate) error {
+	data, err := json.Marshal(agg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	if err := p.client.Publish(ctx, "candles:"+p.resolution+":"+agg.Pair, data); err != nil {
+		return fmt.Errorf("publish candle: %w", err)
+	}
+	return nil
+}
+
+// formatResolution печатает окно коротко: 1m, 5m, 1h, 30s.
+func formatResolution(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
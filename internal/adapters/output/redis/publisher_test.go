@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/output/memory"
+	"marketflow/internal/domain/models"
+)
+
+func TestPubSubPublisherTick(t *testing.T) {
+	client := memory.NewRedisClient()
+	p := NewPubSubPublisher(client, time.Minute)
+
+	err := p.PublishTick(models.PriceUpdate{
+		Exchange:  "exchange1",
+		Pair:      "BTCUSDT",
+		Price:     models.MustDecimal("65000.50"),
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 3, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Подписчики разбирают именно эту форму: symbol, цена числом, метки без пустых полей
+	payload := `{"exchange":"exchange1","symbol":"BTCUSDT","price":65000.5,"timestamp":"2024-05-01T12:00:03Z"}`
+	want := []memory.Message{
+		{Channel: "prices:exchange1:BTCUSDT", Payload: payload},
+		{Channel: "prices:all", Payload: payload},
+	}
+	assertMessages(t, client.Messages(), want)
+}
+
+func TestPubSubPublisherAggregate(t *testing.T) {
+	tests := []struct {
+		window  time.Duration
+		channel string
+	}{
+		{time.Minute, "candles:1m:ETHUSDT"},
+		{5 * time.Minute, "candles:5m:ETHUSDT"},
+		{time.Hour, "candles:1h:ETHUSDT"},
+		{90 * time.Minute, "candles:90m:ETHUSDT"},
+		{30 * time.Second, "candles:30s:ETHUSDT"},
+	}
+	for _, tt := range tests {
+		client := memory.NewRedisClient()
+		p := NewPubSubPublisher(client, tt.window)
+
+		err := p.PublishAggregate(models.Aggregate{
+			Exchange:  "exchange2",
+			Pair:      "ETHUSDT",
+			Average:   models.MustDecimal("3000.25"),
+			Min:       models.MustDecimal("2999"),
+			Max:       models.MustDecimal("3001.5"),
+			Count:     42,
+			Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		payload := `{"exchange":"exchange2","symbol":"ETHUSDT","average_price":3000.25,"min_price":2999,"max_price":3001.5,"count":42,"timestamp":"2024-05-01T12:00:00Z"}`
+		assertMessages(t, client.Messages(), []memory.Message{{Channel: tt.channel, Payload: payload}})
+	}
+}
+
+func assertMessages(t *testing.T, got, want []memory.Message) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("published %d messages %v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("message %d:\n got %s %s\nwant %s %s", i, got[i].Channel, got[i].Payload, want[i].Channel, want[i].Payload)
+		}
+	}
+}
@@ -3,33 +3,59 @@ package redis
 import (
 	"context"
 	"marketflow/internal/domain/ports/output"
+	"marketflow/pkg/metrics"
+	"strings"
 	"time"
 
 	redis "github.com/redis/go-redis/v9"
 )
 
-func NewRedisAdapter(client *redis.Client) output.RedisClient {
+// cluster=true включает hash-теги в ключах (см. key).
+func NewRedisAdapter(client redis.UniversalClient, cluster bool) output.RedisClient {
 	return &RedisAdapter{
-		client: client,
+		client:  client,
+		cluster: cluster,
 	}
 }
 
 // отвязываем MarketService от сторонней библиотеки, и сможешь в тестах подменять Redis-зависимость (например, моками).
 type RedisAdapter struct {
-	client *redis.Client
+	client  redis.UniversalClient
+	cluster bool
+}
+
+// key в режиме кластера заключает серию в hash-тег: "exchange1:BTCUSDT" -> "{exchange1:BTCUSDT}",
+// "exchange1:BTCUSDT:latest" -> "{exchange1:BTCUSDT}:latest". Ключи одной серии лежат в одном
+// слоте, а разные биржи и пары расходятся по кластеру и не создают горячий слот.
+func (r *RedisAdapter) key(key string) string {
+	if !r.cluster || strings.Contains(key, "{") {
+		return key
+	}
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) < 2 {
+		return key
+	}
+	tagged := "{" + parts[0] + ":" + parts[1] + "}"
+	if len(parts) == 3 {
+		tagged += ":" + parts[2]
+	}
+	return tagged
 }
 
 func (r *RedisAdapter) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	return r.client.Set(ctx, key, value, expiration).Err()
+	defer metrics.ObserveSince(metrics.RedisLatency, "set", time.Now())
+	return r.client.Set(ctx, r.key(key), value, expiration).Err()
 }
 
 func (r *RedisAdapter) Get(ctx context.Context, key string) (string, error) {
-	return r.client.Get(ctx, key).Result() // ← преобразует *StringCmd в string
+	defer metrics.ObserveSince(metrics.RedisLatency, "get", time.Now())
+	return r.client.Get(ctx, r.key(key)).Result() // ← преобразует *StringCmd в string
 }
 
 // добавляет элемент (member) с числовым значением (score) в отсортированное множество (Sorted Set) Redis по указанному key.
 func (r *RedisAdapter) ZAdd(ctx context.Context, key string, score float64, member interface{}) error {
-	cmd := r.client.ZAdd(ctx, key, redis.Z{
+	defer metrics.ObserveSince(metrics.RedisLatency, "zadd", time.Now())
+	cmd := r.client.ZAdd(ctx, r.key(key), redis.Z{
 		Score:  score,
 		Member: member,
 	})
@@ -38,7 +64,8 @@ func (r *RedisAdapter) ZAdd(ctx context.Context, key string, score float64, memb
 
 // получение данных за последнюю минуту
 func (r *RedisAdapter) ZRangeByScore(ctx context.Context, key string, min, max string) ([]string, error) {
-	return r.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+	defer metrics.ObserveSince(metrics.RedisLatency, "zrangebyscore", time.Now())
+	return r.client.ZRangeByScore(ctx, r.key(key), &redis.ZRangeBy{
 		Min: min,
 		Max: max,
 	}).Result()
@@ -46,6 +73,13 @@ func (r *RedisAdapter) ZRangeByScore(ctx context.Context, key string, min, max s
 
 // удаляем устаревшие записи, чтобы Redis не разрастался бесконечно, старше 60 секунд
 func (r *RedisAdapter) ZRemRangeByScore(ctx context.Context, key string, min, max string) error {
-	cmd := r.client.ZRemRangeByScore(ctx, key, min, max)
+	defer metrics.ObserveSince(metrics.RedisLatency, "zremrangebyscore", time.Now())
+	cmd := r.client.ZRemRangeByScore(ctx, r.key(key), min, max)
 	return cmd.Err()
 }
+
+// отправляет сообщение подписчикам канала Pub/Sub
+func (r *RedisAdapter) Publish(ctx context.Context, channel string, message interface{}) error {
+	defer metrics.ObserveSince(metrics.RedisLatency, "publish", time.Now())
+	return r.client.Publish(ctx, channel, message).Err()
+}
@@ -0,0 +1,28 @@
+package redis
+
+import "testing"
+
+func TestKeyHashTag(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"exchange1:BTCUSDT", "{exchange1:BTCUSDT}"},
+		{"exchange2:BTCUSDT", "{exchange2:BTCUSDT}"},
+		{"exchange1:BTCUSDT:latest", "{exchange1:BTCUSDT}:latest"},
+		{"exchange1:BTCUSDT:a:b", "{exchange1:BTCUSDT}:a:b"},
+		{"{exchange1:BTCUSDT}", "{exchange1:BTCUSDT}"}, // тег уже есть
+		{"standalone", "standalone"},
+	}
+	cluster := &RedisAdapter{cluster: true}
+	for _, tt := range tests {
+		if got := cluster.key(tt.key); got != tt.want {
+			t.Errorf("key(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	standalone := &RedisAdapter{}
+	if got := standalone.key("exchange1:BTCUSDT"); got != "exchange1:BTCUSDT" {
+		t.Errorf("standalone key = %q, want it unchanged", got)
+	}
+}
@@ -0,0 +1,112 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/output/recording"
+	"marketflow/internal/domain/models"
+)
+
+// record пишет сессию биржи с тиками BTCUSDT по указанным ценам, по секунде между ними.
+func record(t *testing.T, dir, exchange string, start time.Time, prices ...string) string {
+	t.Helper()
+	rec, err := recording.NewRecorder(dir, exchange, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, price := range prices {
+		line := `{"symbol":"BTCUSDT","price":` + price + `}`
+		if err := rec.Write(line, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, exchange+"-"+start.UTC().Format("20060102T150405.000000000")+recording.Extension)
+}
+
+func newClient(dir string, speed float64) *ReplayExchangeClient {
+	return NewReplayExchangeClient(dir, speed, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestFilesMatchExchangeExactly(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	want := []string{
+		record(t, dir, "ex", start, "1"),
+		record(t, dir, "ex", start.Add(time.Hour), "2"),
+	}
+	record(t, dir, "ex1", start, "3")
+	record(t, dir, "ex-2", start, "4")
+
+	got, err := recording.Files(dir, "ex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Files(ex) = %v, want %v", got, want)
+	}
+}
+
+func TestReplayPlaysFilesInOrderAndFinishes(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	record(t, dir, "ex", start.Add(time.Hour), "3")
+	record(t, dir, "ex", start, "1", "2")
+	record(t, dir, "ex1", start, "100")
+
+	c := newClient(dir, 0)
+	config := models.ExchangeConfig{Name: "ex"}
+	if err := c.Connect(config); err != nil {
+		t.Fatal(err)
+	}
+
+	updates := make(chan models.PriceUpdate, 10)
+	err := c.Listen(context.Background(), updates, config)
+	if !errors.Is(err, models.ErrFeedFinished) || !errors.Is(err, ErrReplayFinished) {
+		t.Fatalf("Listen err = %v, want ErrFeedFinished", err)
+	}
+	close(updates)
+
+	var prices []string
+	for update := range updates {
+		if update.Exchange != "ex" || update.Timestamp.IsZero() {
+			t.Errorf("unexpected update %+v", update)
+		}
+		prices = append(prices, update.Price.String())
+	}
+	if len(prices) != 3 || prices[0] != "1" || prices[1] != "2" || prices[2] != "3" {
+		t.Errorf("prices = %v, want [1 2 3]", prices)
+	}
+
+	if err := c.Connect(config); !errors.Is(err, models.ErrFeedFinished) {
+		t.Errorf("Connect after finish: err = %v, want ErrFeedFinished", err)
+	}
+}
+
+func TestReplayKeepsRecordedPace(t *testing.T) {
+	dir := t.TempDir()
+	record(t, dir, "ex", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "1", "2", "3")
+
+	c := newClient(dir, 10) // 2s записи за 200ms
+	config := models.ExchangeConfig{Name: "ex"}
+	if err := c.Connect(config); err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Now()
+	err := c.Listen(context.Background(), make(chan models.PriceUpdate, 10), config)
+	if !errors.Is(err, ErrReplayFinished) {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed < 180*time.Millisecond || elapsed > time.Second {
+		t.Errorf("replay took %s, want about 200ms", elapsed)
+	}
+}
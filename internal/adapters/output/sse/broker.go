@@ -0,0 +1,377 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+const (
+	subscriberBuffer = 64
+	heartbeatPeriod  = 15 * time.Second
+	maxResolution    = 24 * time.Hour
+	retryMillis      = 3000
+)
+
+var errSlowConsumer = errors.New("slow consumer")
+
+// Broker раздаёт завершённые свечи по Server-Sent Events:
+// GET /stream/candles?pair=BTCUSDT&resolution=5m[&exchange=exchange1]
+//
+// Id события — курсор соединения: начало последней отправленной свечи по каждой
+// бирже, "exchange1=1700000000,exchange2=1700000060". При переподключении с
+// Last-Event-ID каждая биржа досылается из market_data со своего следующего бакета.
+//
+// Если разрешение крупнее окна агрегатора, свеча собирается из окон. Окна текущей,
+// ещё не закрытой свечи, записанные до подключения, берутся из market_data.
+type Broker struct {
+	window time.Duration // окно агрегатора, минимальное разрешение
+	repo   output.AggregateReader
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+func NewBroker(window time.Duration, repo output.AggregateReader, logger *slog.Logger) *Broker {
+	return &Broker{
+		window: window,
+		repo:   repo,
+		logger: logger,
+		now:    time.Now,
+		subs:   make(map[*subscriber]struct{}),
+	}
+}
+
+// PublishTick — тики в SSE не раздаются.
+func (b *Broker) PublishTick(models.PriceUpdate) error { return nil }
+
+func (b *Broker) PublishAggregate(agg models.Aggregate) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if agg.Pair != sub.pair || (sub.exchange != "" && agg.Exchange != sub.exchange) {
+			continue
+		}
+		if !sub.seeded {
+			if len(sub.early) >= subscriberBuffer {
+				b.drop(sub)
+				continue
+			}
+			sub.early = append(sub.early, agg)
+			continue
+		}
+		b.deliver(sub, sub.add(agg, b.window))
+	}
+	return nil
+}
+
+// deliver отправляет завершённые свечи подписчику. Вызывается под мьютексом.
+func (b *Broker) deliver(sub *subscriber, candles []models.Aggregate) bool {
+	for _, candle := range candles {
+		select {
+		case sub.events <- candle:
+		default:
+			// Клиент не успевает читать — закрываем, он переподключится с Last-Event-ID
+			b.drop(sub)
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Broker) drop(sub *subscriber) {
+	delete(b.subs, sub)
+	sub.cancel(errSlowConsumer)
+}
+
+// seed начинает незакрытые свечи подписчика с окон из market_data и окон,
+// пришедших, пока они читались. Окно, которое есть и там и там, берётся один раз.
+func (b *Broker) seed(sub *subscriber, stored []models.Aggregate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+
+	type key struct {
+		exchange string
+		start    int64
+	}
+	windows := make(map[key]models.Aggregate, len(stored)+len(sub.early))
+	for _, agg := range append(stored, sub.early...) {
+		windows[key{agg.Exchange, agg.Timestamp.UnixNano()}] = agg
+	}
+	ordered := slices.Collect(maps.Values(windows))
+	sort.Slice(ordered, func(i, j int) bool {
+		if !ordered[i].Timestamp.Equal(ordered[j].Timestamp) {
+			return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+		}
+		return ordered[i].Exchange < ordered[j].Exchange
+	})
+
+	sub.early, sub.seeded = nil, true
+	for _, agg := range ordered {
+		if !b.deliver(sub, sub.add(agg, b.window)) {
+			return
+		}
+	}
+}
+
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	pair := pairSymbol(query.Get("pair"))
+	if pair == "" {
+		http.Error(w, "pair is required", http.StatusBadRequest)
+		return
+	}
+
+	resolution := b.window
+	if raw := query.Get("resolution"); raw != "" {
+		var err error
+		if resolution, err = time.ParseDuration(raw); err != nil {
+			http.Error(w, "invalid resolution", http.StatusBadRequest)
+			return
+		}
+	}
+	if resolution < b.window || resolution%b.window != 0 || resolution > maxResolution {
+		http.Error(w, fmt.Sprintf("resolution must be a multiple of %s up to %s", b.window, maxResolution), http.StatusBadRequest)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = query.Get("lastEventId")
+	}
+
+	ctx, cancel := context.WithCancelCause(r.Context())
+	defer cancel(nil)
+
+	sub := &subscriber{
+		pair:       pair,
+		exchange:   query.Get("exchange"),
+		resolution: resolution,
+		events:     make(chan models.Aggregate, subscriberBuffer),
+		cancel:     cancel,
+		pending:    make(map[string][]models.Aggregate),
+		seeded:     resolution == b.window,
+	}
+
+	// Подписываемся до чтения истории, чтобы не потерять свечи между ними
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.subs, sub)
+		b.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+	flusher.Flush()
+
+	// История отдаётся закрытыми свечами до openFrom, незакрытые свечи с openFrom
+	// собираются из окон. Последнее окно могло ещё не дойти до агрегатора, поэтому
+	// его свеча тоже считается незакрытой.
+	openFrom := b.now().Truncate(resolution)
+	if !sub.seeded {
+		openFrom = b.now().Truncate(b.window).Add(-b.window).Truncate(resolution)
+	}
+
+	sent := make(cursor)
+	if lastID != "" {
+		if from, ok := sent.parse(lastID); !ok {
+			b.logger.Warn("Invalid Last-Event-ID", "value", lastID)
+		} else {
+			err := b.repo.QueryAggregates(ctx, output.AggregateQuery{
+				Pair:       sub.pair,
+				Exchange:   sub.exchange,
+				From:       from,
+				To:         openFrom,
+				Resolution: resolution,
+			}, func(agg models.Aggregate) error {
+				if !sent.advance(agg) {
+					return nil
+				}
+				return writeEvent(w, agg, sent)
+			})
+			if err != nil {
+				b.logger.Error("Failed to replay candles", "pair", sub.pair, "error", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+
+	if !sub.seeded {
+		var stored []models.Aggregate
+		err := b.repo.QueryAggregates(ctx, output.AggregateQuery{
+			Pair:       sub.pair,
+			Exchange:   sub.exchange,
+			From:       openFrom,
+			To:         b.now().Add(b.window),
+			Resolution: b.window,
+		}, func(agg models.Aggregate) error {
+			stored = append(stored, agg)
+			return nil
+		})
+		if err != nil {
+			b.logger.Error("Failed to read open candles", "pair", sub.pair, "error", err)
+			return
+		}
+		b.seed(sub, stored)
+	}
+
+	heartbeat := time.NewTicker(heartbeatPeriod)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(context.Cause(ctx), errSlowConsumer) {
+				b.logger.Warn("Disconnecting slow SSE consumer", "remote", r.RemoteAddr)
+			}
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case agg := <-sub.events:
+			// Уже отправлено из истории
+			if !sent.advance(agg) {
+				continue
+			}
+			if err := writeEvent(w, agg, sent); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, agg models.Aggregate, sent cursor) error {
+	data, err := json.Marshal(agg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: candle\ndata: %s\n\n", sent, data)
+	return err
+}
+
+// cursor — начало последней отправленной свечи по биржам.
+type cursor map[string]time.Time
+
+// advance отмечает свечу отправленной; false — свеча биржи за этот бакет уже ушла.
+func (c cursor) advance(agg models.Aggregate) bool {
+	if last, ok := c[agg.Exchange]; ok && !agg.Timestamp.After(last) {
+		return false
+	}
+	c[agg.Exchange] = agg.Timestamp
+	return true
+}
+
+func (c cursor) String() string {
+	parts := make([]string, 0, len(c))
+	for _, exchange := range slices.Sorted(maps.Keys(c)) {
+		parts = append(parts, exchange+"="+strconv.FormatInt(c[exchange].Unix(), 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// parse заполняет курсор из Last-Event-ID и возвращает, с какого бакета читать
+// историю: с самого раннего из курсора — биржи, которых в нём нет, досылаются
+// с него же.
+func (c cursor) parse(id string) (from time.Time, ok bool) {
+	parsed := make(cursor)
+	for _, part := range strings.Split(id, ",") {
+		exchange, raw, _ := strings.Cut(part, "=")
+		since, err := strconv.ParseInt(raw, 10, 64)
+		if exchange == "" || err != nil {
+			return time.Time{}, false
+		}
+		parsed[exchange] = time.Unix(since, 0)
+		if from.IsZero() || parsed[exchange].Before(from) {
+			from = parsed[exchange]
+		}
+	}
+	maps.Copy(c, parsed)
+	return from, true
+}
+
+// pairSymbol приводит пару из запроса ("BTC/USDT", "btc-usdt") к символу свечей.
+func pairSymbol(raw string) string {
+	if pair, ok := models.ParsePair(raw); ok {
+		return pair.Symbol()
+	}
+	return strings.ToUpper(strings.TrimSpace(raw))
+}
+
+type subscriber struct {
+	pair       string
+	exchange   string
+	resolution time.Duration
+	events     chan models.Aggregate
+	cancel     context.CancelCauseFunc
+
+	// Окна агрегатора, накопленные для текущей свечи, по биржам
+	pending map[string][]models.Aggregate
+
+	// Пока незакрытые свечи не начаты из market_data, окна копятся в early
+	seeded bool
+	early  []models.Aggregate
+}
+
+// add копит окна агрегатора и возвращает свечи, которые завершились.
+// Вызывается под мьютексом брокера.
+func (s *subscriber) add(agg models.Aggregate, window time.Duration) []models.Aggregate {
+	if s.resolution == window {
+		return []models.Aggregate{agg}
+	}
+
+	var done []models.Aggregate
+	bucket := agg.Timestamp.Truncate(s.resolution)
+	pending := s.pending[agg.Exchange]
+
+	// Пришло окно следующей свечи, а предыдущая не закрылась (пропуск данных)
+	if len(pending) > 0 && !pending[0].Timestamp.Truncate(s.resolution).Equal(bucket) {
+		if candle, ok := models.MergeAggregates(pending, pending[0].Timestamp.Truncate(s.resolution)); ok {
+			done = append(done, candle)
+		}
+		pending = nil
+	}
+
+	pending = append(pending, agg)
+	if !agg.Timestamp.Add(window).Before(bucket.Add(s.resolution)) {
+		if candle, ok := models.MergeAggregates(pending, bucket); ok {
+			done = append(done, candle)
+		}
+		pending = nil
+	}
+
+	s.pending[agg.Exchange] = pending
+	return done
+}
@@ -0,0 +1,261 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+// candleStore — market_data с окнами ровно по разрешению; gate задерживает чтение истории.
+type candleStore struct {
+	candles []models.Aggregate
+	gate    chan struct{}
+}
+
+func (s *candleStore) QueryAggregates(ctx context.Context, q output.AggregateQuery, fn func(models.Aggregate) error) error {
+	if s.gate != nil {
+		<-s.gate
+	}
+	for _, agg := range s.candles {
+		if agg.Pair != q.Pair || (q.Exchange != "" && agg.Exchange != q.Exchange) {
+			continue
+		}
+		if agg.Timestamp.Before(q.From) || !agg.Timestamp.Before(q.To) {
+			continue
+		}
+		if err := fn(agg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var t0 = time.Unix(1_700_000_040, 0).Truncate(time.Minute)
+
+func candle(exchange string, bucket int) models.Aggregate {
+	price := models.MustDecimal("100")
+	return models.Aggregate{
+		Exchange: exchange, Pair: "BTCUSDT", Average: price, Min: price, Max: price, Count: 1,
+		Timestamp: t0.Add(time.Duration(bucket) * time.Minute),
+	}
+}
+
+type stream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+}
+
+// open подключается к /stream/candles и ждёт, пока брокер зарегистрирует подписку.
+func open(t *testing.T, b *Broker, query, lastID string) *stream {
+	t.Helper()
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"?"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+
+	for deadline := time.Now().Add(time.Second); b.subscribers() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription not registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return &stream{body: resp.Body, reader: bufio.NewReader(resp.Body)}
+}
+
+func (b *Broker) subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// next возвращает id и свечу следующего события, пропуская retry и пинги.
+func (s *stream) next(t *testing.T) (string, models.Aggregate) {
+	t.Helper()
+	var id string
+	var agg models.Aggregate
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &agg); err != nil {
+				t.Fatal(err)
+			}
+		case line == "" && id != "":
+			return id, agg
+		}
+	}
+}
+
+func newBroker(repo output.AggregateReader) *Broker {
+	return NewBroker(time.Minute, repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestBrokerStreamsEveryExchange(t *testing.T) {
+	b := newBroker(&candleStore{})
+	s := open(t, b, "pair=btc/usdt", "")
+
+	// Свечи двух бирж за один бакет приходят обе
+	b.PublishAggregate(candle("exchange1", 0))
+	b.PublishAggregate(candle("exchange2", 0))
+	b.PublishAggregate(candle("exchange1", 0)) // повтор не отправляется
+	b.PublishAggregate(candle("exchange1", 1))
+
+	want := []struct {
+		exchange string
+		id       string
+	}{
+		{"exchange1", "exchange1=1700000040"},
+		{"exchange2", "exchange1=1700000040,exchange2=1700000040"},
+		{"exchange1", "exchange1=1700000100,exchange2=1700000040"},
+	}
+	for i, w := range want {
+		id, agg := s.next(t)
+		if id != w.id || agg.Exchange != w.exchange {
+			t.Errorf("event %d: id %q exchange %s, want %q %s", i, id, agg.Exchange, w.id, w.exchange)
+		}
+	}
+}
+
+func TestBrokerResumesEachExchangeFromItsCursor(t *testing.T) {
+	repo := &candleStore{candles: []models.Aggregate{
+		candle("exchange1", 0), candle("exchange2", 0),
+		candle("exchange1", 1), candle("exchange2", 1), candle("exchange3", 1),
+	}}
+	b := newBroker(repo)
+
+	// exchange2 за бакет 0 клиент ещё не получил; exchange3 не видел вовсе
+	s := open(t, b, "pair=BTCUSDT", "exchange1=1700000040")
+
+	want := []string{"exchange2@0", "exchange1@1", "exchange2@1", "exchange3@1"}
+	for i, w := range want {
+		_, agg := s.next(t)
+		if got := fmt.Sprintf("%s@%d", agg.Exchange, agg.Timestamp.Sub(t0)/time.Minute); got != w {
+			t.Errorf("replayed event %d = %s, want %s", i, got, w)
+		}
+	}
+
+	// Живая свеча, уже досланная из истории, не повторяется
+	b.PublishAggregate(candle("exchange1", 1))
+	b.PublishAggregate(candle("exchange1", 2))
+	if id, agg := s.next(t); !agg.Timestamp.Equal(t0.Add(2 * time.Minute)) {
+		t.Errorf("live event %s at %s, want bucket 2", id, agg.Timestamp)
+	}
+}
+
+func window(exchange string, start time.Time, price string) models.Aggregate {
+	p := models.MustDecimal(price)
+	return models.Aggregate{Exchange: exchange, Pair: "BTCUSDT", Average: p, Min: p, Max: p, Count: 1, Timestamp: start}
+}
+
+// Свеча 5m при окне 1m: окна текущей свечи, записанные до подключения, берутся
+// из market_data, а окно, пришедшее и оттуда и вживую, учитывается один раз.
+func TestBrokerSeedsOpenCandleFromHistory(t *testing.T) {
+	bucket := t0.Truncate(5 * time.Minute)
+	at := func(minute int) time.Time { return bucket.Add(time.Duration(minute) * time.Minute) }
+
+	repo := &candleStore{
+		candles: []models.Aggregate{
+			window("exchange1", at(-1), "50"), // прошлая свеча
+			window("exchange1", at(0), "100"),
+			window("exchange1", at(1), "90"),
+			window("exchange1", at(2), "120"),
+		},
+		gate: make(chan struct{}),
+	}
+	b := newBroker(repo)
+	b.now = func() time.Time { return at(3).Add(30 * time.Second) }
+	s := open(t, b, "pair=BTCUSDT&resolution=5m", "")
+
+	// Пока брокер читает market_data
+	b.PublishAggregate(window("exchange1", at(2), "120"))
+	b.PublishAggregate(window("exchange1", at(3), "110"))
+	close(repo.gate)
+	b.PublishAggregate(window("exchange1", at(4), "105"))
+
+	_, agg := s.next(t)
+	if !agg.Timestamp.Equal(bucket) || agg.Count != 5 || agg.Min.String() != "90" || agg.Max.String() != "120" || agg.Average.String() != "105" {
+		t.Errorf("first candle = %+v, want 5 windows 90..120 avg 105 at %s", agg, bucket.Format(time.TimeOnly))
+	}
+}
+
+// В первую минуту свечи последнее окно прошлой ещё не агрегировано: прошлая
+// свеча не отдаётся из истории обрезанной, а дособирается из окон.
+func TestBrokerSeedsCandleAwaitingLastWindow(t *testing.T) {
+	bucket := t0.Truncate(5 * time.Minute)
+	at := func(minute int) time.Time { return bucket.Add(time.Duration(minute) * time.Minute) }
+
+	var candles []models.Aggregate
+	for minute := range 4 {
+		candles = append(candles, window("exchange1", at(minute), "100"))
+	}
+	b := newBroker(&candleStore{candles: candles})
+	b.now = func() time.Time { return at(5).Add(500 * time.Millisecond) }
+	s := open(t, b, "pair=BTCUSDT&resolution=5m", "exchange1="+strconv.FormatInt(at(-5).Unix(), 10))
+
+	b.PublishAggregate(window("exchange1", at(4), "100"))
+	if _, agg := s.next(t); !agg.Timestamp.Equal(bucket) || agg.Count != 5 {
+		t.Errorf("candle = %d windows at %s, want 5 at %s", agg.Count,
+			agg.Timestamp.Format(time.TimeOnly), bucket.Format(time.TimeOnly))
+	}
+}
+
+func TestBrokerDisconnectsSlowConsumer(t *testing.T) {
+	// Пока брокер читает историю, события копятся в буфере подписчика
+	repo := &candleStore{gate: make(chan struct{})}
+	b := newBroker(repo)
+	s := open(t, b, "pair=BTCUSDT", "exchange1=1700000040")
+
+	for i := range subscriberBuffer + 1 {
+		b.PublishAggregate(candle("exchange1", i+1))
+	}
+	if n := b.subscribers(); n != 0 {
+		t.Fatalf("%d subscribers after overflow, want 0", n)
+	}
+
+	close(repo.gate)
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, s.body)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("stream ended with %v, want EOF", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow consumer stream still open")
+	}
+}
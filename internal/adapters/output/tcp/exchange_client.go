@@ -3,24 +3,41 @@ package tcp
 import (
 	"bufio"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"strings"
+	"sync"
 	"time"
 
+	"marketflow/internal/adapters/output/exchangeproto"
+	"marketflow/internal/adapters/output/recording"
 	"marketflow/internal/domain/models"
+	applog "marketflow/pkg/logger"
+	"marketflow/pkg/metrics"
+	"marketflow/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
+// TCPExchangeClient один на все биржи, поэтому соединения хранятся по имени биржи.
+// Если задан recordDir, каждая сессия пишется в файл для replay.
 type TCPExchangeClient struct {
-	logger *slog.Logger
-	conn   net.Conn
+	logger    *slog.Logger
+	parseLog  *slog.Logger // битые сообщения идут потоком — семплируются
+	recordDir string
+	mu        sync.Mutex
+	conns     map[string]net.Conn
 }
 
-func NewTCPExchangeClient(logger *slog.Logger) *TCPExchangeClient {
+func NewTCPExchangeClient(logger *slog.Logger, recordDir string) *TCPExchangeClient {
 	return &TCPExchangeClient{
-		logger: logger,
+		logger:    logger,
+		parseLog:  applog.Sampled(logger),
+		recordDir: recordDir,
+		conns:     make(map[string]net.Conn),
 	}
 }
 
@@ -33,22 +50,50 @@ func (c *TCPExchangeClient) Connect(config models.ExchangeConfig) error {
 		return fmt.Errorf("failed to connect to %s: %w", config.Name, err)
 	}
 
-	c.conn = conn
+	c.mu.Lock()
+	c.conns[config.Name] = conn
+	c.mu.Unlock()
+
+	metrics.ConnectionUp.WithLabelValues(config.Name).Set(1)
+	metrics.ConnectedSince.WithLabelValues(config.Name).SetToCurrentTime()
+
 	c.logger.Info("Connected to exchange", "exchange", config.Name)
 	return nil
 }
 
 func (c *TCPExchangeClient) Listen(ctx context.Context, updates chan<- models.PriceUpdate, exchange models.ExchangeConfig) error {
-	if c.conn == nil {
+	c.mu.Lock()
+	conn := c.conns[exchange.Name]
+	c.mu.Unlock()
+	if conn == nil {
 		return fmt.Errorf("not connected")
 	}
 
-	defer c.conn.Close()
+	defer func() {
+		c.mu.Lock()
+		if c.conns[exchange.Name] == conn {
+			delete(c.conns, exchange.Name)
+		}
+		c.mu.Unlock()
+		conn.Close()
+		metrics.ConnectionUp.WithLabelValues(exchange.Name).Set(0)
+	}()
+
+	// Запись сессии для последующего воспроизведения
+	var recorder *recording.Recorder
+	if c.recordDir != "" {
+		var err error
+		if recorder, err = recording.NewRecorder(c.recordDir, exchange.Name, time.Now()); err != nil {
+			c.logger.Error("Failed to start recording", "exchange", exchange.Name, "error", err)
+		} else {
+			defer recorder.Close()
+		}
+	}
 
 	// Set read timeout
-	c.conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
 
-	scanner := bufio.NewScanner(c.conn)
+	scanner := bufio.NewScanner(conn)
 
 	for scanner.Scan() {
 		select {
@@ -57,26 +102,17 @@ func (c *TCPExchangeClient) Listen(ctx context.Context, updates chan<- models.Pr
 		default:
 		}
 
+		receivedAt := time.Now()
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
 		}
+		metrics.TicksReceived.WithLabelValues(exchange.Name).Inc()
 
-		update, err := c.parseMessage(line, exchange.Name) // передаю имя биржи
-		if err != nil {
-			c.logger.Warn("Failed to parse message", "message", line, "error", err)
-			continue
-		}
-
-		c.conn.SetReadDeadline(time.Now().Add(30 * time.Second))
-
-		select {
-		case updates <- update:
-		case <-ctx.Done():
+		if !c.handleLine(ctx, line, receivedAt, recorder, updates, exchange) {
 			return nil
-		default:
-			c.logger.Warn("Updates channel full, dropping update")
 		}
+		conn.SetReadDeadline(time.Now().Add(30 * time.Second))
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -86,86 +122,62 @@ func (c *TCPExchangeClient) Listen(ctx context.Context, updates chan<- models.Pr
 	return fmt.Errorf("connection closed")
 }
 
-func (c *TCPExchangeClient) Close() error {
-	if c.conn != nil {
-		return c.conn.Close()
+// handleLine записывает, разбирает и ставит строку в очередь под спаном exchange.receive;
+// контекст спана уходит дальше вместе с тиком. false — контекст отменён.
+func (c *TCPExchangeClient) handleLine(ctx context.Context, line string, receivedAt time.Time, recorder *recording.Recorder, updates chan<- models.PriceUpdate, exchange models.ExchangeConfig) bool {
+	_, span := tracing.Start(ctx, tracing.SpanReceive,
+		attribute.String("exchange", exchange.Name),
+		attribute.Int("bytes", len(line)),
+	)
+	defer span.End()
+
+	if recorder != nil {
+		if err := recorder.Write(line, receivedAt); err != nil {
+			c.logger.Error("Failed to record line", "exchange", exchange.Name, "error", err)
+		}
 	}
-	return nil
-}
 
-func (c *TCPExchangeClient) parseMessage(message, exchangeName string) (models.PriceUpdate, error) {
-	// Try to parse as JSON first
-	var jsonData map[string]interface{}
-	if err := json.Unmarshal([]byte(message), &jsonData); err == nil {
-		return c.parseJSONMessage(jsonData, exchangeName)
+	update, err := exchangeproto.ParseMessage(line, exchange.Name) // передаю имя биржи
+	if err != nil {
+		metrics.TicksDropped.WithLabelValues(exchange.Name, "", metrics.DropParseError).Inc()
+		c.parseLog.Warn("Failed to parse message", "exchange", exchange.Name, "message", line, "error", err)
+		span.SetStatus(codes.Error, "parse error")
+		return true
 	}
-
-	// Try to parse as simple format: SYMBOL:PRICE
-	parts := strings.Split(message, ":")
-	if len(parts) == 2 {
-		symbol := strings.TrimSpace(parts[0])
-		priceStr := strings.TrimSpace(parts[1])
-
-		var price float64
-		if _, err := fmt.Sscanf(priceStr, "%f", &price); err != nil {
-			return models.PriceUpdate{}, fmt.Errorf("invalid price format: %s", priceStr)
-		}
-
-		return models.PriceUpdate{
-			Exchange:  exchangeName,
-			Pair:      symbol,
-			Price:     price,
-			Timestamp: time.Now(),
-		}, nil
+	span.SetAttributes(attribute.String("pair", update.Pair))
+	update.Timestamp = receivedAt
+	update.ReceivedAt = receivedAt
+	update.TraceParent = tracing.TraceParent(span)
+
+	// Не отбрасываем: что делать при переполнении, решает политика очереди биржи в сервисе
+	select {
+	case updates <- update:
+	case <-ctx.Done():
+		return false
 	}
+	return true
+}
 
-	// Try to parse as space-separated: SYMBOL PRICE
-	parts = strings.Fields(message)
-	if len(parts) >= 2 {
-		symbol := parts[0]
-		priceStr := parts[1]
-
-		var price float64
-		if _, err := fmt.Sscanf(priceStr, "%f", &price); err != nil {
-			return models.PriceUpdate{}, fmt.Errorf("invalid price format: %s", priceStr)
-		}
-
-		return models.PriceUpdate{
-			Exchange:  exchangeName,
-			Pair:      symbol,
-			Price:     price,
-			Timestamp: time.Now(),
-		}, nil
+// Disconnect закрывает соединение одной биржи; её Listen вернёт ошибку чтения.
+func (c *TCPExchangeClient) Disconnect(exchange string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if conn := c.conns[exchange]; conn != nil {
+		conn.Close()
+		delete(c.conns, exchange)
 	}
-
-	return models.PriceUpdate{}, fmt.Errorf("unknown message format: %s", message)
 }
 
-func (c *TCPExchangeClient) parseJSONMessage(data map[string]interface{}, exchangeName string) (models.PriceUpdate, error) {
-	symbol, ok := data["symbol"].(string)
-	if !ok {
-		if s, ok := data["pair"].(string); ok {
-			symbol = s
-		} else {
-			return models.PriceUpdate{}, fmt.Errorf("missing symbol/pair field")
-		}
-	}
+func (c *TCPExchangeClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	var price float64
-	if p, ok := data["price"].(float64); ok {
-		price = p
-	} else if p, ok := data["price"].(string); ok {
-		if _, err := fmt.Sscanf(p, "%f", &price); err != nil {
-			return models.PriceUpdate{}, fmt.Errorf("invalid price format: %s", p)
+	var errs []error
+	for name, conn := range c.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", name, err))
 		}
-	} else {
-		return models.PriceUpdate{}, fmt.Errorf("missing or invalid price field")
+		delete(c.conns, name)
 	}
-
-	return models.PriceUpdate{
-		Exchange:  exchangeName,
-		Pair:      symbol,
-		Price:     price,
-		Timestamp: time.Now(),
-	}, nil
+	return errors.Join(errs...)
 }
@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+var errForbiddenHost = errors.New("webhook host is not allowed")
+
+// AlertNotifier отправляет сработавшие правила POST-запросом.
+// Заголовок Idempotency-Key совпадает с DedupKey события и не меняется между повторами.
+//
+// URL вебхука задаёт пользователь API, поэтому без списка hosts запросы уходят только
+// на публичные адреса: loopback, частные, link-local, CGNAT и прочие служебные сети
+// недоступны, в том числе в виде IPv4-mapped, NAT64 и 6to4. Со списком —
+// только на перечисленные хосты, зато на любые их адреса.
+type AlertNotifier struct {
+	client *http.Client
+}
+
+func NewAlertNotifier(timeout time.Duration, hosts []string) *AlertNotifier {
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialAllowed(ctx, dialer, hosts, network, addr)
+		},
+		TLSHandshakeTimeout: timeout,
+	}
+	return &AlertNotifier{client: &http.Client{Timeout: timeout, Transport: transport}}
+}
+
+// dialAllowed проверяет хост при каждом соединении, в том числе после редиректа,
+// и соединяется с тем адресом, который проверил, а не резолвит имя повторно.
+func dialAllowed(ctx context.Context, dialer *net.Dialer, hosts []string, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if len(hosts) > 0 {
+		if !slices.Contains(hosts, strings.ToLower(host)) {
+			return nil, fmt.Errorf("%w: %s", errForbiddenHost, host)
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if !publicIP(ip.IP) {
+			return nil, fmt.Errorf("%w: %s resolves to %s", errForbiddenHost, host, ip.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses for %s", host)
+	}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+}
+
+// deniedPrefixes — сети, куда вебхук без ALERT_WEBHOOK_HOSTS не ходит: всё, что
+// не маршрутизируется в интернете (RFC 6890), включая CGNAT и бенчмарк-сеть.
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // «эта» сеть
+	netip.MustParsePrefix("10.0.0.0/8"),      // частная
+	netip.MustParsePrefix("100.64.0.0/10"),   // CGNAT
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local, в том числе метаданные облаков
+	netip.MustParsePrefix("172.16.0.0/12"),   // частная
+	netip.MustParsePrefix("192.0.0.0/24"),    // служебные IETF
+	netip.MustParsePrefix("192.0.2.0/24"),    // документация
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 relay
+	netip.MustParsePrefix("192.168.0.0/16"),  // частная
+	netip.MustParsePrefix("198.18.0.0/15"),   // бенчмарки
+	netip.MustParsePrefix("198.51.100.0/24"), // документация
+	netip.MustParsePrefix("203.0.113.0/24"),  // документация
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // зарезервирована, broadcast
+	netip.MustParsePrefix("::/128"),          // unspecified
+	netip.MustParsePrefix("::1/128"),         // loopback
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // локальный NAT64: длину префикса выбирает оператор
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001:db8::/32"),   // документация
+	netip.MustParsePrefix("fc00::/7"),        // unique local
+	netip.MustParsePrefix("fe80::/10"),       // link-local
+	netip.MustParsePrefix("ff00::/8"),        // multicast
+}
+
+// IPv4 внутри NAT64 (64:ff9b::/96) и 6to4 (2002::/16) проверяется как IPv4:
+// через такие адреса шлюз довёл бы запрос до частной IPv4-сети.
+var (
+	nat64Prefix     = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+)
+
+func publicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap() // ::ffff:10.0.0.1 — тот же 10.0.0.1
+	b := addr.As16()
+	switch {
+	case nat64Prefix.Contains(addr):
+		addr = netip.AddrFrom4([4]byte(b[12:16]))
+	case sixToFourPrefix.Contains(addr):
+		addr = netip.AddrFrom4([4]byte(b[2:6]))
+	}
+	for _, prefix := range deniedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *AlertNotifier) Notify(ctx context.Context, url string, event models.AlertEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", event.DedupKey)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alert webhook: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook: unexpected status %s", resp.Status)
+	}
+	return nil
+}
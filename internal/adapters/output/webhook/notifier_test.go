@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+func TestAlertNotifierRefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	event := models.AlertEvent{DedupKey: "1"}
+
+	err := NewAlertNotifier(time.Second, nil).Notify(context.Background(), srv.URL, event)
+	if !errors.Is(err, errForbiddenHost) {
+		t.Errorf("loopback without ALERT_WEBHOOK_HOSTS: err = %v, want errForbiddenHost", err)
+	}
+
+	if err := NewAlertNotifier(time.Second, []string{"127.0.0.1"}).Notify(context.Background(), srv.URL, event); err != nil {
+		t.Errorf("allowed host: %v", err)
+	}
+	err = NewAlertNotifier(time.Second, []string{"hooks.internal"}).Notify(context.Background(), srv.URL, event)
+	if !errors.Is(err, errForbiddenHost) {
+		t.Errorf("host outside ALERT_WEBHOOK_HOSTS: err = %v, want errForbiddenHost", err)
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"8.8.8.8":                true,
+		"1.1.1.1":                true,
+		"2606:4700::1111":        true,
+		"::ffff:8.8.8.8":         true,
+		"64:ff9b::808:808":       true, // NAT64 к 8.8.8.8
+		"2002:808:808::1":        true, // 6to4 через 8.8.8.8
+		"127.0.0.1":              false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"100.64.0.1":             false,
+		"100.127.255.254":        false,
+		"0.0.0.0":                false,
+		"0.1.2.3":                false,
+		"198.18.0.1":             false,
+		"198.19.255.255":         false,
+		"192.0.0.8":              false,
+		"224.0.0.1":              false,
+		"255.255.255.255":        false,
+		"::":                     false,
+		"::1":                    false,
+		"fd00::1":                false,
+		"fe80::1":                false,
+		"ff02::1":                false,
+		"::ffff:127.0.0.1":       false,
+		"::ffff:10.0.0.1":        false,
+		"::ffff:169.254.169.254": false,
+		"64:ff9b::a00:1":         false, // NAT64 к 10.0.0.1
+		"64:ff9b::7f00:1":        false, // NAT64 к 127.0.0.1
+		"64:ff9b:1::a9fe:a9fe":   false, // локальный NAT64 к 169.254.169.254
+		"2002:c0a8:101::1":       false, // 6to4 через 192.168.1.1
+	} {
+		if got := publicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("publicIP(%s) = %t, want %t", addr, got, want)
+		}
+	}
+}
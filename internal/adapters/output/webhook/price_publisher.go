@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+type payload struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// WebhookPricePublisher отправляет каждое событие POST-запросом с JSON-телом.
+type WebhookPricePublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookPricePublisher(url string, timeout time.Duration) *WebhookPricePublisher {
+	return &WebhookPricePublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *WebhookPricePublisher) PublishTick(update models.PriceUpdate) error {
+	return p.post(payload{Type: "tick", Data: update})
+}
+
+func (p *WebhookPricePublisher) PublishAggregate(agg models.Aggregate) error {
+	return p.post(payload{Type: "aggregate", Data: agg})
+}
+
+func (p *WebhookPricePublisher) post(body payload) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, p.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook post: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook post: unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package ws
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+	maxMessage = 4096
+)
+
+const (
+	TypeTick      = "tick"
+	TypeAggregate = "aggregate"
+)
+
+// Message — то, что уходит клиенту.
+type Message struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// request — команда от клиента: {"action":"subscribe","pairs":["BTCUSDT"],"exchanges":["exchange1"]}
+type request struct {
+	Action    string   `json:"action"`
+	Pairs     []string `json:"pairs"`
+	Exchanges []string `json:"exchanges"`
+	Types     []string `json:"types"`
+}
+
+// Hub раздаёт живые тики и агрегаты подписанным WebSocket-клиентам.
+// У каждого клиента ограниченный буфер; если клиент не успевает читать, его отключаем.
+// Соединение закрывает только горутина записи клиента, поэтому рассылка не ждёт сети.
+type Hub struct {
+	logger     *slog.Logger
+	bufferSize int
+	upgrader   websocket.Upgrader
+
+	mu      sync.RWMutex
+	clients map[*client]struct{}
+}
+
+// NewHub: origins — разрешённые Origin браузерных клиентов ("https://app.example.com"),
+// "*" — любые. Без списка принимаются только запросы с того же хоста и без Origin (CLI).
+func NewHub(bufferSize int, origins []string, logger *slog.Logger) *Hub {
+	return &Hub{
+		logger:     logger,
+		bufferSize: bufferSize,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkOrigin(origins),
+		},
+		clients: make(map[*client]struct{}),
+	}
+}
+
+// checkOrigin возвращает nil для пустого списка — тогда gorilla сравнивает Origin с Host.
+func checkOrigin(origins []string) func(r *http.Request) bool {
+	if len(origins) == 0 {
+		return nil
+	}
+	if slices.Contains(origins, "*") {
+		return func(r *http.Request) bool { return true }
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		return slices.ContainsFunc(origins, func(allowed string) bool {
+			return strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin)
+		})
+	}
+}
+
+func (h *Hub) PublishTick(update models.PriceUpdate) error {
+	return h.broadcast(TypeTick, update.Exchange, update.Pair, update)
+}
+
+func (h *Hub) PublishAggregate(agg models.Aggregate) error {
+	return h.broadcast(TypeAggregate, agg.Exchange, agg.Pair, agg)
+}
+
+func (h *Hub) broadcast(kind, exchange, pair string, data any) error {
+	h.mu.RLock()
+	if len(h.clients) == 0 {
+		h.mu.RUnlock()
+		return nil
+	}
+
+	payload, err := json.Marshal(Message{Type: kind, Data: data})
+	if err != nil {
+		h.mu.RUnlock()
+		return err
+	}
+
+	var slow []*client
+	for c := range h.clients {
+		if !c.wants(kind, exchange, pair) {
+			continue
+		}
+		select {
+		case c.send <- payload:
+		default:
+			slow = append(slow, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range slow {
+		h.logger.Warn("Disconnecting slow websocket consumer", "remote", c.remote)
+		h.remove(c, websocket.ClosePolicyViolation, "slow consumer")
+	}
+	return nil
+}
+
+// ServeHTTP апгрейдит соединение. Начальную подписку можно передать в query:
+// /ws?pairs=BTCUSDT,ETHUSDT&exchanges=exchange1&types=tick
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.logger.Warn("Websocket upgrade failed", "error", err)
+		return
+	}
+
+	c := &client{
+		conn:   conn,
+		remote: r.RemoteAddr,
+		send:   make(chan []byte, h.bufferSize),
+		done:   make(chan struct{}),
+	}
+	query := r.URL.Query()
+	c.subscribe(request{
+		Pairs:     splitParam(query.Get("pairs")),
+		Exchanges: splitParam(query.Get("exchanges")),
+		Types:     splitParam(query.Get("types")),
+	})
+
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+	h.logger.Info("Websocket client connected", "remote", c.remote)
+
+	go h.writePump(c)
+	h.readPump(c)
+}
+
+// remove убирает клиента из рассылки и будит его горутину записи, которая
+// отправит close-фрейм с кодом и причиной и закроет соединение.
+func (h *Hub) remove(c *client, code int, reason string) {
+	h.mu.Lock()
+	_, ok := h.clients[c]
+	delete(h.clients, c)
+	if ok {
+		c.closeCode, c.closeReason = code, reason
+		close(c.done)
+	}
+	h.mu.Unlock()
+	if ok {
+		h.logger.Info("Websocket client disconnected", "remote", c.remote, "reason", reason)
+	}
+}
+
+func (h *Hub) readPump(c *client) {
+	defer h.remove(c, websocket.CloseNormalClosure, "closed")
+
+	c.conn.SetReadLimit(maxMessage)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var req request
+		if err := c.conn.ReadJSON(&req); err != nil {
+			return
+		}
+		switch req.Action {
+		case "subscribe":
+			c.subscribe(req)
+		case "unsubscribe":
+			c.unsubscribe(req)
+		default:
+			h.logger.Warn("Unknown websocket action", "remote", c.remote, "action", req.Action)
+		}
+	}
+}
+
+func (h *Hub) writePump(c *client) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	defer c.conn.Close()
+
+	for {
+		select {
+		case <-c.done:
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.closeCode, c.closeReason),
+				time.Now().Add(writeWait))
+			return
+		case payload := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				h.remove(c, websocket.CloseGoingAway, "write failed")
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				h.remove(c, websocket.CloseGoingAway, "ping failed")
+				return
+			}
+		}
+	}
+}
+
+type client struct {
+	conn   *websocket.Conn
+	remote string
+	send   chan []byte
+	done   chan struct{} // закрывается в remove; код и причина — для close-фрейма
+
+	closeCode   int
+	closeReason string
+
+	mu        sync.RWMutex
+	pairs     map[string]bool // nil — все пары, пустое множество — ни одной
+	exchanges map[string]bool // nil — все биржи
+	types     map[string]bool // nil — все типы
+}
+
+func (c *client) wants(kind, exchange, pair string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return matches(c.types, kind) && matches(c.exchanges, exchange) && matches(c.pairs, pair)
+}
+
+func (c *client) subscribe(req request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pairs := make([]string, len(req.Pairs))
+	for i, p := range req.Pairs {
+		pairs[i] = pairSymbol(p)
+	}
+	c.pairs = addAll(c.pairs, pairs)
+	c.exchanges = addAll(c.exchanges, req.Exchanges)
+	c.types = addAll(c.types, req.Types)
+}
+
+func (c *client) unsubscribe(req request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range req.Pairs {
+		delete(c.pairs, pairSymbol(p))
+	}
+	for _, e := range req.Exchanges {
+		delete(c.exchanges, e)
+	}
+	for _, t := range req.Types {
+		delete(c.types, t)
+	}
+}
+
+// pairSymbol приводит пару из подписки ("BTC/USDT", "btc-usdt") к символу тиков.
+func pairSymbol(raw string) string {
+	if pair, ok := models.ParsePair(raw); ok {
+		return pair.Symbol()
+	}
+	return strings.ToUpper(strings.TrimSpace(raw))
+}
+
+// matches: nil — подписка на всё; после отписки от последнего значения множество
+// пустое, но не nil, и клиент больше ничего из него не получает.
+func matches(set map[string]bool, value string) bool {
+	return set == nil || set[value]
+}
+
+func addAll(set map[string]bool, values []string) map[string]bool {
+	if len(values) == 0 {
+		return set
+	}
+	if set == nil {
+		set = make(map[string]bool)
+	}
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func splitParam(raw string) []string {
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,150 @@
+package ws
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientUnsubscribeLastPair(t *testing.T) {
+	c := &client{}
+	if !c.wants("tick", "exchange1", "BTCUSDT") {
+		t.Fatal("client without subscriptions should receive all pairs")
+	}
+
+	c.subscribe(request{Pairs: []string{"btcusdt"}})
+	if !c.wants("tick", "exchange1", "BTCUSDT") || c.wants("tick", "exchange1", "ETHUSDT") {
+		t.Fatal("client should receive only BTCUSDT")
+	}
+
+	c.unsubscribe(request{Pairs: []string{"BTCUSDT"}})
+	if c.wants("tick", "exchange1", "BTCUSDT") || c.wants("tick", "exchange1", "ETHUSDT") {
+		t.Error("client unsubscribed from its last pair should receive nothing")
+	}
+	if !matches(c.exchanges, "exchange1") {
+		t.Error("subscribing to pairs only must keep all exchanges")
+	}
+}
+
+func TestClientSubscribeNormalizesPairs(t *testing.T) {
+	c := &client{}
+	c.subscribe(request{Pairs: []string{"BTC/USDT", "eth-usdt"}})
+	if !c.wants("tick", "exchange1", "BTCUSDT") || !c.wants("tick", "exchange1", "ETHUSDT") {
+		t.Fatal("BTC/USDT and eth-usdt should match canonical symbols")
+	}
+	c.unsubscribe(request{Pairs: []string{"btc_usdt"}})
+	if c.wants("tick", "exchange1", "BTCUSDT") {
+		t.Error("btc_usdt should unsubscribe BTCUSDT")
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	request := func(origin string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "http://api.local/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	if checkOrigin(nil) != nil {
+		t.Error("no origins should fall back to the same-host check")
+	}
+	if !checkOrigin([]string{"*"})(request("https://evil.example")) {
+		t.Error("* should allow any origin")
+	}
+
+	allow := checkOrigin([]string{"https://app.example.com/"})
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"http://app.example.com", false},
+		{"https://evil.example", false},
+		{"", true}, // не браузер
+	}
+	for _, tt := range tests {
+		if got := allow(request(tt.origin)); got != tt.want {
+			t.Errorf("origin %q allowed = %t, want %t", tt.origin, got, tt.want)
+		}
+	}
+}
+
+// dial подключает клиента к хабу и ждёт, пока тот его зарегистрирует.
+func dial(t *testing.T, h *Hub) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	for deadline := time.Now().Add(time.Second); h.clientCount() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("client not registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return conn
+}
+
+func (h *Hub) clientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+func TestSlowConsumerIsDisconnected(t *testing.T) {
+	h := NewHub(1, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	conn := dial(t, h)
+
+	// Клиент не читает: буфер на одно сообщение переполняется
+	for range 10 {
+		h.broadcast(TypeTick, "exchange1", "BTCUSDT", "x")
+	}
+	if n := h.clientCount(); n != 0 {
+		t.Fatalf("%d clients after overflow, want 0", n)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+			t.Fatalf("read error = %v, want policy violation close", err)
+		}
+		break
+	}
+}
+
+func TestSlowConsumerDoesNotBlockBroadcast(t *testing.T) {
+	h := NewHub(1, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	dial(t, h)
+
+	// Большие сообщения забивают TCP-буферы: запись клиенту висит до writeWait,
+	// а рассылка и отключение не должны её ждать
+	payload := strings.Repeat("x", 16<<20)
+	start := time.Now()
+	for range 20 {
+		h.broadcast(TypeTick, "exchange1", "BTCUSDT", payload)
+	}
+	if elapsed := time.Since(start); elapsed > writeWait/2 {
+		t.Errorf("broadcast took %s with a stuck client", elapsed)
+	}
+	if n := h.clientCount(); n != 0 {
+		t.Errorf("%d clients after overflow, want 0", n)
+	}
+}
@@ -1,12 +1,18 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+	"log/slog"
+	redisAdapter "marketflow/internal/adapters/output/redis"
 	"marketflow/internal/domain/models"
+	applog "marketflow/pkg/logger"
 	"marketflow/pkg/utils"
+	"net"
 	"os"
-	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,88 +21,401 @@ type Config struct {
 	Redis            RedisConfig
 	Exchanges        []models.ExchangeConfig
 	PortAPI          int
+	AdminToken       Secret // ADMIN_TOKEN; пусто — /alerts/rules и /admin/* не подключаются
 	AggregatorWindow time.Duration
 	RedisTTL         time.Duration
 	AppEnv           string
+	Spread           SpreadConfig
+	Validation       ValidationConfig
+	TrackedPairs     []string
+	SymbolAliases    map[string]map[string]string
+	WSClientBuffer   int
+	WSOrigins        []string // WS_ALLOWED_ORIGINS; пусто — только тот же хост
+	Publishers       PublishersConfig
+	Alerts           AlertsConfig
+	Recording        RecordingConfig
+	Log              LogConfig
+	Tracing          TracingConfig
+	Staleness        StalenessConfig
+	Chaos            ChaosConfig
+	Backpressure     models.BackpressureConfig // BACKPRESSURE_POLICY, _BUFFER и BACKPRESSURE_<EXCHANGE>_*
 }
 
 type PostgresConfig struct {
 	Host     string
 	Port     int
 	User     string
-	Password string
+	Password Secret
 	NameDB   string
 	SSLMode  string
 }
 
+// RedisConfig: REDIS_MODE=standalone использует REDIS_HOST:REDIS_PORT,
+// sentinel и cluster — список REDIS_ADDRS (адреса sentinel'ов или узлов кластера).
 type RedisConfig struct {
 	Host     string
 	Port     string
 	Name     string
-	Password string
+	Username string
+	Password Secret
 	DB       int
+
+	Mode             string // redisAdapter.ModeStandalone, ModeSentinel или ModeCluster
+	Addrs            []string
+	MasterName       string // имя мастера в Sentinel
+	SentinelUsername string
+	SentinelPassword Secret
+
+	TLS                   bool
+	TLSCAFile             string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolSize     int
+	MinIdleConns int
+}
+
+type SpreadConfig struct {
+	ThresholdBps float64
+	MinDuration  time.Duration
+	MaxQuoteAge  time.Duration
+}
+
+// StalenessConfig: пара считается устаревшей, если тиков с биржи нет дольше
+// STALENESS_THRESHOLD; проверка раз в STALENESS_CHECK_INTERVAL.
+type StalenessConfig struct {
+	Threshold     time.Duration
+	CheckInterval time.Duration
+}
+
+// ChaosConfig: CHAOS_ENABLED оборачивает биржи, Redis и запись агрегатов в декораторы
+// со сбоями (CHAOS_EXCHANGE_*, CHAOS_REDIS_*, CHAOS_POSTGRES_*). Разрешено только при
+// APP_ENV из chaosEnvironments. Биржа получает Seed, Redis — Seed+1, Postgres — Seed+2.
+type ChaosConfig struct {
+	Enabled                 bool
+	Seed                    int64
+	Exchange                ChaosFaults
+	ExchangeDisconnectEvery time.Duration
+	Redis                   ChaosFaults
+	Postgres                ChaosFaults
+}
+
+// ChaosFaults — CHAOS_<PORT>_LATENCY, _ERROR_RATE, _PARTIAL_RATE, _OUTAGE_EVERY, _OUTAGE_FOR.
+type ChaosFaults struct {
+	Latency     time.Duration
+	ErrorRate   float64
+	PartialRate float64
+	OutageEvery time.Duration
+	OutageFor   time.Duration
+}
+
+type ValidationRules struct {
+	RequirePositive bool
+	MaxDeviation    float64
+	MedianWindow    int
+	MaxJumpPerSec   float64
+	MaxTickAge      time.Duration
+}
+
+// ValidationConfig: Default берётся из VALIDATION_<RULE>,
+// переопределения для пары — из VALIDATION_<PAIR>_<RULE>.
+type ValidationConfig struct {
+	Default ValidationRules
+	Pairs   map[string]ValidationRules
+}
+
+var validationRuleKeys = []string{
+	"REQUIRE_POSITIVE",
+	"MAX_DEVIATION",
+	"MEDIAN_WINDOW",
+	"MAX_JUMP_PER_SEC",
+	"MAX_TICK_AGE",
+}
+
+// PublisherConfig — настройки приёмника шины публикации:
+// PUBLISH_<NAME>, PUBLISH_<NAME>_PAIRS, _EXCHANGES, _EVENTS (tick,aggregate), _BUFFER, _TARGET.
+type PublisherConfig struct {
+	Enabled    bool
+	Pairs      []string
+	Exchanges  []string
+	Ticks      bool
+	Aggregates bool
+	Buffer     int
+	Target     string // путь к файлу или URL вебхука
+}
+
+type PublishersConfig struct {
+	Console PublisherConfig
+	File    PublisherConfig
+	Webhook PublisherConfig
+	Redis   PublisherConfig
+}
+
+type NamedPublisher struct {
+	Name   string // суффикс переменных PUBLISH_<NAME>
+	Config PublisherConfig
+}
+
+// List возвращает приёмники в фиксированном порядке — для проверок и вывода.
+func (p PublishersConfig) List() []NamedPublisher {
+	return []NamedPublisher{
+		{"CONSOLE", p.Console},
+		{"FILE", p.File},
+		{"WEBHOOK", p.Webhook},
+		{"REDIS", p.Redis},
+	}
+}
+
+type AlertsConfig struct {
+	WebhookAttempts int
+	WebhookBackoff  time.Duration
+	WebhookTimeout  time.Duration
+	WebhookHosts    []string // ALERT_WEBHOOK_HOSTS; пусто — любые публичные адреса
+}
+
+// RecordingConfig: EXCHANGE_MODE=live пишет сессии в RECORD_DIR (если задан),
+// EXCHANGE_MODE=replay воспроизводит их со скоростью REPLAY_SPEED (0 — без пауз).
+type RecordingConfig struct {
+	Mode        string
+	RecordDir   string
+	ReplaySpeed float64
+}
+
+// LogConfig: LOG_LEVEL, LOG_FORMAT (text|json); повторяющиеся предупреждения
+// семплируются — не больше LOG_SAMPLE_BURST одинаковых за LOG_SAMPLE_INTERVAL (0 — выключено).
+type LogConfig struct {
+	Level          slog.Level
+	Format         string
+	SampleBurst    int
+	SampleInterval time.Duration
 }
 
-func NewConfig() (*Config, error) {
-	if err := utils.LoadEnv(filepath.Join(".env")); err != nil {
-		log.Fatalf("Ошибка загрузки .env: %v", err)
-	}
-
-	env := map[string]string{
-		"PG_HOST":           os.Getenv("PG_HOST"),
-		"PG_PORT":           os.Getenv("PG_PORT"),
-		"PG_USER":           os.Getenv("PG_USER"),
-		"PG_PASSWORD":       os.Getenv("PG_PASSWORD"),
-		"PG_NAME":           os.Getenv("PG_NAME"),
-		"PG_SSLMODE":        os.Getenv("PG_SSLMODE"),
-		"REDIS_HOST":        os.Getenv("REDIS_HOST"),
-		"REDIS_PORT":        os.Getenv("REDIS_PORT"),
-		"REDIS_PASSWORD":    os.Getenv("REDIS_PASSWORD"),
-		"REDIS_DB":          os.Getenv("REDIS_DB"),
-		"EXCHANGE1_NAME":    os.Getenv("EXCHANGE1_NAME"),
-		"EXCHANGE2_NAME":    os.Getenv("EXCHANGE2_NAME"),
-		"EXCHANGE3_NAME":    os.Getenv("EXCHANGE3_NAME"),
-		"EXCHANGE1_PORT":    os.Getenv("EXCHANGE1_PORT"),
-		"EXCHANGE2_PORT":    os.Getenv("EXCHANGE2_PORT"),
-		"EXCHANGE3_PORT":    os.Getenv("EXCHANGE3_PORT"),
-		"REDIS_TLS":         os.Getenv("REDIS_TLS"),
-		"AGGREGATOR_WINDOW": os.Getenv("AGGREGATOR_WINDOW"),
-	}
-	log.Println(os.Getenv("EXCHANGE1_NAME"))
-	for key, value := range env {
-		if value == "" {
+// TracingConfig: TRACING_ENABLED включает экспорт спанов по OTLP/HTTP на TRACING_ENDPOINT;
+// в выборку попадает доля TRACING_SAMPLE_RATIO корневых спанов (тиков и окон агрегации).
+type TracingConfig struct {
+	Enabled     bool
+	Endpoint    string
+	Insecure    bool
+	SampleRatio float64
+	ServiceName string
+}
+
+const (
+	ExchangeModeLive   = "live"
+	ExchangeModeReplay = "replay"
+)
+
+// defaults — нижний слой конфигурации. Порядок: defaults < env-файл < окружение < флаги.
+// Подключения к Postgres и Redis (хост, пользователь, пароль, БД) обязательны.
+var defaults = map[string]string{
+	"PG_PORT":           "5432",
+	"PG_SSLMODE":        "disable",
+	"REDIS_PORT":        "6379",
+	"REDIS_DB":          "0",
+	"REDIS_TLS":         "false",
+	"REDIS_TTL":         "1m",
+	"API_PORT":          "8080",
+	"AGGREGATOR_WINDOW": "1m",
+	"EXCHANGE1_NAME":    "exchange1",
+	"EXCHANGE1_PORT":    "40101",
+	"EXCHANGE2_NAME":    "exchange2",
+	"EXCHANGE2_PORT":    "40102",
+	"EXCHANGE3_NAME":    "exchange3",
+	"EXCHANGE3_PORT":    "40103",
+}
+
+// getenv — значение из окружения, а если его нет — из defaults. Окружение
+// процесса не меняется: значения по умолчанию живут только в Config.
+func getenv(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaults[key]
+}
+
+func envInt(key string) (int, error) {
+	value, err := strconv.Atoi(getenv(key))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s :%w", key, err)
+	}
+	return value, nil
+}
+
+func envDuration(key string) (time.Duration, error) {
+	value, err := time.ParseDuration(getenv(key))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s :%w", key, err)
+	}
+	return value, nil
+}
+
+// NewConfig читает переменные окружения, предварительно подгрузив envFile.
+// Файл необязателен: .env по умолчанию пропускается, если его нет (в контейнере
+// окружение обычно задаётся напрямую); явно указанный файл должен существовать.
+// Значения из окружения важнее значений из файла. Проверка по смыслу (Validate)
+// выполняется в Apply — уже после флагов, которые могут исправить значение.
+func NewConfig(envFile string) (*Config, error) {
+	explicit := envFile != ""
+	if !explicit {
+		envFile = ".env"
+	}
+	if err := loadEnvFile(envFile, explicit); err != nil {
+		return nil, err
+	}
+	// Без значений по умолчанию: адрес и учётные данные БД
+	for _, key := range []string{"PG_HOST", "PG_USER", "PG_PASSWORD", "PG_NAME"} {
+		if os.Getenv(key) == "" {
 			return nil, fmt.Errorf("missing required env variable: %s", key)
 		}
 	}
-	pgPort, err := utils.ParseEnvInt("PG_PORT")
+	pgPort, err := envInt("PG_PORT")
 	if err != nil {
 		return nil, err
 	}
 
-	redisDB, err := utils.ParseEnvInt("REDIS_DB")
+	redis, err := loadRedisConfig()
 	if err != nil {
 		return nil, err
 	}
 
 	for i := 1; i <= 3; i++ {
 		key := fmt.Sprintf("EXCHANGE%d_PORT", i)
-		_, err := utils.ParseEnvInt(key)
+		_, err := envInt(key)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse %s: %w", key, err)
 		}
 	}
 
-	portAPI, err := utils.ParseEnvInt("API_PORT")
+	portAPI, err := envInt("API_PORT")
+	if err != nil {
+		return nil, err
+	}
+
+	aggregatorWindow, err := envDuration("AGGREGATOR_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+
+	redisTTL, err := envDuration("REDIS_TTL")
+	if err != nil {
+		return nil, err
+	}
+
+	spreadThreshold, err := utils.ParseEnvFloatDefault("SPREAD_THRESHOLD_BPS", 50)
+	if err != nil {
+		return nil, err
+	}
+
+	spreadMinDuration, err := utils.ValidTimeDefault("SPREAD_MIN_DURATION", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	spreadMaxQuoteAge, err := utils.ValidTimeDefault("SPREAD_MAX_QUOTE_AGE", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	var staleness StalenessConfig
+	if staleness.Threshold, err = utils.ValidTimeDefault("STALENESS_THRESHOLD", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if staleness.CheckInterval, err = utils.ValidTimeDefault("STALENESS_CHECK_INTERVAL", 5*time.Second); err != nil {
+		return nil, err
+	}
+
+	validation, err := loadValidationConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	trackedPairs := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "DOGEUSDT", "TONUSDT"}
+	if raw := os.Getenv("TRACKED_PAIRS"); raw != "" {
+		trackedPairs = splitList(raw)
+	}
+
+	symbolAliases, err := parseSymbolAliases(os.Getenv("SYMBOL_ALIASES"))
+	if err != nil {
+		return nil, err
+	}
+
+	wsClientBuffer := 256
+	if os.Getenv("WS_CLIENT_BUFFER") != "" {
+		if wsClientBuffer, err = utils.ParseEnvInt("WS_CLIENT_BUFFER"); err != nil {
+			return nil, err
+		}
+	}
+
+	var wsOrigins []string
+	for _, origin := range splitList(os.Getenv("WS_ALLOWED_ORIGINS")) {
+		wsOrigins = append(wsOrigins, strings.TrimSuffix(origin, "/"))
+	}
+
+	var publishers PublishersConfig
+	if publishers.Console, err = loadPublisherConfig("CONSOLE", true); err != nil {
+		return nil, err
+	}
+	if publishers.File, err = loadPublisherConfig("FILE", false); err != nil {
+		return nil, err
+	}
+	if publishers.Webhook, err = loadPublisherConfig("WEBHOOK", false); err != nil {
+		return nil, err
+	}
+	if publishers.Redis, err = loadPublisherConfig("REDIS", false); err != nil {
+		return nil, err
+	}
+
+	alerts := AlertsConfig{WebhookAttempts: 3}
+	if os.Getenv("ALERT_WEBHOOK_ATTEMPTS") != "" {
+		if alerts.WebhookAttempts, err = utils.ParseEnvInt("ALERT_WEBHOOK_ATTEMPTS"); err != nil {
+			return nil, err
+		}
+	}
+	if alerts.WebhookBackoff, err = utils.ValidTimeDefault("ALERT_WEBHOOK_BACKOFF", time.Second); err != nil {
+		return nil, err
+	}
+	if alerts.WebhookTimeout, err = utils.ValidTimeDefault("ALERT_WEBHOOK_TIMEOUT", 5*time.Second); err != nil {
+		return nil, err
+	}
+	for _, host := range splitList(os.Getenv("ALERT_WEBHOOK_HOSTS")) {
+		alerts.WebhookHosts = append(alerts.WebhookHosts, strings.ToLower(host))
+	}
+
+	rec := RecordingConfig{
+		Mode:      strings.ToLower(os.Getenv("EXCHANGE_MODE")),
+		RecordDir: os.Getenv("RECORD_DIR"),
+	}
+	if rec.Mode == "" {
+		rec.Mode = ExchangeModeLive
+	}
+	if rec.Mode != ExchangeModeLive && rec.Mode != ExchangeModeReplay {
+		return nil, fmt.Errorf("invalid EXCHANGE_MODE %q: expected live or replay", rec.Mode)
+	}
+	if rec.ReplaySpeed, err = utils.ParseEnvFloatDefault("REPLAY_SPEED", 1); err != nil {
+		return nil, err
+	}
+
+	logCfg, err := loadLogConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	aggregatorWindow, err := utils.ValidTime("AGGREGATOR_WINDOW")
+	tracing, err := loadTracingConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	redisTTL, err := utils.ValidTime("REDIS_TTL")
+	chaos, err := loadChaosConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	backpressure, err := loadBackpressureConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -106,39 +425,514 @@ func NewConfig() (*Config, error) {
 			Host:     os.Getenv("PG_HOST"),
 			Port:     pgPort,
 			User:     os.Getenv("PG_USER"),
-			Password: os.Getenv("PG_PASSWORD"),
+			Password: Secret(os.Getenv("PG_PASSWORD")),
 			NameDB:   os.Getenv("PG_NAME"),
-			SSLMode:  os.Getenv("PG_SSLMODE"),
-		},
-		Redis: RedisConfig{
-			Host:     os.Getenv("REDIS_HOST"),
-			Port:     os.Getenv("REDIS_PORT"),
-			Name:     os.Getenv("REDIS_DB"),
-			Password: os.Getenv("PG_PASSWORD"),
-			DB:       redisDB,
+			SSLMode:  getenv("PG_SSLMODE"),
 		},
+		Redis: redis,
 		Exchanges: []models.ExchangeConfig{
 			{
-				Name: os.Getenv("EXCHANGE1_NAME"),
-				Host: os.Getenv("EXCHANGE1_NAME"),
-				Port: os.Getenv("EXCHANGE1_PORT"),
+				Name: getenv("EXCHANGE1_NAME"),
+				Host: getenv("EXCHANGE1_NAME"),
+				Port: getenv("EXCHANGE1_PORT"),
 			},
 			{
-				Name: os.Getenv("EXCHANGE2_NAME"),
-				Host: os.Getenv("EXCHANGE2_NAME"),
-				Port: os.Getenv("EXCHANGE2_PORT"),
+				Name: getenv("EXCHANGE2_NAME"),
+				Host: getenv("EXCHANGE2_NAME"),
+				Port: getenv("EXCHANGE2_PORT"),
 			},
 			{
-				Name: os.Getenv("EXCHANGE3_NAME"),
-				Host: os.Getenv("EXCHANGE3_NAME"),
-				Port: os.Getenv("EXCHANGE3_PORT"),
+				Name: getenv("EXCHANGE3_NAME"),
+				Host: getenv("EXCHANGE3_NAME"),
+				Port: getenv("EXCHANGE3_PORT"),
 			},
 		},
 		PortAPI:          portAPI,
+		AdminToken:       Secret(os.Getenv("ADMIN_TOKEN")),
 		AggregatorWindow: aggregatorWindow,
 		RedisTTL:         redisTTL,
 		AppEnv:           os.Getenv("APP_ENV"),
+		Spread: SpreadConfig{
+			ThresholdBps: spreadThreshold,
+			MinDuration:  spreadMinDuration,
+			MaxQuoteAge:  spreadMaxQuoteAge,
+		},
+		Validation:     validation,
+		TrackedPairs:   trackedPairs,
+		SymbolAliases:  symbolAliases,
+		WSClientBuffer: wsClientBuffer,
+		WSOrigins:      wsOrigins,
+		Publishers:     publishers,
+		Alerts:         alerts,
+		Recording:      rec,
+		Log:            logCfg,
+		Tracing:        tracing,
+		Staleness:      staleness,
+		Chaos:          chaos,
+		Backpressure:   backpressure,
 	}
 
 	return cfg, nil
 }
+
+// loadEnvFile загружает env-файл под окружение процесса и разворачивает KEY_FILE.
+// Отсутствующий файл по умолчанию (.env) — не ошибка.
+func loadEnvFile(path string, explicit bool) error {
+	environ := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		environ[key] = true
+	}
+	if err := utils.LoadEnv(path); err != nil && (explicit || !errors.Is(err, fs.ErrNotExist)) {
+		return fmt.Errorf("load %s: %w", path, err)
+	}
+	return resolveFileEnv(environ)
+}
+
+func loadLogConfig() (LogConfig, error) {
+	cfg := LogConfig{SampleBurst: 10}
+	var err error
+	if cfg.Level, err = applog.ParseLevel(os.Getenv("LOG_LEVEL")); err != nil {
+		return cfg, err
+	}
+	if cfg.Format, err = applog.ParseFormat(os.Getenv("LOG_FORMAT")); err != nil {
+		return cfg, err
+	}
+	if os.Getenv("LOG_SAMPLE_BURST") != "" {
+		if cfg.SampleBurst, err = utils.ParseEnvInt("LOG_SAMPLE_BURST"); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.SampleInterval, err = utils.ValidTimeDefault("LOG_SAMPLE_INTERVAL", time.Second); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// loadBackpressureConfig: очередь тиков каждой биржи — BACKPRESSURE_POLICY
+// (block|drop-newest|drop-oldest|coalesce) на BACKPRESSURE_BUFFER тиков;
+// для биржи — BACKPRESSURE_<EXCHANGE>_POLICY и _BUFFER (имя биржи в верхнем регистре).
+func loadBackpressureConfig() (models.BackpressureConfig, error) {
+	def, err := loadQueueConfig("BACKPRESSURE_", models.QueueConfig{Policy: models.PolicyDropNewest, Buffer: 1000})
+	if err != nil {
+		return models.BackpressureConfig{}, err
+	}
+	cfg := models.BackpressureConfig{Default: def, Exchanges: make(map[string]models.QueueConfig)}
+
+	// Ищем переменные вида BACKPRESSURE_<EXCHANGE>_POLICY и _BUFFER
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		rest, ok := strings.CutPrefix(key, "BACKPRESSURE_")
+		if !ok {
+			continue
+		}
+		exchange, ok := strings.CutSuffix(rest, "_POLICY")
+		if !ok {
+			exchange, ok = strings.CutSuffix(rest, "_BUFFER")
+		}
+		if !ok || exchange == "" {
+			continue
+		}
+		if _, done := cfg.Exchanges[exchange]; done {
+			continue
+		}
+		if cfg.Exchanges[exchange], err = loadQueueConfig("BACKPRESSURE_"+exchange+"_", def); err != nil {
+			return models.BackpressureConfig{}, err
+		}
+	}
+	return cfg, nil
+}
+
+func loadQueueConfig(prefix string, def models.QueueConfig) (models.QueueConfig, error) {
+	q := def
+	if raw := os.Getenv(prefix + "POLICY"); raw != "" {
+		q.Policy = strings.ToLower(raw)
+	}
+	if os.Getenv(prefix+"BUFFER") != "" {
+		var err error
+		if q.Buffer, err = utils.ParseEnvInt(prefix + "BUFFER"); err != nil {
+			return q, err
+		}
+	}
+	return q, nil
+}
+
+func loadChaosConfig() (ChaosConfig, error) {
+	var cfg ChaosConfig
+	var err error
+	if raw := os.Getenv("CHAOS_ENABLED"); raw != "" {
+		if cfg.Enabled, err = strconv.ParseBool(raw); err != nil {
+			return cfg, fmt.Errorf("invalid CHAOS_ENABLED :%w", err)
+		}
+	}
+	if raw := os.Getenv("CHAOS_SEED"); raw != "" {
+		if cfg.Seed, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return cfg, fmt.Errorf("invalid CHAOS_SEED :%w", err)
+		}
+	}
+	if cfg.Exchange, err = loadChaosFaults("CHAOS_EXCHANGE_"); err != nil {
+		return cfg, err
+	}
+	if cfg.ExchangeDisconnectEvery, err = utils.ValidTimeDefault("CHAOS_EXCHANGE_DISCONNECT_EVERY", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.Redis, err = loadChaosFaults("CHAOS_REDIS_"); err != nil {
+		return cfg, err
+	}
+	if cfg.Postgres, err = loadChaosFaults("CHAOS_POSTGRES_"); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func loadChaosFaults(prefix string) (ChaosFaults, error) {
+	var f ChaosFaults
+	var err error
+	if f.Latency, err = utils.ValidTimeDefault(prefix+"LATENCY", 0); err != nil {
+		return f, err
+	}
+	if f.ErrorRate, err = utils.ParseEnvFloatDefault(prefix+"ERROR_RATE", 0); err != nil {
+		return f, err
+	}
+	if f.PartialRate, err = utils.ParseEnvFloatDefault(prefix+"PARTIAL_RATE", 0); err != nil {
+		return f, err
+	}
+	if f.OutageEvery, err = utils.ValidTimeDefault(prefix+"OUTAGE_EVERY", 0); err != nil {
+		return f, err
+	}
+	if f.OutageFor, err = utils.ValidTimeDefault(prefix+"OUTAGE_FOR", 0); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+func loadTracingConfig() (TracingConfig, error) {
+	cfg := TracingConfig{
+		Endpoint:    os.Getenv("TRACING_ENDPOINT"),
+		Insecure:    true,
+		ServiceName: os.Getenv("TRACING_SERVICE_NAME"),
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "localhost:4318"
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "marketflow"
+	}
+
+	var err error
+	if raw := os.Getenv("TRACING_ENABLED"); raw != "" {
+		if cfg.Enabled, err = strconv.ParseBool(raw); err != nil {
+			return cfg, fmt.Errorf("invalid TRACING_ENABLED :%w", err)
+		}
+	}
+	if raw := os.Getenv("TRACING_INSECURE"); raw != "" {
+		if cfg.Insecure, err = strconv.ParseBool(raw); err != nil {
+			return cfg, fmt.Errorf("invalid TRACING_INSECURE :%w", err)
+		}
+	}
+	if cfg.SampleRatio, err = utils.ParseEnvFloatDefault("TRACING_SAMPLE_RATIO", 0.01); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func loadValidationConfig() (ValidationConfig, error) {
+	def, err := loadValidationRules("VALIDATION_", ValidationRules{
+		RequirePositive: true,
+		MaxDeviation:    0.1,
+		MedianWindow:    20,
+		MaxJumpPerSec:   0.05,
+		MaxTickAge:      10 * time.Second,
+	})
+	if err != nil {
+		return ValidationConfig{}, err
+	}
+
+	cfg := ValidationConfig{Default: def, Pairs: make(map[string]ValidationRules)}
+
+	// Ищем переменные вида VALIDATION_<PAIR>_<RULE>
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		rest, ok := strings.CutPrefix(key, "VALIDATION_")
+		if !ok {
+			continue
+		}
+		for _, rule := range validationRuleKeys {
+			pair, ok := strings.CutSuffix(rest, "_"+rule)
+			if !ok || pair == "" {
+				continue
+			}
+			if _, done := cfg.Pairs[pair]; done {
+				break
+			}
+			rules, err := loadValidationRules("VALIDATION_"+pair+"_", def)
+			if err != nil {
+				return ValidationConfig{}, err
+			}
+			cfg.Pairs[pair] = rules
+			break
+		}
+	}
+
+	return cfg, nil
+}
+
+func loadValidationRules(prefix string, def ValidationRules) (ValidationRules, error) {
+	rules := def
+	var err error
+
+	if raw := os.Getenv(prefix + "REQUIRE_POSITIVE"); raw != "" {
+		if rules.RequirePositive, err = strconv.ParseBool(raw); err != nil {
+			return rules, fmt.Errorf("invalid %sREQUIRE_POSITIVE :%w", prefix, err)
+		}
+	}
+	if rules.MaxDeviation, err = utils.ParseEnvFloatDefault(prefix+"MAX_DEVIATION", def.MaxDeviation); err != nil {
+		return rules, err
+	}
+	if os.Getenv(prefix+"MEDIAN_WINDOW") != "" {
+		if rules.MedianWindow, err = utils.ParseEnvInt(prefix + "MEDIAN_WINDOW"); err != nil {
+			return rules, err
+		}
+	}
+	if rules.MaxJumpPerSec, err = utils.ParseEnvFloatDefault(prefix+"MAX_JUMP_PER_SEC", def.MaxJumpPerSec); err != nil {
+		return rules, err
+	}
+	if rules.MaxTickAge, err = utils.ValidTimeDefault(prefix+"MAX_TICK_AGE", def.MaxTickAge); err != nil {
+		return rules, err
+	}
+
+	return rules, nil
+}
+
+// parseSymbolAliases разбирает список вида "exchange1:XBTUSDT=BTCUSDT,*:XBT-USDT=BTCUSDT".
+func parseSymbolAliases(raw string) (map[string]map[string]string, error) {
+	aliases := make(map[string]map[string]string)
+	for _, item := range splitList(raw) {
+		from, to, ok := strings.Cut(item, "=")
+		exchange, symbol, okEx := strings.Cut(from, ":")
+		if !ok || !okEx || exchange == "" || symbol == "" || to == "" {
+			return nil, fmt.Errorf("invalid SYMBOL_ALIASES entry %q, want exchange:symbol=PAIR", item)
+		}
+		if aliases[exchange] == nil {
+			aliases[exchange] = make(map[string]string)
+		}
+		aliases[exchange][symbol] = to
+	}
+	return aliases, nil
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func loadPublisherConfig(name string, enabled bool) (PublisherConfig, error) {
+	prefix := "PUBLISH_" + name
+	cfg := PublisherConfig{
+		Enabled:    enabled,
+		Pairs:      splitList(os.Getenv(prefix + "_PAIRS")),
+		Exchanges:  splitList(os.Getenv(prefix + "_EXCHANGES")),
+		Ticks:      true,
+		Aggregates: true,
+		Target:     os.Getenv(prefix + "_TARGET"),
+	}
+
+	if raw := os.Getenv(prefix); raw != "" {
+		var err error
+		if cfg.Enabled, err = strconv.ParseBool(raw); err != nil {
+			return cfg, fmt.Errorf("invalid %s :%w", prefix, err)
+		}
+	}
+
+	if raw := os.Getenv(prefix + "_EVENTS"); raw != "" {
+		cfg.Ticks, cfg.Aggregates = false, false
+		for _, event := range splitList(raw) {
+			switch event {
+			case "tick":
+				cfg.Ticks = true
+			case "aggregate":
+				cfg.Aggregates = true
+			default:
+				return cfg, fmt.Errorf("invalid %s_EVENTS entry %q, want tick or aggregate", prefix, event)
+			}
+		}
+	}
+
+	if os.Getenv(prefix+"_BUFFER") != "" {
+		var err error
+		if cfg.Buffer, err = utils.ParseEnvInt(prefix + "_BUFFER"); err != nil {
+			return cfg, err
+		}
+	}
+
+	if cfg.Enabled && (name == "FILE" || name == "WEBHOOK") && cfg.Target == "" {
+		return cfg, fmt.Errorf("missing required env variable: %s_TARGET", prefix)
+	}
+	return cfg, nil
+}
+
+// Overrides — значения из флагов командной строки, перекрывают окружение.
+// Нулевые значения ничего не меняют.
+type Overrides struct {
+	Port      int
+	Mode      string
+	Exchanges string // "exchange1,exchange2" — выбрать из настроенных; "name=host:port" — задать адрес
+	LogLevel  string
+	LogFormat string
+}
+
+// Apply накладывает флаги поверх загруженной конфигурации и повторяет проверку Validate.
+func (c *Config) Apply(o Overrides) error {
+	if o.Port != 0 {
+		c.PortAPI = o.Port
+	}
+
+	if o.Mode != "" {
+		mode := strings.ToLower(o.Mode)
+		if mode != ExchangeModeLive && mode != ExchangeModeReplay {
+			return fmt.Errorf("invalid mode %q: expected live or replay", o.Mode)
+		}
+		c.Recording.Mode = mode
+	}
+
+	if o.LogLevel != "" {
+		level, err := applog.ParseLevel(o.LogLevel)
+		if err != nil {
+			return err
+		}
+		c.Log.Level = level
+	}
+	if o.LogFormat != "" {
+		format, err := applog.ParseFormat(o.LogFormat)
+		if err != nil {
+			return err
+		}
+		c.Log.Format = format
+	}
+
+	if o.Exchanges != "" {
+		exchanges, err := selectExchanges(c.Exchanges, o.Exchanges)
+		if err != nil {
+			return err
+		}
+		c.Exchanges = exchanges
+	}
+	return c.Validate()
+}
+
+func selectExchanges(configured []models.ExchangeConfig, spec string) ([]models.ExchangeConfig, error) {
+	var selected []models.ExchangeConfig
+	for _, item := range splitList(spec) {
+		name, addr, hasAddr := strings.Cut(item, "=")
+		if hasAddr {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exchange address %q: %w", item, err)
+			}
+			selected = append(selected, models.ExchangeConfig{Name: name, Host: host, Port: port})
+			continue
+		}
+
+		found := false
+		for _, ex := range configured {
+			if ex.Name == name {
+				selected = append(selected, ex)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown exchange %q", name)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no exchanges selected")
+	}
+	return selected, nil
+}
+
+func loadRedisConfig() (RedisConfig, error) {
+	cfg := RedisConfig{
+		Host:             os.Getenv("REDIS_HOST"),
+		Port:             getenv("REDIS_PORT"),
+		Name:             getenv("REDIS_DB"),
+		Username:         os.Getenv("REDIS_USERNAME"),
+		Password:         Secret(os.Getenv("REDIS_PASSWORD")),
+		Mode:             strings.ToLower(os.Getenv("REDIS_MODE")),
+		Addrs:            splitList(os.Getenv("REDIS_ADDRS")),
+		MasterName:       os.Getenv("REDIS_SENTINEL_MASTER"),
+		SentinelUsername: os.Getenv("REDIS_SENTINEL_USERNAME"),
+		SentinelPassword: Secret(os.Getenv("REDIS_SENTINEL_PASSWORD")),
+		TLSCAFile:        os.Getenv("REDIS_TLS_CA_FILE"),
+		TLSCertFile:      os.Getenv("REDIS_TLS_CERT_FILE"),
+		TLSKeyFile:       os.Getenv("REDIS_TLS_KEY_FILE"),
+		TLSServerName:    os.Getenv("REDIS_TLS_SERVER_NAME"),
+	}
+
+	var err error
+	if cfg.DB, err = envInt("REDIS_DB"); err != nil {
+		return cfg, err
+	}
+	if cfg.TLS, err = strconv.ParseBool(getenv("REDIS_TLS")); err != nil {
+		return cfg, fmt.Errorf("invalid REDIS_TLS :%w", err)
+	}
+	if raw := os.Getenv("REDIS_TLS_INSECURE_SKIP_VERIFY"); raw != "" {
+		if cfg.TLSInsecureSkipVerify, err = strconv.ParseBool(raw); err != nil {
+			return cfg, fmt.Errorf("invalid REDIS_TLS_INSECURE_SKIP_VERIFY :%w", err)
+		}
+	}
+
+	if cfg.DialTimeout, err = utils.ValidTimeDefault("REDIS_DIAL_TIMEOUT", 5*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.ReadTimeout, err = utils.ValidTimeDefault("REDIS_READ_TIMEOUT", 3*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.WriteTimeout, err = utils.ValidTimeDefault("REDIS_WRITE_TIMEOUT", 3*time.Second); err != nil {
+		return cfg, err
+	}
+	if os.Getenv("REDIS_POOL_SIZE") != "" {
+		if cfg.PoolSize, err = utils.ParseEnvInt("REDIS_POOL_SIZE"); err != nil {
+			return cfg, err
+		}
+	}
+	if os.Getenv("REDIS_MIN_IDLE_CONNS") != "" {
+		if cfg.MinIdleConns, err = utils.ParseEnvInt("REDIS_MIN_IDLE_CONNS"); err != nil {
+			return cfg, err
+		}
+	}
+
+	switch cfg.Mode {
+	case "", redisAdapter.ModeStandalone:
+		cfg.Mode = redisAdapter.ModeStandalone
+		if cfg.Host == "" || cfg.Port == "" {
+			return cfg, fmt.Errorf("missing required env variable: REDIS_HOST or REDIS_PORT")
+		}
+	case redisAdapter.ModeSentinel:
+		if cfg.MasterName == "" {
+			return cfg, fmt.Errorf("REDIS_MODE=sentinel requires REDIS_SENTINEL_MASTER")
+		}
+		if len(cfg.Addrs) == 0 {
+			return cfg, fmt.Errorf("REDIS_MODE=sentinel requires REDIS_ADDRS")
+		}
+	case redisAdapter.ModeCluster:
+		if len(cfg.Addrs) == 0 {
+			return cfg, fmt.Errorf("REDIS_MODE=cluster requires REDIS_ADDRS")
+		}
+		if cfg.DB != 0 {
+			return cfg, fmt.Errorf("REDIS_DB must be 0 in cluster mode")
+		}
+	default:
+		return cfg, fmt.Errorf("invalid REDIS_MODE %q: expected standalone, sentinel or cluster", cfg.Mode)
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return cfg, fmt.Errorf("REDIS_TLS_CERT_FILE and REDIS_TLS_KEY_FILE must be set together")
+	}
+	return cfg, nil
+}
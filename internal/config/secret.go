@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const redacted = "******"
+
+// Secret — значение, которое не должно попасть в логи и дампы конфигурации.
+// fmt, slog и encoding/json видят только "******"; само значение — через Reveal.
+type Secret string
+
+func (s Secret) Reveal() string { return string(s) }
+
+func (s Secret) IsSet() bool { return s != "" }
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string { return `config.Secret("` + s.String() + `")` }
+
+func (s Secret) LogValue() slog.Value { return slog.StringValue(s.String()) }
+
+func (s Secret) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// fileEnvKeys — переменные, которые можно передать файлом через KEY_FILE (Docker secrets).
+var fileEnvKeys = []string{
+	"PG_USER",
+	"PG_PASSWORD",
+	"REDIS_USERNAME",
+	"REDIS_PASSWORD",
+	"REDIS_SENTINEL_PASSWORD",
+	"ADMIN_TOKEN",
+}
+
+// resolveFileEnv читает значения KEY из файлов KEY_FILE. environ — переменные,
+// заданные в окружении процесса до загрузки env-файла. Как и для прочих
+// переменных, окружение важнее env-файла: KEY_FILE из окружения перекрывает KEY
+// из .env, а KEY из окружения — KEY_FILE из .env. Если KEY и KEY_FILE заданы на
+// одном уровне с разными значениями — ошибка: непонятно, какое из них верное.
+func resolveFileEnv(environ map[string]bool) error {
+	for _, key := range fileEnvKeys {
+		path := os.Getenv(key + "_FILE")
+		if path == "" {
+			continue
+		}
+		keyInEnv, fileInEnv := environ[key], environ[key+"_FILE"]
+		if keyInEnv && !fileInEnv && os.Getenv(key) != "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s_FILE: %w", key, err)
+		}
+		value := strings.TrimRight(string(data), "\r\n")
+
+		if current := os.Getenv(key); current != "" && current != value && keyInEnv == fileInEnv {
+			return fmt.Errorf("both %s and %s_FILE are set", key, key)
+		}
+		os.Setenv(key, value)
+	}
+	return nil
+}
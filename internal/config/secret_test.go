@@ -0,0 +1,140 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// unsetenv убирает переменную на время теста, как будто её нет в окружении.
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "") // восстановит прежнее значение после теста
+	os.Unsetenv(key)
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFileEnvPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		dotenv  string            // содержимое .env; {secret} — путь к файлу секрета
+		environ map[string]string // окружение процесса
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "_FILE from environment beats .env value",
+			dotenv:  "PG_PASSWORD=from-dotenv\n",
+			environ: map[string]string{"PG_PASSWORD_FILE": "{secret}"},
+			want:    "from-secret",
+		},
+		{
+			name:    "environment value beats _FILE from .env",
+			dotenv:  "PG_PASSWORD_FILE={secret}\n",
+			environ: map[string]string{"PG_PASSWORD": "from-env"},
+			want:    "from-env",
+		},
+		{
+			name:   "_FILE from .env",
+			dotenv: "PG_PASSWORD_FILE={secret}\n",
+			want:   "from-secret",
+		},
+		{
+			name:    "both in environment",
+			environ: map[string]string{"PG_PASSWORD": "from-env", "PG_PASSWORD_FILE": "{secret}"},
+			wantErr: true,
+		},
+		{
+			name:    "both in .env",
+			dotenv:  "PG_PASSWORD=from-dotenv\nPG_PASSWORD_FILE={secret}\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			secret := writeFile(t, dir, "pg_password", "from-secret\n")
+			unsetenv(t, "PG_PASSWORD")
+			unsetenv(t, "PG_PASSWORD_FILE")
+			for key, value := range tt.environ {
+				if value == "{secret}" {
+					value = secret
+				}
+				t.Setenv(key, value)
+			}
+			dotenv := writeFile(t, dir, ".env", strings.ReplaceAll(tt.dotenv, "{secret}", secret))
+
+			err := loadEnvFile(dotenv, true)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want error, PG_PASSWORD=%q", os.Getenv("PG_PASSWORD"))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := os.Getenv("PG_PASSWORD"); got != tt.want {
+				t.Errorf("PG_PASSWORD = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretIsRedacted(t *testing.T) {
+	const value = "hunter2-s3cr3t"
+	secret := Secret(value)
+	cfg := PostgresConfig{Host: "db", User: "marketflow", Password: secret}
+
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("text", "password", secret, "postgres", cfg)
+	slog.New(slog.NewJSONHandler(&logs, nil)).Info("json", "password", secret, "postgres", cfg)
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := map[string]string{
+		"%v":       fmt.Sprintf("%v", secret),
+		"%s":       fmt.Sprintf("%s", secret),
+		"%q":       fmt.Sprintf("%q", secret),
+		"%#v":      fmt.Sprintf("%#v", secret),
+		"struct":   fmt.Sprintf("%v", cfg),
+		"struct+":  fmt.Sprintf("%+v", cfg),
+		"struct#":  fmt.Sprintf("%#v", cfg),
+		"slog":     logs.String(),
+		"json":     string(data),
+		"pointer#": fmt.Sprintf("%#v", &cfg),
+	}
+	for name, out := range outputs {
+		if strings.Contains(out, value) {
+			t.Errorf("%s leaks the secret: %s", name, out)
+		}
+		if !strings.Contains(out, redacted) {
+			t.Errorf("%s has no placeholder: %s", name, out)
+		}
+	}
+
+	if secret.Reveal() != value {
+		t.Errorf("Reveal() = %q, want the value", secret.Reveal())
+	}
+	// Пустой секрет виден как пустой: по дампу понятно, что он не задан
+	if got := fmt.Sprint(Secret("")); got != "" {
+		t.Errorf("empty secret prints %q", got)
+	}
+}
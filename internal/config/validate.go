@@ -0,0 +1,188 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	redisAdapter "marketflow/internal/adapters/output/redis"
+	"marketflow/internal/domain/models"
+)
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// chaosEnvironments — APP_ENV, в которых разрешено внедрение сбоев. Пустой или
+// незнакомый APP_ENV считается production.
+var chaosEnvironments = []string{"development", "dev", "local", "test", "staging"}
+
+// Validate проверяет значения по смыслу: диапазоны портов, границы длительностей,
+// связи между настройками. Синтаксис переменных проверяется ещё при чтении.
+// Возвращает все найденные ошибки разом, чтобы не чинить конфиг по одной.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.PortAPI), "API_PORT %d out of range 1-65535", c.PortAPI)
+
+	// postgres
+	check(validPort(c.Postgres.Port), "PG_PORT %d out of range 1-65535", c.Postgres.Port)
+	check(slices.Contains(sslModes, c.Postgres.SSLMode), "invalid PG_SSLMODE %q", c.Postgres.SSLMode)
+
+	// redis
+	if c.Redis.Mode == redisAdapter.ModeStandalone {
+		check(validPortString(c.Redis.Port), "REDIS_PORT %q out of range 1-65535", c.Redis.Port)
+	}
+	for _, addr := range c.Redis.Addrs {
+		_, port, err := net.SplitHostPort(addr)
+		check(err == nil && validPortString(port), "invalid REDIS_ADDRS entry %q, want host:port", addr)
+	}
+	check(c.Redis.DB >= 0, "REDIS_DB must be >= 0")
+	check(inRange(c.Redis.DialTimeout, time.Millisecond, time.Minute), "REDIS_DIAL_TIMEOUT %s out of range 1ms-1m", c.Redis.DialTimeout)
+	check(inRange(c.Redis.ReadTimeout, time.Millisecond, time.Minute), "REDIS_READ_TIMEOUT %s out of range 1ms-1m", c.Redis.ReadTimeout)
+	check(inRange(c.Redis.WriteTimeout, time.Millisecond, time.Minute), "REDIS_WRITE_TIMEOUT %s out of range 1ms-1m", c.Redis.WriteTimeout)
+	check(c.Redis.PoolSize >= 0, "REDIS_POOL_SIZE must be >= 0")
+	check(c.Redis.MinIdleConns >= 0, "REDIS_MIN_IDLE_CONNS must be >= 0")
+	if c.Redis.PoolSize > 0 {
+		check(c.Redis.MinIdleConns <= c.Redis.PoolSize, "REDIS_MIN_IDLE_CONNS %d exceeds REDIS_POOL_SIZE %d", c.Redis.MinIdleConns, c.Redis.PoolSize)
+	}
+
+	// окно агрегации и TTL; задержку чтения окна сервис сам добавляет к сроку хранения тиков
+	check(inRange(c.AggregatorWindow, time.Second, 24*time.Hour), "AGGREGATOR_WINDOW %s out of range 1s-24h", c.AggregatorWindow)
+	check(c.RedisTTL >= c.AggregatorWindow, "REDIS_TTL %s must be >= AGGREGATOR_WINDOW %s", c.RedisTTL, c.AggregatorWindow)
+
+	// биржи
+	check(len(c.Exchanges) > 0, "no exchanges configured")
+	seen := make(map[string]bool, len(c.Exchanges))
+	for _, ex := range c.Exchanges {
+		check(ex.Name != "", "exchange name must not be empty")
+		check(!seen[ex.Name], "duplicate exchange name %q", ex.Name)
+		seen[ex.Name] = true
+		check(ex.Host != "", "exchange %q: empty host", ex.Name)
+		check(validPortString(ex.Port), "exchange %q: port %q out of range 1-65535", ex.Name, ex.Port)
+	}
+
+	// очереди бирж; переопределение без такой биржи — скорее всего опечатка в имени
+	errs = append(errs, validateQueue("BACKPRESSURE_", c.Backpressure.Default)...)
+	for _, name := range slices.Sorted(maps.Keys(c.Backpressure.Exchanges)) {
+		errs = append(errs, validateQueue("BACKPRESSURE_"+name+"_", c.Backpressure.Exchanges[name])...)
+		check(slices.ContainsFunc(c.Exchanges, func(ex models.ExchangeConfig) bool { return strings.ToUpper(ex.Name) == name }),
+			"BACKPRESSURE_%s_* does not match any exchange", name)
+	}
+
+	// спред
+	check(c.Spread.ThresholdBps > 0, "SPREAD_THRESHOLD_BPS must be > 0")
+	check(c.Spread.MinDuration >= 0, "SPREAD_MIN_DURATION must be >= 0")
+	check(c.Spread.MaxQuoteAge > 0, "SPREAD_MAX_QUOTE_AGE must be > 0")
+
+	check(c.Staleness.Threshold > 0, "STALENESS_THRESHOLD must be > 0")
+	check(inRange(c.Staleness.CheckInterval, 100*time.Millisecond, c.Staleness.Threshold),
+		"STALENESS_CHECK_INTERVAL %s out of range 100ms-STALENESS_THRESHOLD", c.Staleness.CheckInterval)
+
+	// валидация тиков
+	errs = append(errs, validateRules("VALIDATION_", c.Validation.Default)...)
+	for _, pair := range slices.Sorted(maps.Keys(c.Validation.Pairs)) {
+		errs = append(errs, validateRules("VALIDATION_"+pair+"_", c.Validation.Pairs[pair])...)
+	}
+
+	check(len(c.TrackedPairs) > 0, "TRACKED_PAIRS must not be empty")
+	check(c.WSClientBuffer > 0, "WS_CLIENT_BUFFER must be > 0")
+
+	for _, p := range c.Publishers.List() {
+		check(p.Config.Buffer >= 0, "PUBLISH_%s_BUFFER must be >= 0", p.Name)
+		check(!p.Config.Enabled || p.Config.Ticks || p.Config.Aggregates, "PUBLISH_%s_EVENTS selects no events", p.Name)
+	}
+
+	check(c.Alerts.WebhookAttempts >= 1, "ALERT_WEBHOOK_ATTEMPTS must be >= 1")
+	check(c.Alerts.WebhookBackoff >= 0, "ALERT_WEBHOOK_BACKOFF must be >= 0")
+	check(inRange(c.Alerts.WebhookTimeout, time.Millisecond, time.Minute), "ALERT_WEBHOOK_TIMEOUT %s out of range 1ms-1m", c.Alerts.WebhookTimeout)
+
+	check(c.Log.SampleBurst >= 0, "LOG_SAMPLE_BURST must be >= 0")
+	check(c.Log.SampleInterval >= 0, "LOG_SAMPLE_INTERVAL must be >= 0")
+
+	if c.Tracing.Enabled {
+		_, port, err := net.SplitHostPort(c.Tracing.Endpoint)
+		check(err == nil && validPortString(port), "invalid TRACING_ENDPOINT %q, want host:port", c.Tracing.Endpoint)
+		check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO %g out of range 0-1", c.Tracing.SampleRatio)
+	}
+
+	// сбои только в явно перечисленных окружениях
+	if c.Chaos.Enabled {
+		check(slices.Contains(chaosEnvironments, c.AppEnv), "CHAOS_ENABLED is not allowed with APP_ENV=%q: expected one of %s",
+			c.AppEnv, strings.Join(chaosEnvironments, ", "))
+		errs = append(errs, validateChaosFaults("CHAOS_EXCHANGE_", c.Chaos.Exchange)...)
+		check(c.Chaos.ExchangeDisconnectEvery >= 0, "CHAOS_EXCHANGE_DISCONNECT_EVERY must be >= 0")
+		errs = append(errs, validateChaosFaults("CHAOS_REDIS_", c.Chaos.Redis)...)
+		errs = append(errs, validateChaosFaults("CHAOS_POSTGRES_", c.Chaos.Postgres)...)
+	}
+
+	// запись и воспроизведение
+	check(c.Recording.Mode != ExchangeModeReplay || c.Recording.RecordDir != "", "replay mode requires RECORD_DIR")
+	check(c.Recording.ReplaySpeed >= 0, "REPLAY_SPEED must be >= 0")
+
+	return errors.Join(errs...)
+}
+
+func validateRules(prefix string, r ValidationRules) []error {
+	var errs []error
+	if r.MaxDeviation < 0 {
+		errs = append(errs, fmt.Errorf("%sMAX_DEVIATION must be >= 0", prefix))
+	}
+	if r.MedianWindow < 0 {
+		errs = append(errs, fmt.Errorf("%sMEDIAN_WINDOW must be >= 0", prefix))
+	}
+	if r.MaxJumpPerSec < 0 {
+		errs = append(errs, fmt.Errorf("%sMAX_JUMP_PER_SEC must be >= 0", prefix))
+	}
+	if r.MaxTickAge < 0 {
+		errs = append(errs, fmt.Errorf("%sMAX_TICK_AGE must be >= 0", prefix))
+	}
+	return errs
+}
+
+func validateQueue(prefix string, q models.QueueConfig) []error {
+	var errs []error
+	if !slices.Contains(models.QueuePolicies, q.Policy) {
+		errs = append(errs, fmt.Errorf("invalid %sPOLICY %q: expected one of %s", prefix, q.Policy, strings.Join(models.QueuePolicies, ", ")))
+	}
+	if q.Buffer < 1 {
+		errs = append(errs, fmt.Errorf("%sBUFFER must be >= 1", prefix))
+	}
+	return errs
+}
+
+func validateChaosFaults(prefix string, f ChaosFaults) []error {
+	var errs []error
+	if f.Latency < 0 {
+		errs = append(errs, fmt.Errorf("%sLATENCY must be >= 0", prefix))
+	}
+	if f.ErrorRate < 0 || f.ErrorRate > 1 {
+		errs = append(errs, fmt.Errorf("%sERROR_RATE %g out of range 0-1", prefix, f.ErrorRate))
+	}
+	if f.PartialRate < 0 || f.PartialRate > 1 {
+		errs = append(errs, fmt.Errorf("%sPARTIAL_RATE %g out of range 0-1", prefix, f.PartialRate))
+	}
+	if f.OutageEvery < 0 || f.OutageFor < 0 {
+		errs = append(errs, fmt.Errorf("%sOUTAGE_EVERY and %sOUTAGE_FOR must be >= 0", prefix, prefix))
+	} else if f.OutageEvery > 0 && f.OutageFor >= f.OutageEvery {
+		errs = append(errs, fmt.Errorf("%sOUTAGE_FOR %s must be < %sOUTAGE_EVERY %s", prefix, f.OutageFor, prefix, f.OutageEvery))
+	}
+	return errs
+}
+
+func validPort(port int) bool { return port >= 1 && port <= 65535 }
+
+func validPortString(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && validPort(n)
+}
+
+func inRange(d, min, max time.Duration) bool { return d >= min && d <= max }
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+func TestValidateRedisTTLCoversAggregation(t *testing.T) {
+	tests := []struct {
+		ttl, window time.Duration
+		ok          bool
+	}{
+		{ttl: time.Minute, window: time.Minute, ok: true},
+		{ttl: 59 * time.Second, window: time.Minute, ok: false},
+		{ttl: 2 * time.Minute, window: time.Minute, ok: true},
+		{ttl: time.Second, window: time.Second, ok: true},
+		{ttl: time.Minute, window: 5 * time.Minute, ok: false},
+	}
+	for _, tt := range tests {
+		c := &Config{RedisTTL: tt.ttl, AggregatorWindow: tt.window}
+		err := c.Validate()
+		failed := err != nil && strings.Contains(err.Error(), "REDIS_TTL")
+		if failed == tt.ok {
+			t.Errorf("REDIS_TTL %s, AGGREGATOR_WINDOW %s: ok = %t, want %t (%v)", tt.ttl, tt.window, !failed, tt.ok, err)
+		}
+	}
+}
+
+// loadConfig собирает конфигурацию, как setup: env-файл, окружение, флаги.
+// Переменные, которые читает NewConfig, на время теста убираются из окружения.
+func loadConfig(t *testing.T, dotenv string, environ map[string]string, flags Overrides) (*Config, error) {
+	t.Helper()
+	for key := range defaults {
+		unsetenv(t, key)
+	}
+	for _, line := range strings.Split(dotenv, "\n") {
+		if key, _, ok := strings.Cut(line, "="); ok {
+			unsetenv(t, key)
+		}
+	}
+	for _, key := range []string{"PG_PASSWORD_FILE", "REDIS_MODE", "REDIS_ADDRS", "LOG_LEVEL", "LOG_FORMAT", "EXCHANGE_MODE"} {
+		unsetenv(t, key)
+	}
+	for key, value := range map[string]string{"PG_HOST": "db", "PG_USER": "u", "PG_PASSWORD": "p", "PG_NAME": "marketflow", "REDIS_HOST": "redis"} {
+		t.Setenv(key, value)
+	}
+	for key, value := range environ {
+		t.Setenv(key, value)
+	}
+
+	c, err := NewConfig(writeFile(t, t.TempDir(), ".env", dotenv))
+	if err != nil {
+		return nil, err
+	}
+	return c, c.Apply(flags)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		dotenv  string
+		environ map[string]string
+		flags   Overrides
+		port    int
+		level   string
+	}{
+		{name: "defaults", port: 8080, level: "INFO"},
+		{name: "file over defaults", dotenv: "API_PORT=8081\nLOG_LEVEL=warn\n", port: 8081, level: "WARN"},
+		{
+			name:    "environment over file",
+			dotenv:  "API_PORT=8081\nLOG_LEVEL=warn\n",
+			environ: map[string]string{"API_PORT": "8082"},
+			port:    8082, level: "WARN",
+		},
+		{
+			name:    "flags over environment",
+			dotenv:  "API_PORT=8081\nLOG_LEVEL=warn\n",
+			environ: map[string]string{"API_PORT": "8082", "LOG_LEVEL": "error"},
+			flags:   Overrides{Port: 8083, LogLevel: "debug"},
+			port:    8083, level: "DEBUG",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := loadConfig(t, tt.dotenv, tt.environ, tt.flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.PortAPI != tt.port || c.Log.Level.String() != tt.level {
+				t.Errorf("port %d, log level %s; want %d, %s", c.PortAPI, c.Log.Level, tt.port, tt.level)
+			}
+		})
+	}
+}
+
+func TestDefaultsStayOutOfEnvironment(t *testing.T) {
+	c, err := loadConfig(t, "", nil, Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.PortAPI != 8080 || c.Postgres.Port != 5432 || c.Postgres.SSLMode != "disable" ||
+		c.Redis.Port != "6379" || c.AggregatorWindow != time.Minute || c.Exchanges[2].Name != "exchange3" || c.Exchanges[2].Port != "40103" {
+		t.Errorf("defaults not applied: %+v", c)
+	}
+	for key := range defaults {
+		if value, ok := os.LookupEnv(key); ok {
+			t.Errorf("NewConfig set %s=%q in the environment", key, value)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string // подстрока ошибки; пусто — конфигурация верна
+	}{
+		{"defaults", func(c *Config) {}, ""},
+		{"API port zero", func(c *Config) { c.PortAPI = 0 }, "API_PORT 0 out of range"},
+		{"API port too big", func(c *Config) { c.PortAPI = 65536 }, "API_PORT 65536 out of range"},
+		{"API port max", func(c *Config) { c.PortAPI = 65535 }, ""},
+		{"postgres port", func(c *Config) { c.Postgres.Port = 70000 }, "PG_PORT 70000 out of range"},
+		{"redis port", func(c *Config) { c.Redis.Port = "0" }, `REDIS_PORT "0" out of range`},
+		{"redis addr without port", func(c *Config) { c.Redis.Addrs = []string{"redis"} }, `invalid REDIS_ADDRS entry "redis"`},
+		{"redis addr port", func(c *Config) { c.Redis.Addrs = []string{"redis:99999"} }, `invalid REDIS_ADDRS entry "redis:99999"`},
+		{"exchange port", func(c *Config) { c.Exchanges[1].Port = "65536" }, `exchange "exchange2": port "65536" out of range`},
+		{"exchange port not a number", func(c *Config) { c.Exchanges[0].Port = "http" }, `exchange "exchange1": port "http" out of range`},
+		{"duplicate exchange", func(c *Config) { c.Exchanges[2].Name = "exchange1" }, `duplicate exchange name "exchange1"`},
+		{"no exchanges", func(c *Config) { c.Exchanges = nil }, "no exchanges configured"},
+		{"backpressure for a configured exchange", func(c *Config) {
+			c.Backpressure.Exchanges["EXCHANGE2"] = models.QueueConfig{Policy: "block", Buffer: 10}
+		}, ""},
+		{"backpressure for an unknown exchange", func(c *Config) {
+			c.Backpressure.Exchanges["EXCHNGE2"] = models.QueueConfig{Policy: "block", Buffer: 10}
+		}, "BACKPRESSURE_EXCHNGE2_* does not match any exchange"},
+		{"backpressure policy", func(c *Config) {
+			c.Backpressure.Exchanges["EXCHANGE1"] = models.QueueConfig{Policy: "spill", Buffer: 10}
+		}, `invalid BACKPRESSURE_EXCHANGE1_POLICY "spill"`},
+		{"chaos in staging", func(c *Config) { c.AppEnv, c.Chaos.Enabled = "staging", true }, ""},
+		{"chaos in test", func(c *Config) { c.AppEnv, c.Chaos.Enabled = "test", true }, ""},
+		{"chaos in production", func(c *Config) { c.AppEnv, c.Chaos.Enabled = "production", true }, `CHAOS_ENABLED is not allowed with APP_ENV="production"`},
+		{"chaos in prod", func(c *Config) { c.AppEnv, c.Chaos.Enabled = "prod", true }, `CHAOS_ENABLED is not allowed with APP_ENV="prod"`},
+		{"chaos without APP_ENV", func(c *Config) { c.AppEnv, c.Chaos.Enabled = "", true }, `CHAOS_ENABLED is not allowed with APP_ENV=""`},
+		{"production without chaos", func(c *Config) { c.AppEnv = "production" }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := loadConfig(t, "", nil, Overrides{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(c)
+			err = c.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
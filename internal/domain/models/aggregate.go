@@ -0,0 +1,82 @@
+package models
+
+import (
+	"math/big"
+	"time"
+)
+
+// Aggregate — статистика цен пары на бирже за окно агрегатора (строка market_data).
+type Aggregate struct {
+	Exchange  string    `json:"exchange"`
+	Pair      string    `json:"symbol"`
+	Average   Decimal   `json:"average_price"`
+	Min       Decimal   `json:"min_price"`
+	Max       Decimal   `json:"max_price"`
+	Count     int       `json:"count"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewAggregate считает avg/min/max по ценам. ok=false, если цен нет.
+func NewAggregate(exchange, pair string, prices []Decimal, ts time.Time) (Aggregate, bool) {
+	avg, ok := Mean(prices)
+	if !ok {
+		return Aggregate{}, false
+	}
+
+	min, max := prices[0], prices[0]
+	for _, p := range prices[1:] {
+		if p.Cmp(min) < 0 {
+			min = p
+		}
+		if p.Cmp(max) > 0 {
+			max = p
+		}
+	}
+
+	return Aggregate{
+		Exchange:  exchange,
+		Pair:      pair,
+		Average:   avg,
+		Min:       min,
+		Max:       max,
+		Count:     len(prices),
+		Timestamp: ts,
+	}, true
+}
+
+// MergeAggregates сворачивает несколько окон одной пары/биржи в одно с меткой ts.
+// Среднее взвешивается по числу тиков в окне.
+func MergeAggregates(aggs []Aggregate, ts time.Time) (Aggregate, bool) {
+	if len(aggs) == 0 {
+		return Aggregate{}, false
+	}
+
+	merged := Aggregate{
+		Exchange:  aggs[0].Exchange,
+		Pair:      aggs[0].Pair,
+		Min:       aggs[0].Min,
+		Max:       aggs[0].Max,
+		Timestamp: ts,
+	}
+
+	sum := new(big.Int)
+	plain := make([]Decimal, 0, len(aggs))
+	for _, a := range aggs {
+		if a.Min.Cmp(merged.Min) < 0 {
+			merged.Min = a.Min
+		}
+		if a.Max.Cmp(merged.Max) > 0 {
+			merged.Max = a.Max
+		}
+		merged.Count += a.Count
+		sum.Add(sum, new(big.Int).Mul(big.NewInt(a.Average.units), big.NewInt(int64(a.Count))))
+		plain = append(plain, a.Average)
+	}
+
+	if merged.Count == 0 {
+		merged.Average, _ = Mean(plain)
+		return merged, true
+	}
+	merged.Average = Decimal{units: divRound(sum, big.NewInt(int64(merged.Count))).Int64()}
+	return merged, true
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func decimals(in ...string) []Decimal {
+	out := make([]Decimal, len(in))
+	for i, s := range in {
+		out[i] = MustDecimal(s)
+	}
+	return out
+}
+
+func TestNewAggregate(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	agg, ok := NewAggregate("ex", "BTCUSDT", decimals("0.3", "0.1", "0.2"), ts)
+	if !ok {
+		t.Fatal("no aggregate")
+	}
+	if agg.Average.String() != "0.2" || agg.Min.String() != "0.1" || agg.Max.String() != "0.3" || agg.Count != 3 || !agg.Timestamp.Equal(ts) {
+		t.Errorf("aggregate = %+v", agg)
+	}
+
+	if _, ok := NewAggregate("ex", "BTCUSDT", nil, ts); ok {
+		t.Error("aggregate of no prices")
+	}
+}
+
+func TestMergeAggregates(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	window := func(avg, min, max string, count int) Aggregate {
+		return Aggregate{Exchange: "ex", Pair: "BTCUSDT", Average: MustDecimal(avg), Min: MustDecimal(min), Max: MustDecimal(max), Count: count}
+	}
+
+	tests := []struct {
+		name          string
+		in            []Aggregate
+		avg, min, max string
+		count         int
+	}{
+		{
+			name: "weighted by ticks",
+			in:   []Aggregate{window("10", "9", "11", 1), window("20", "15", "25", 3)},
+			avg:  "17.5", min: "9", max: "25", count: 4,
+		},
+		{
+			name: "rounded weighted average",
+			in:   []Aggregate{window("1", "1", "1", 2), window("2", "2", "2", 1)},
+			avg:  "1.33333333", min: "1", max: "2", count: 3,
+		},
+		{
+			name: "windows without tick counts",
+			in:   []Aggregate{window("10", "10", "10", 0), window("20", "20", "20", 0)},
+			avg:  "15", min: "10", max: "20", count: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := MergeAggregates(tt.in, ts)
+			if !ok {
+				t.Fatal("no aggregate")
+			}
+			if got.Average.String() != tt.avg || got.Min.String() != tt.min || got.Max.String() != tt.max || got.Count != tt.count {
+				t.Errorf("merged = avg %s min %s max %s count %d", got.Average, got.Min, got.Max, got.Count)
+			}
+			if got.Exchange != "ex" || got.Pair != "BTCUSDT" || !got.Timestamp.Equal(ts) {
+				t.Errorf("merged = %+v", got)
+			}
+		})
+	}
+
+	if _, ok := MergeAggregates(nil, ts); ok {
+		t.Error("merge of no windows")
+	}
+}
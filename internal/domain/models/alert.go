@@ -0,0 +1,143 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Типы правил оповещений
+const (
+	RuleThreshold = "threshold"  // цена пересекла уровень
+	RulePctChange = "pct_change" // цена сдвинулась на Percent% за Window
+	RuleSpread    = "spread"     // спред между биржами по паре больше SpreadBps
+	RuleStaleness = "staleness"  // по паре нет тиков дольше MaxAge
+)
+
+const (
+	DirectionAbove = "above"
+	DirectionBelow = "below"
+)
+
+// Duration в JSON пишется строкой вида "5m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("duration must be a string like \"5m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+type AlertRule struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Pair       string    `json:"symbol"`
+	Exchange   string    `json:"exchange,omitempty"` // пусто — любая биржа
+	Direction  string    `json:"direction,omitempty"`
+	Price      Decimal   `json:"price,omitzero"`
+	Percent    float64   `json:"percent,omitempty"`
+	Window     Duration  `json:"window,omitempty"`
+	SpreadBps  float64   `json:"spread_bps,omitempty"`
+	MaxAge     Duration  `json:"max_age,omitempty"`
+	Cooldown   Duration  `json:"cooldown,omitempty"`
+	WebhookURL string    `json:"webhook_url"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Normalize приводит пару к виду, в котором она приходит в тиках (BTCUSDT).
+func (r AlertRule) Normalize() AlertRule {
+	r.Pair = strings.ToUpper(strings.TrimSpace(r.Pair))
+	r.Exchange = strings.TrimSpace(r.Exchange)
+	r.WebhookURL = strings.TrimSpace(r.WebhookURL)
+	return r
+}
+
+// Validate проверяет, что у правила заполнены поля, нужные его типу.
+func (r AlertRule) Validate() error {
+	if r.Pair == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if r.WebhookURL == "" {
+		return fmt.Errorf("webhook_url is required")
+	}
+	if _, err := r.WebhookHost(); err != nil {
+		return err
+	}
+	// Длительности хранятся в alert_rules целыми секундами
+	for _, d := range []struct {
+		name  string
+		value Duration
+	}{{"window", r.Window}, {"max_age", r.MaxAge}, {"cooldown", r.Cooldown}} {
+		if d.value < 0 || time.Duration(d.value)%time.Second != 0 {
+			return fmt.Errorf("%s must be a whole number of seconds", d.name)
+		}
+	}
+	switch r.Type {
+	case RuleThreshold:
+		if r.Direction != DirectionAbove && r.Direction != DirectionBelow {
+			return fmt.Errorf("direction must be %q or %q", DirectionAbove, DirectionBelow)
+		}
+		if r.Price.Sign() <= 0 {
+			return fmt.Errorf("price must be positive")
+		}
+	case RulePctChange:
+		if r.Percent <= 0 || r.Window <= 0 {
+			return fmt.Errorf("percent and window must be positive")
+		}
+	case RuleSpread:
+		if r.SpreadBps <= 0 {
+			return fmt.Errorf("spread_bps must be positive")
+		}
+	case RuleStaleness:
+		if r.MaxAge <= 0 {
+			return fmt.Errorf("max_age must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown rule type %q", r.Type)
+	}
+	return nil
+}
+
+// WebhookHost возвращает хост вебхука; допускаются только http и https.
+func (r AlertRule) WebhookHost() (string, error) {
+	u, err := url.Parse(r.WebhookURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid webhook_url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("webhook_url must be http or https")
+	}
+	if u.Hostname() == "" || u.User != nil {
+		return "", fmt.Errorf("webhook_url must have a host and no credentials")
+	}
+	return strings.ToLower(u.Hostname()), nil
+}
+
+// AlertEvent — сработавшее правило. DedupKey одинаков для повторных
+// доставок одного срабатывания, получатель может по нему отбрасывать дубли.
+type AlertEvent struct {
+	RuleID    int64     `json:"rule_id"`
+	RuleName  string    `json:"rule_name"`
+	Type      string    `json:"type"`
+	Pair      string    `json:"symbol"`
+	Exchange  string    `json:"exchange,omitempty"`
+	Message   string    `json:"message"`
+	Value     float64   `json:"value"`
+	DedupKey  string    `json:"dedup_key"`
+	Timestamp time.Time `json:"timestamp"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// Цена есть только у threshold-правил; у остальных её нет и в JSON.
+func TestAlertRuleJSONOmitsZeroPrice(t *testing.T) {
+	data, err := json.Marshal(AlertRule{Type: RuleSpread, SpreadBps: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"price"`) {
+		t.Errorf("spread rule JSON has a price: %s", data)
+	}
+
+	data, err = json.Marshal(AlertRule{Type: RuleThreshold, Price: MustDecimal("70000.5")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back AlertRule
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Price.Cmp(MustDecimal("70000.5")) != 0 {
+		t.Errorf("price round trip = %s, want 70000.5 (%s)", back.Price, data)
+	}
+}
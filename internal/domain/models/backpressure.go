@@ -0,0 +1,34 @@
+package models
+
+import "strings"
+
+// Политики переполнения очереди биржи
+const (
+	PolicyBlock      = "block"       // ждать места: давление уходит в TCP-сокет биржи
+	PolicyDropNewest = "drop-newest" // отбросить пришедший тик
+	PolicyDropOldest = "drop-oldest" // вытеснить самый старый тик
+	PolicyCoalesce   = "coalesce"    // заменить ожидающий тик той же пары, иначе вытеснить самый старый
+)
+
+// QueuePolicies — все политики переполнения.
+var QueuePolicies = []string{PolicyBlock, PolicyDropNewest, PolicyDropOldest, PolicyCoalesce}
+
+type QueueConfig struct {
+	Policy string
+	Buffer int
+}
+
+// BackpressureConfig: Default для всех бирж, Exchanges — переопределения по имени
+// биржи в верхнем регистре, как в BACKPRESSURE_<EXCHANGE>_*.
+type BackpressureConfig struct {
+	Default   QueueConfig
+	Exchanges map[string]QueueConfig
+}
+
+// For — очередь биржи с учётом переопределения.
+func (c BackpressureConfig) For(exchange string) QueueConfig {
+	if q, ok := c.Exchanges[strings.ToUpper(exchange)]; ok {
+		return q
+	}
+	return c.Default
+}
@@ -0,0 +1,19 @@
+package models
+
+import "testing"
+
+func TestBackpressureFor(t *testing.T) {
+	c := BackpressureConfig{
+		Default:   QueueConfig{Policy: PolicyDropNewest, Buffer: 1000},
+		Exchanges: map[string]QueueConfig{"EXCHANGE2": {Policy: PolicyBlock, Buffer: 10}},
+	}
+	for exchange, want := range map[string]QueueConfig{
+		"exchange2": {Policy: PolicyBlock, Buffer: 10},
+		"Exchange2": {Policy: PolicyBlock, Buffer: 10},
+		"exchange1": {Policy: PolicyDropNewest, Buffer: 1000},
+	} {
+		if got := c.For(exchange); got != want {
+			t.Errorf("For(%q) = %+v, want %+v", exchange, got, want)
+		}
+	}
+}
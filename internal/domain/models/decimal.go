@@ -0,0 +1,242 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DecimalScale — число знаков после запятой, совпадает с DECIMAL(20,8) в Postgres.
+const DecimalScale = 8
+
+var ErrDecimalRange = errors.New("decimal out of range")
+
+// Границы экспоненциальной записи: int64 в единицах 1e-8 — это 19 знаков, так что
+// порядок ±30 и мантисса в 64 символа покрывают любую представимую цену с запасом.
+const (
+	maxExponent = 30
+	maxMantissa = 64
+)
+
+// Decimal — точное десятичное число с фиксированными 8 знаками после запятой.
+// Хранится как целое количество 1e-8, поэтому цены вроде 0.12345678
+// проходят парсер, Redis, агрегацию и Postgres без ошибок двоичного float.
+type Decimal struct {
+	units int64
+}
+
+// ParseDecimal разбирает строку вида "-123.456". Знаки сверх 8-го после запятой округляются.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	// Экспоненциальная запись (4.3e4) — приводим к обычной через big.Rat. Порядок и
+	// длина мантиссы ограничены заранее: big.Rat честно построит 10^999999999.
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return Decimal{}, fmt.Errorf("%w: %q", ErrDecimalRange, s)
+			}
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		if exp < -maxExponent || exp > maxExponent || i > maxMantissa {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrDecimalRange, s)
+		}
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		s = r.FloatString(DecimalScale)
+	}
+
+	neg := false
+	digits := s
+	switch digits[0] {
+	case '-':
+		neg = true
+		digits = digits[1:]
+	case '+':
+		digits = digits[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	if intPart == "" && fracPart == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+			}
+		}
+	}
+
+	roundUp := false
+	if len(fracPart) > DecimalScale {
+		roundUp = fracPart[DecimalScale] >= '5'
+		fracPart = fracPart[:DecimalScale]
+	}
+	fracPart += strings.Repeat("0", DecimalScale-len(fracPart))
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	units, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrDecimalRange, s)
+	}
+	if roundUp {
+		if units == math.MaxInt64 {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrDecimalRange, s)
+		}
+		units++
+	}
+	if neg {
+		units = -units
+	}
+	return Decimal{units: units}, nil
+}
+
+// MustDecimal — ParseDecimal для констант; паникует на неверном вводе.
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// DecimalFromFloat переводит float64 в Decimal, округляя до 8 знаков.
+func DecimalFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("invalid decimal %v", f)
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+func (d Decimal) String() string {
+	units := d.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+	abs := new(big.Int).Abs(big.NewInt(units)).String()
+	if len(abs) <= DecimalScale {
+		abs = strings.Repeat("0", DecimalScale-len(abs)+1) + abs
+	}
+	intPart, fracPart := abs[:len(abs)-DecimalScale], strings.TrimRight(abs[len(abs)-DecimalScale:], "0")
+	if fracPart == "" {
+		return sign + intPart
+	}
+	return sign + intPart + "." + fracPart
+}
+
+// Float64 — приближённое значение для статистики и отношений (спреды, отклонения).
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Units — число в единицах 1e-8, для форматов с фиксированной точкой (Parquet DECIMAL).
+func (d Decimal) Units() int64 { return d.units }
+
+func (d Decimal) IsZero() bool { return d.units == 0 }
+
+func (d Decimal) Sign() int {
+	switch {
+	case d.units > 0:
+		return 1
+	case d.units < 0:
+		return -1
+	}
+	return 0
+}
+
+// Cmp возвращает -1, 0 или 1.
+func (d Decimal) Cmp(other Decimal) int {
+	switch {
+	case d.units < other.units:
+		return -1
+	case d.units > other.units:
+		return 1
+	}
+	return 0
+}
+
+// Mean возвращает точное среднее значений, округлённое до 8 знаков.
+func Mean(values []Decimal) (Decimal, bool) {
+	if len(values) == 0 {
+		return Decimal{}, false
+	}
+
+	sum := new(big.Int)
+	for _, v := range values {
+		sum.Add(sum, big.NewInt(v.units))
+	}
+
+	return Decimal{units: divRound(sum, big.NewInt(int64(len(values)))).Int64()}, true
+}
+
+// divRound делит с округлением половины от нуля; n > 0.
+func divRound(sum, n *big.Int) *big.Int {
+	quo, rem := new(big.Int).QuoRem(sum, n, new(big.Int))
+	if rem.Abs(rem).Mul(rem, big.NewInt(2)).Cmp(n) >= 0 {
+		if sum.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON принимает как число, так и строку в кавычках.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseDecimal(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value передаёт число в pgx строкой, чтобы NUMERIC получил его без потерь.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d *Decimal) Scan(src any) error {
+	var (
+		parsed Decimal
+		err    error
+	)
+	switch v := src.(type) {
+	case string:
+		parsed, err = ParseDecimal(v)
+	case []byte:
+		parsed, err = ParseDecimal(string(v))
+	case int64:
+		parsed, err = ParseDecimal(strconv.FormatInt(v, 10))
+	case float64:
+		parsed, err = DecimalFromFloat(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Decimal", src)
+	}
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // "" — ошибка разбора
+	}{
+		{"0", "0"},
+		{"42", "42"},
+		{" 65432.1 ", "65432.1"},
+		{"+3.5", "3.5"},
+		{"-3.5", "-3.5"},
+		{".5", "0.5"},
+		{"5.", "5"},
+		{"007.10", "7.1"},
+		{"0.12345678", "0.12345678"},
+
+		// округление сверх 8 знаков — половина от нуля
+		{"0.123456784", "0.12345678"},
+		{"0.123456785", "0.12345679"},
+		{"-0.123456785", "-0.12345679"},
+		{"0.999999995", "1"},
+		{"0.000000004", "0"},
+
+		{"4.3e4", "43000"},
+		{"1.5E-3", "0.0015"},
+		{"-2e-9", "0"},
+		{"1e-30", "0"},
+		{"1e-5", "0.00001"},
+		{"0.000000000000000000000000000001e30", "1"},
+		{"92233720368.54775807e0", "92233720368.54775807"},
+		{"9223372036854775807e-8", "92233720368.54775807"},
+
+		{"92233720368.54775807", "92233720368.54775807"},
+		{"-92233720368.54775807", "-92233720368.54775807"},
+
+		{"", ""},
+		{" ", ""},
+		{"-", ""},
+		{".", ""},
+		{"abc", ""},
+		{"1.2.3", ""},
+		{"1,5", ""},
+		{"--1", ""},
+		{"0x10", ""},
+		{"NaN", ""},
+		{"Inf", ""},
+		{"1e", ""},
+		{"1e+", ""},
+		{"1ee5", ""},
+		{"1e5.5", ""},
+		{"e5", ""},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseDecimal(%q) = %s, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseDecimalOverflow(t *testing.T) {
+	for _, in := range []string{"92233720368.54775808", "100000000000", "-100000000000", "1e400", "92233720368.547758075",
+		"1e-400", "1e31", "1e-31", "1e999999999", "1e-999999999", "1e99999999999999999999",
+		"1" + strings.Repeat("0", 64) + "e-30"} {
+		if _, err := ParseDecimal(in); !errors.Is(err, ErrDecimalRange) {
+			t.Errorf("ParseDecimal(%q) error = %v, want ErrDecimalRange", in, err)
+		}
+	}
+}
+
+func TestDecimalStringRoundTrip(t *testing.T) {
+	for _, in := range []string{"0", "1", "-1", "0.00000001", "-0.00000001", "123.456", "65432.10000001", "92233720368.54775807"} {
+		d := MustDecimal(in)
+		again, err := ParseDecimal(d.String())
+		if err != nil || again != d || d.String() != in {
+			t.Errorf("%q -> %q -> %v, %v", in, d.String(), again, err)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		Number Decimal `json:"number"`
+		Quoted Decimal `json:"quoted"`
+	}
+	if err := json.Unmarshal([]byte(`{"number": 0.1, "quoted": "0.2"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"number":0.1,"quoted":0.2}` {
+		t.Errorf("Marshal = %s", out)
+	}
+}
+
+func TestMean(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+	}{
+		{[]string{"0.1", "0.2"}, "0.15"},
+		{[]string{"0.1", "0.2", "0.3"}, "0.2"},
+		{[]string{"0.00000001", "0.00000002"}, "0.00000002"}, // 1.5e-8 округляется от нуля
+		{[]string{"-0.00000001", "-0.00000002"}, "-0.00000002"},
+		{[]string{"1", "1", "2"}, "1.33333333"},
+		{[]string{"2", "2", "1"}, "1.66666667"},
+		// сумма выходит за int64, среднее — нет
+		{[]string{"92233720368.54775807", "92233720368.54775807"}, "92233720368.54775807"},
+	}
+	for _, tt := range tests {
+		values := make([]Decimal, len(tt.in))
+		for i, s := range tt.in {
+			values[i] = MustDecimal(s)
+		}
+		got, ok := Mean(values)
+		if !ok || got.String() != tt.want {
+			t.Errorf("Mean(%v) = %s, %t; want %s", tt.in, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := Mean(nil); ok {
+		t.Error("Mean(nil) ok")
+	}
+}
+
+func TestDecimalScanValue(t *testing.T) {
+	tests := []struct {
+		src  any
+		want string
+	}{
+		{"65432.12345678", "65432.12345678"},
+		{[]byte("-0.5"), "-0.5"},
+		{int64(42), "42"},
+		{0.1, "0.1"},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		if err := d.Scan(tt.src); err != nil || d.String() != tt.want {
+			t.Errorf("Scan(%#v) = %s, %v; want %s", tt.src, d, err, tt.want)
+			continue
+		}
+		value, err := d.Value()
+		if err != nil || value != tt.want {
+			t.Errorf("Value() = %#v, %v; want %q", value, err, tt.want)
+		}
+	}
+
+	for _, src := range []any{nil, true, "abc"} {
+		var d Decimal
+		if err := d.Scan(src); err == nil {
+			t.Errorf("Scan(%#v) = %s, want error", src, d)
+		}
+	}
+}
+
+// Любая строка разбирается быстро и без паники, а удачный разбор переживает String.
+func FuzzParseDecimal(f *testing.F) {
+	for _, seed := range []string{"0", "-3.5", ".5", "4.3e4", "1e-30", "1e999999999", "92233720368.54775807", "0.123456785"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		d, err := ParseDecimal(in)
+		if err != nil {
+			return
+		}
+		back, err := ParseDecimal(d.String())
+		if err != nil || back != d {
+			t.Errorf("ParseDecimal(%q) = %s, reparsed as %s, %v", in, d, back, err)
+		}
+	})
+}
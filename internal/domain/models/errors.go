@@ -0,0 +1,13 @@
+package models
+
+import "errors"
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrInvalidRule  = errors.New("invalid alert rule")
+	ErrInvalidQuery = errors.New("invalid query")
+
+	// ErrFeedFinished — у биржи больше не будет тиков (запись воспроизведена целиком),
+	// переподключаться не нужно.
+	ErrFeedFinished = errors.New("feed finished")
+)
@@ -0,0 +1,37 @@
+package models
+
+import "strings"
+
+// Известные котируемые активы, по которым символ без разделителя делится на base/quote.
+var quoteAssets = []string{"USDT", "USDC", "BUSD", "USD", "EUR", "BTC", "ETH"}
+
+// Pair — каноническая торговая пара, например BTC/USDT.
+type Pair struct {
+	Base  string
+	Quote string
+}
+
+// Symbol возвращает каноническое имя пары, которое используется в ключах Redis и в БД.
+func (p Pair) Symbol() string {
+	return p.Base + p.Quote
+}
+
+func (p Pair) String() string {
+	return p.Base + "/" + p.Quote
+}
+
+// ParsePair разбирает "BTC/USDT", "btc-usdt" или "BTCUSDT" в пару.
+func ParsePair(symbol string) (Pair, bool) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	for _, sep := range []string{"/", "-", "_", ":"} {
+		if base, quote, ok := strings.Cut(symbol, sep); ok && base != "" && quote != "" {
+			return Pair{Base: base, Quote: quote}, true
+		}
+	}
+	for _, quote := range quoteAssets {
+		if base, ok := strings.CutSuffix(symbol, quote); ok && base != "" {
+			return Pair{Base: base, Quote: quote}, true
+		}
+	}
+	return Pair{}, false
+}
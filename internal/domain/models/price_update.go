@@ -2,11 +2,32 @@ package models
 
 import "time"
 
+// PriceUpdate несёт три отметки времени пути тика:
+//   - ExchangeTime — время, указанное биржей (пусто, если биржа его не прислала);
+//   - ReceivedAt — строка прочитана из сокета;
+//   - ProcessedAt — тик записан в Redis.
+//
+// Timestamp — время тика для окон агрегации; для живого потока совпадает с ReceivedAt.
 type PriceUpdate struct {
-	Exchange  string    `json:"exchange"`
-	Pair      string    `json:"symbol"`
-	Price     float64   `json:"price"`
-	Timestamp time.Time `json:"timestamp"`
+	Exchange     string    `json:"exchange"`
+	Pair         string    `json:"symbol"`
+	Price        Decimal   `json:"price"`
+	Timestamp    time.Time `json:"timestamp"`
+	ExchangeTime time.Time `json:"exchange_time,omitzero"`
+	ReceivedAt   time.Time `json:"received_at,omitzero"`
+	ProcessedAt  time.Time `json:"processed_at,omitzero"`
+
+	// TraceParent — спан приёма тика в формате W3C traceparent; дальнейшие стадии
+	// пайплайна продолжают его трассу. Пусто, если трассировка выключена.
+	TraceParent string `json:"-"`
+}
+
+// EventTime — время события по бирже, если оно известно, иначе Timestamp.
+func (u PriceUpdate) EventTime() time.Time {
+	if !u.ExchangeTime.IsZero() {
+		return u.ExchangeTime
+	}
+	return u.Timestamp
 }
 
 type ExchangeConfig struct {
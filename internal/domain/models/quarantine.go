@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Коды причин, по которым тик не прошёл валидацию
+const (
+	RejectNonPositive = "non_positive"
+	RejectDeviation   = "max_deviation"
+	RejectJump        = "max_jump"
+	RejectStale       = "stale_timestamp"
+)
+
+type QuarantinedTick struct {
+	Update     PriceUpdate `json:"update"`
+	Reason     string      `json:"reason"`
+	Detail     string      `json:"detail"`
+	RejectedAt time.Time   `json:"rejected_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	SpreadOpened = "opened"
+	SpreadClosed = "closed"
+)
+
+// SpreadEvent описывает превышение спреда между двумя биржами по одной паре.
+// Opened отправляется, когда спред держится выше порога минимальное время,
+// Closed — когда он снова опускается ниже порога.
+type SpreadEvent struct {
+	Pair         string        `json:"symbol"`
+	BuyExchange  string        `json:"buy_exchange"`
+	SellExchange string        `json:"sell_exchange"`
+	BuyPrice     Decimal       `json:"buy_price"`
+	SellPrice    Decimal       `json:"sell_price"`
+	SpreadBps    float64       `json:"spread_bps"`
+	PeakBps      float64       `json:"peak_bps"`
+	Status       string        `json:"status"`
+	StartedAt    time.Time     `json:"started_at"`
+	Timestamp    time.Time     `json:"timestamp"`
+	Duration     time.Duration `json:"duration"`
+}
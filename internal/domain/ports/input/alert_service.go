@@ -0,0 +1,15 @@
+package input
+
+import (
+	"context"
+
+	"marketflow/internal/domain/models"
+)
+
+type AlertService interface {
+	ListRules(ctx context.Context) ([]models.AlertRule, error)
+	GetRule(ctx context.Context, id int64) (models.AlertRule, error)
+	CreateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error)
+	UpdateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error)
+	DeleteRule(ctx context.Context, id int64) error
+}
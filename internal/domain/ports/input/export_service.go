@@ -0,0 +1,13 @@
+package input
+
+import (
+	"context"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+type ExportService interface {
+	// ExportAggregates проверяет запрос и построчно отдаёт агрегаты в fn.
+	ExportAggregates(ctx context.Context, q output.AggregateQuery, fn func(models.Aggregate) error) error
+}
@@ -0,0 +1,20 @@
+package output
+
+import (
+	"context"
+
+	"marketflow/internal/domain/models"
+)
+
+type AlertRuleRepository interface {
+	ListAlertRules(ctx context.Context) ([]models.AlertRule, error)
+	GetAlertRule(ctx context.Context, id int64) (models.AlertRule, error)
+	CreateAlertRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error)
+	UpdateAlertRule(ctx context.Context, rule models.AlertRule) error
+	DeleteAlertRule(ctx context.Context, id int64) error
+}
+
+// AlertNotifier доставляет сработавшее правило получателю.
+type AlertNotifier interface {
+	Notify(ctx context.Context, url string, event models.AlertEvent) error
+}
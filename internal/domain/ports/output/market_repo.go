@@ -1,7 +1,26 @@
 package output
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
 
 type MarketRepository interface {
-	InsertMarketData(exchange, symbol string, prices []float64, ts time.Time) error
+	InsertMarketData(ctx context.Context, agg models.Aggregate) error
+}
+
+// AggregateQuery выбирает агрегаты пары за [From, To), свёрнутые до Resolution.
+// Пустой Exchange — все биржи.
+type AggregateQuery struct {
+	Pair       string
+	Exchange   string
+	From       time.Time
+	To         time.Time
+	Resolution time.Duration
+}
+
+type AggregateReader interface {
+	QueryAggregates(ctx context.Context, q AggregateQuery, fn func(models.Aggregate) error) error
 }
@@ -2,6 +2,10 @@ package output
 
 import "marketflow/internal/domain/models"
 
+// PricePublisher получает каждый тик и каждый агрегат конвейера.
+// Сервис публикует в шину (services.PublisherBus), а шина раздаёт события
+// зарегистрированным приёмникам: консоль, файл, WebSocket, вебхук и т.д.
 type PricePublisher interface {
-	PublishRedis(key, value string, update models.PriceUpdate) error
+	PublishTick(update models.PriceUpdate) error
+	PublishAggregate(agg models.Aggregate) error
 }
@@ -0,0 +1,7 @@
+package output
+
+import "marketflow/internal/domain/models"
+
+type QuarantineRepository interface {
+	InsertQuarantinedTick(tick models.QuarantinedTick) error
+}
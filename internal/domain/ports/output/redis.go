@@ -12,4 +12,5 @@ type RedisClient interface {
 	ZAdd(ctx context.Context, key string, score float64, member interface{}) error
 	ZRangeByScore(ctx context.Context, key string, min, max string) ([]string, error)
 	ZRemRangeByScore(ctx context.Context, key string, min, max string) error
+	Publish(ctx context.Context, channel string, message interface{}) error
 }
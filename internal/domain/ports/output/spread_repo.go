@@ -0,0 +1,7 @@
+package output
+
+import "marketflow/internal/domain/models"
+
+type SpreadRepository interface {
+	InsertSpreadEvent(event models.SpreadEvent) error
+}
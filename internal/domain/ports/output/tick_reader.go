@@ -0,0 +1,13 @@
+package output
+
+import (
+	"context"
+
+	"marketflow/internal/domain/models"
+)
+
+// TickReader отдаёт исторические тики (файлы, записи сессий) по одному в fn.
+// Pair в тиках — символ как в источнике, нормализация на стороне сервиса.
+type TickReader interface {
+	ReadTicks(ctx context.Context, fn func(models.PriceUpdate) error) error
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/metrics"
+	"marketflow/pkg/tracing"
+)
+
+// Тики, пришедшие на границе окна, успевают долететь до Redis.
+const aggregationDelay = time.Second
+
+// retentionMargin — запас на случай, если агрегатор запустится позже end+aggregationDelay.
+const retentionMargin = 5 * time.Second
+
+// maxWindowLinks ограничивает ссылки спана окна на тики: при доле семплирования
+// 1 их были бы тысячи, для перехода к примерам хватает нескольких десятков.
+const maxWindowLinks = 64
+
+// retention — сколько держать тики в ZSet. Окно [start, end) читается только в
+// end+aggregationDelay, поэтому тики живут не меньше окна с задержкой и запасом,
+// даже если REDIS_TTL короче: иначе начало окна удалялось бы до агрегации.
+func (s *MarketServiceImpl) retention() time.Duration {
+	return max(s.redisTTL, s.window+aggregationDelay+retentionMargin)
+}
+
+// aggregator раз в окно (AGGREGATOR_WINDOW) считает статистику за завершённое окно
+// [start, end), выровненное по границе окна.
+func (s *MarketServiceImpl) aggregator() {
+	defer s.wg.Done()
+	s.logger.Info("Aggregator started", "window", s.window)
+
+	// Следующее окно считается от предыдущего, а не от текущего времени: иначе при
+	// aggregationDelay >= window (окно 1s) каждое второе окно пропускалось бы.
+	end := time.Now().Truncate(s.window).Add(s.window)
+	for ; ; end = end.Add(s.window) {
+		timer := time.NewTimer(time.Until(end.Add(aggregationDelay)))
+
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			s.logger.Info("Aggregator stopped")
+			return
+		case <-timer.C:
+			started := time.Now()
+			s.aggregateWindow(end.Add(-s.window), end)
+			metrics.AggregatorDuration.Observe(time.Since(started).Seconds())
+
+			if s.feedsFinishedBy(end) {
+				s.logger.Info("All exchange feeds finished, stopping")
+				s.Stop()
+				return
+			}
+		}
+	}
+}
+
+func (s *MarketServiceImpl) aggregateWindow(start, end time.Time) {
+	ctx, span := tracing.StartLinked(s.ctx, tracing.SpanAggregateWindow, s.takeTickLinks(start),
+		tracing.String("window.start", start.Format(time.RFC3339)),
+		tracing.String("window.size", s.window.String()),
+	)
+	defer span.End()
+
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.knownKeys))
+	for k := range s.knownKeys {
+		keys = append(keys, k)
+	}
+	s.mu.RUnlock()
+	span.SetAttributes(tracing.Int("keys", len(keys)))
+
+	for _, key := range keys {
+		s.aggregatePair(ctx, key, start, end)
+	}
+}
+
+// linkTick запоминает приём записанного тика для спана его окна. Окно тика —
+// по времени записи в ZSet, как его читает агрегатор.
+func (s *MarketServiceImpl) linkTick(update models.PriceUpdate, storedAt time.Time) {
+	if !tracing.Sampled(update.TraceParent) {
+		return
+	}
+	start := storedAt.Truncate(s.window).UnixNano()
+	s.mu.Lock()
+	if links := s.tickLinks[start]; len(links) < maxWindowLinks {
+		s.tickLinks[start] = append(links, update.TraceParent)
+	}
+	s.mu.Unlock()
+}
+
+// takeTickLinks забирает ссылки окна start; ссылки более ранних окон
+// (агрегатор их уже прошёл) выбрасываются.
+func (s *MarketServiceImpl) takeTickLinks(start time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	links := s.tickLinks[start.UnixNano()]
+	for t := range s.tickLinks {
+		if t <= start.UnixNano() {
+			delete(s.tickLinks, t)
+		}
+	}
+	return links
+}
+
+// aggregatePair считает агрегат одной пары за окно и пишет его в Postgres.
+func (s *MarketServiceImpl) aggregatePair(ctx context.Context, key string, start, end time.Time) {
+	ex, pair, ok := strings.Cut(key, ":")
+	if !ok {
+		return
+	}
+
+	ctx, span := tracing.Start(ctx, tracing.SpanAggregatePair,
+		tracing.String("exchange", ex),
+		tracing.String("pair", pair),
+	)
+	defer span.End()
+
+	min := fmt.Sprintf("%d", start.Unix())
+	max := fmt.Sprintf("(%d", end.Unix())
+
+	values, err := s.redisClient.ZRangeByScore(ctx, key, min, max)
+	if err != nil {
+		s.logger.Error("Aggregator Redis read error", "key", key, "error", err)
+		tracing.Fail(span, err)
+		return
+	}
+
+	var prices []models.Decimal
+	for _, v := range values {
+		price, err := models.ParseDecimal(priceFromMember(v))
+		if err != nil {
+			s.logger.Error("Parse error", "value", v, "error", err)
+			continue
+		}
+		prices = append(prices, price)
+	}
+
+	span.SetAttributes(tracing.Int("prices", len(prices)))
+
+	agg, ok := models.NewAggregate(ex, pair, prices, start)
+	if !ok {
+		s.logger.Info("No prices to write", "key", key)
+		return
+	}
+
+	if err := s.db.InsertMarketData(ctx, agg); err != nil {
+		s.logger.Error("DB insert failed", "exchange", ex, "pair", pair, "error", err)
+		tracing.Fail(span, err)
+		return
+	}
+	s.logger.Info("Wrote to DB", "exchange", ex, "pair", pair, "count", len(prices))
+
+	if err := s.pricePublisher.PublishAggregate(agg); err != nil {
+		s.logger.Error("Failed to publish aggregate", "error", err)
+	}
+}
+
+// Член ZSet должен быть уникальным, иначе одинаковые цены внутри окна схлопываются.
+// Поэтому к цене дописывается время получения: "43250.5:1700000000123456789".
+func zsetMember(update models.PriceUpdate, now time.Time) string {
+	return update.Price.String() + ":" + strconv.FormatInt(now.UnixNano(), 10)
+}
+
+func priceFromMember(member string) string {
+	price, _, _ := strings.Cut(member, ":")
+	return price
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+// zsetStore — ZSet в памяти: только то, что нужно агрегатору.
+type zsetStore struct {
+	output.RedisClient
+
+	mu      sync.Mutex
+	members map[string]map[string]float64
+}
+
+func newZSetStore() *zsetStore {
+	return &zsetStore{members: make(map[string]map[string]float64)}
+}
+
+func (z *zsetStore) ZAdd(ctx context.Context, key string, score float64, member interface{}) error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if z.members[key] == nil {
+		z.members[key] = make(map[string]float64)
+	}
+	z.members[key][member.(string)] = score
+	return nil
+}
+
+// ZRangeByScore понимает границы вида "10" и "(10", как Redis.
+func (z *zsetStore) ZRangeByScore(ctx context.Context, key, min, max string) ([]string, error) {
+	lo, loOpen := parseBound(min)
+	hi, hiOpen := parseBound(max)
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	var out []string
+	for member, score := range z.members[key] {
+		if score < lo || loOpen && score == lo || score > hi || hiOpen && score == hi {
+			continue
+		}
+		out = append(out, member)
+	}
+	return out, nil
+}
+
+func parseBound(s string) (float64, bool) {
+	open := len(s) > 0 && s[0] == '('
+	if open {
+		s = s[1:]
+	}
+	v, _ := strconv.ParseFloat(s, 64)
+	return v, open
+}
+
+// aggregateStore — market_data в памяти.
+type aggregateStore struct {
+	output.MarketRepository
+
+	mu         sync.Mutex
+	aggregates []models.Aggregate
+}
+
+func (r *aggregateStore) InsertMarketData(ctx context.Context, agg models.Aggregate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aggregates = append(r.aggregates, agg)
+	return nil
+}
+
+func (r *aggregateStore) stored() []models.Aggregate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.Aggregate(nil), r.aggregates...)
+}
+
+func newAggregatorService(ctx context.Context, window, ttl time.Duration) (*MarketServiceImpl, *zsetStore, *aggregateStore) {
+	redis, repo := newZSetStore(), &aggregateStore{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &MarketServiceImpl{
+		ctx:            ctx,
+		logger:         logger,
+		pricePublisher: NewPublisherBus(logger),
+		redisClient:    redis,
+		db:             repo,
+		redisTTL:       ttl,
+		window:         window,
+		knownKeys:      map[string]struct{}{"ex:BTCUSDT": {}},
+	}
+	return s, redis, repo
+}
+
+func (z *zsetStore) add(price string, at time.Time) {
+	update := models.PriceUpdate{Exchange: "ex", Pair: "BTCUSDT", Price: models.MustDecimal(price)}
+	z.ZAdd(context.Background(), "ex:BTCUSDT", float64(at.Unix()), zsetMember(update, at))
+}
+
+// Одинаковые цены внутри окна — разные члены ZSet, иначе они схлопнулись бы в один.
+func TestZSetMemberKeepsEqualPrices(t *testing.T) {
+	update := models.PriceUpdate{Price: models.MustDecimal("43250.5")}
+	now := time.Unix(1_700_000_000, 123)
+
+	first, second := zsetMember(update, now), zsetMember(update, now.Add(time.Nanosecond))
+	if first == second {
+		t.Fatalf("equal prices share member %q", first)
+	}
+	if got := priceFromMember(first); got != "43250.5" {
+		t.Errorf("priceFromMember(%q) = %q, want 43250.5", first, got)
+	}
+	// Члены, записанные до смены формата, — просто цена.
+	if got := priceFromMember("43250.5"); got != "43250.5" {
+		t.Errorf("priceFromMember(plain) = %q, want 43250.5", got)
+	}
+}
+
+// REDIS_TTL короче окна не должен удалять тики до агрегации.
+func TestRetentionCoversDelayedWindow(t *testing.T) {
+	for _, tt := range []struct {
+		window, ttl, want time.Duration
+	}{
+		{time.Minute, time.Minute, time.Minute + aggregationDelay + retentionMargin},
+		{time.Minute, time.Second, time.Minute + aggregationDelay + retentionMargin},
+		{time.Minute, time.Hour, time.Hour},
+	} {
+		s, _, _ := newAggregatorService(context.Background(), tt.window, tt.ttl)
+		if got := s.retention(); got != tt.want {
+			t.Errorf("retention(window %s, ttl %s) = %s, want %s", tt.window, tt.ttl, got, tt.want)
+		}
+	}
+}
+
+// Окно [start, end): тик на границе end относится к следующему окну, агрегат
+// помечается началом окна.
+func TestAggregateWindowIsHalfOpen(t *testing.T) {
+	s, redis, repo := newAggregatorService(context.Background(), time.Minute, time.Hour)
+	start := time.Unix(1_700_000_040, 0).Truncate(time.Minute)
+	end := start.Add(time.Minute)
+
+	redis.add("90", start.Add(-time.Second))
+	redis.add("100", start)
+	redis.add("100", start.Add(time.Millisecond))
+	redis.add("110", end.Add(-time.Second))
+	redis.add("120", end)
+
+	s.aggregateWindow(start, end)
+
+	got := repo.stored()
+	if len(got) != 1 {
+		t.Fatalf("aggregates = %d, want 1", len(got))
+	}
+	agg := got[0]
+	if !agg.Timestamp.Equal(start) || agg.Count != 3 || agg.Min.String() != "100" || agg.Max.String() != "110" {
+		t.Errorf("aggregate = %+v, want 3 ticks 100..110 at %s", agg, start.Format(time.TimeOnly))
+	}
+}
+
+// Окно 1s читается в end+1s, то есть уже в следующем окне. Следующее окно должно
+// отсчитываться от предыдущего, иначе каждое второе окно пропускается.
+func TestAggregatorDoesNotSkipWindows(t *testing.T) {
+	const window = time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, redis, repo := newAggregatorService(ctx, window, time.Minute)
+
+	first := time.Now().Truncate(window).Add(window)
+	for i := range 3 {
+		redis.add("100", first.Add(time.Duration(i)*window))
+	}
+	s.wg.Add(1)
+	go s.aggregator()
+
+	deadline := time.Now().Add(3*window + 5*time.Second)
+	for len(repo.stored()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	got := repo.stored()
+	if len(got) < 3 {
+		t.Fatalf("aggregates = %d, want 3", len(got))
+	}
+	for i, agg := range got[:3] {
+		if want := first.Add(time.Duration(i) * window); !agg.Timestamp.Equal(want) || agg.Count != 1 {
+			t.Errorf("aggregate %d = %d ticks at %s, want 1 at %s", i, agg.Count,
+				agg.Timestamp.Format(time.TimeOnly), want.Format(time.TimeOnly))
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+	"marketflow/pkg/metrics"
+)
+
+const (
+	alertCheckInterval  = time.Second
+	alertReloadInterval = 30 * time.Second
+	alertQueueSize      = 256              // общая очередь и очередь каждого вебхука
+	alertQuoteMaxAge    = 30 * time.Second // котировки старше не участвуют в правилах спреда
+	defaultCooldown     = time.Minute
+)
+
+type AlertDeliveryConfig struct {
+	MaxAttempts int           // попыток доставки одного события
+	Backoff     time.Duration // пауза перед второй попыткой, дальше удваивается
+	Hosts       []string      // разрешённые хосты вебхуков; пусто — любой
+}
+
+type pricePoint struct {
+	price float64
+	at    time.Time
+}
+
+// ruleState — состояние правила по одной бирже ("" для правил по паре в целом).
+type ruleState struct {
+	active  bool      // условие выполнено сейчас
+	firedAt time.Time // последнее срабатывание, для cooldown
+	history []pricePoint
+}
+
+// AlertEngine проверяет правила на потоке тиков и отправляет сработавшие в вебхуки.
+// Правило срабатывает при переходе условия из ложного в истинное и не чаще раза в cooldown;
+// повторные доставки одного срабатывания идут с одинаковым DedupKey.
+type AlertEngine struct {
+	repo     output.AlertRuleRepository
+	notifier output.AlertNotifier
+	delivery AlertDeliveryConfig
+	logger   *slog.Logger
+	queue    chan alertDelivery
+
+	// Настроенные биржи: устаревание проверяется и по тем, что ещё не прислали ни одного тика
+	exchanges []string
+	pairs     map[string]bool // TRACKED_PAIRS; правила по другим парам не принимаются
+	started   time.Time
+
+	mu       sync.Mutex
+	rules    []models.AlertRule
+	states   map[string]*ruleState           // ruleID:exchange -> state
+	quotes   map[string]map[string]quote     // pair -> exchange -> last quote
+	lastSeen map[string]map[string]time.Time // pair -> exchange -> last tick
+}
+
+type alertDelivery struct {
+	url   string
+	event models.AlertEvent
+}
+
+func NewAlertEngine(repo output.AlertRuleRepository, notifier output.AlertNotifier, delivery AlertDeliveryConfig, exchanges, tracked []string, logger *slog.Logger) *AlertEngine {
+	if delivery.MaxAttempts <= 0 {
+		delivery.MaxAttempts = 1
+	}
+	pairs := make(map[string]bool, len(tracked))
+	for _, symbol := range tracked {
+		if pair, ok := models.ParsePair(symbol); ok {
+			pairs[pair.Symbol()] = true
+		}
+	}
+	return &AlertEngine{
+		repo:      repo,
+		notifier:  notifier,
+		delivery:  delivery,
+		logger:    logger,
+		queue:     make(chan alertDelivery, alertQueueSize),
+		exchanges: exchanges,
+		pairs:     pairs,
+		states:    make(map[string]*ruleState),
+		quotes:    make(map[string]map[string]quote),
+		lastSeen:  make(map[string]map[string]time.Time),
+	}
+}
+
+// Start загружает правила и запускает проверку устаревания, перезагрузку и доставку.
+func (e *AlertEngine) Start(ctx context.Context) error {
+	if err := e.reload(ctx); err != nil {
+		return fmt.Errorf("load alert rules: %w", err)
+	}
+	e.mu.Lock()
+	e.started = time.Now()
+	e.mu.Unlock()
+
+	go e.deliver(ctx)
+	go func() {
+		check := time.NewTicker(alertCheckInterval)
+		reload := time.NewTicker(alertReloadInterval)
+		defer check.Stop()
+		defer reload.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-check.C:
+				e.checkStaleness(now)
+			case <-reload.C:
+				if err := e.reload(ctx); err != nil {
+					e.logger.Error("Failed to reload alert rules", "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (e *AlertEngine) reload(ctx context.Context) error {
+	rules, err := e.repo.ListAlertRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.rules = rules
+	e.mu.Unlock()
+	return nil
+}
+
+// PublishTick — движок подключается к шине публикации как приёмник тиков.
+func (e *AlertEngine) PublishTick(update models.PriceUpdate) error {
+	price := update.Price.Float64()
+	now := update.Timestamp
+
+	e.mu.Lock()
+	if e.quotes[update.Pair] == nil {
+		e.quotes[update.Pair] = make(map[string]quote)
+		e.lastSeen[update.Pair] = make(map[string]time.Time)
+	}
+	e.quotes[update.Pair][update.Exchange] = quote{price: update.Price, at: now}
+	e.lastSeen[update.Pair][update.Exchange] = now
+
+	var fired []alertDelivery
+	for _, rule := range e.rules {
+		if !rule.Enabled || rule.Pair != update.Pair || (rule.Exchange != "" && rule.Exchange != update.Exchange) {
+			continue
+		}
+
+		switch rule.Type {
+		case models.RuleThreshold:
+			state := e.state(rule.ID, update.Exchange)
+			above := update.Price.Cmp(rule.Price) > 0
+			crossed := (rule.Direction == models.DirectionAbove && above) || (rule.Direction == models.DirectionBelow && !above)
+			if d, ok := e.transition(rule, state, update.Exchange, crossed, price, now,
+				fmt.Sprintf("%s on %s is %s %s: %v", rule.Pair, update.Exchange, rule.Direction, rule.Price, update.Price)); ok {
+				fired = append(fired, d)
+			}
+
+		case models.RulePctChange:
+			state := e.state(rule.ID, update.Exchange)
+			window := time.Duration(rule.Window)
+			state.history = append(state.history, pricePoint{price: price, at: now})
+			for len(state.history) > 1 && now.Sub(state.history[0].at) > window {
+				state.history = state.history[1:]
+			}
+			first := state.history[0].price
+			change := 0.0
+			if first > 0 {
+				change = (price - first) / first * 100
+			}
+			if d, ok := e.transition(rule, state, update.Exchange, math.Abs(change) >= rule.Percent, change, now,
+				fmt.Sprintf("%s on %s moved %.2f%% in %s", rule.Pair, update.Exchange, change, window)); ok {
+				fired = append(fired, d)
+			}
+
+		case models.RuleSpread:
+			state := e.state(rule.ID, "")
+			low, high, lowEx, highEx := math.MaxFloat64, 0.0, "", ""
+			for ex, q := range e.quotes[rule.Pair] {
+				if now.Sub(q.at) > alertQuoteMaxAge {
+					continue
+				}
+				p := q.price.Float64()
+				if p < low {
+					low, lowEx = p, ex
+				}
+				if p > high {
+					high, highEx = p, ex
+				}
+			}
+			bps := 0.0
+			if lowEx != "" && highEx != "" && lowEx != highEx {
+				bps = (high - low) / ((high + low) / 2) * 10000
+			}
+			if d, ok := e.transition(rule, state, "", bps >= rule.SpreadBps, bps, now,
+				fmt.Sprintf("%s spread %.1f bps between %s and %s", rule.Pair, bps, lowEx, highEx)); ok {
+				fired = append(fired, d)
+			}
+
+		case models.RuleStaleness:
+			// Пришёл тик — условие устаревания снимается, проверка в checkStaleness
+			e.state(rule.ID, update.Exchange).active = false
+		}
+	}
+	e.mu.Unlock()
+
+	for _, d := range fired {
+		e.enqueue(d)
+	}
+	return nil
+}
+
+func (e *AlertEngine) PublishAggregate(models.Aggregate) error { return nil }
+
+func (e *AlertEngine) checkStaleness(now time.Time) {
+	e.mu.Lock()
+	var fired []alertDelivery
+	for _, rule := range e.rules {
+		if !rule.Enabled || rule.Type != models.RuleStaleness {
+			continue
+		}
+		maxAge := time.Duration(rule.MaxAge)
+		for ex, seen := range e.seen(rule.Pair) {
+			if rule.Exchange != "" && rule.Exchange != ex {
+				continue
+			}
+			age := now.Sub(seen)
+			if d, ok := e.transition(rule, e.state(rule.ID, ex), ex, age > maxAge, age.Seconds(), now,
+				fmt.Sprintf("no %s ticks from %s for %s", rule.Pair, ex, age.Round(time.Second))); ok {
+				fired = append(fired, d)
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	for _, d := range fired {
+		e.enqueue(d)
+	}
+}
+
+// seen возвращает время последнего тика по паре для каждой биржи. Настроенные биржи
+// без тиков считаются молчащими с запуска движка. Вызывается под e.mu.
+func (e *AlertEngine) seen(pair string) map[string]time.Time {
+	seen := make(map[string]time.Time, len(e.exchanges))
+	for _, ex := range e.exchanges {
+		seen[ex] = e.started
+	}
+	for ex, at := range e.lastSeen[pair] {
+		seen[ex] = at
+	}
+	return seen
+}
+
+// transition фиксирует новое значение условия и возвращает событие,
+// если условие только что стало истинным и cooldown прошёл. Вызывается под e.mu.
+func (e *AlertEngine) transition(rule models.AlertRule, state *ruleState, exchange string, active bool, value float64, now time.Time, message string) (alertDelivery, bool) {
+	wasActive := state.active
+	state.active = active
+	if !active || wasActive {
+		return alertDelivery{}, false
+	}
+
+	cooldown := time.Duration(rule.Cooldown)
+	if cooldown == 0 {
+		cooldown = defaultCooldown
+	}
+	if !state.firedAt.IsZero() && now.Sub(state.firedAt) < cooldown {
+		return alertDelivery{}, false
+	}
+	state.firedAt = now
+
+	return alertDelivery{
+		url: rule.WebhookURL,
+		event: models.AlertEvent{
+			RuleID:    rule.ID,
+			RuleName:  rule.Name,
+			Type:      rule.Type,
+			Pair:      rule.Pair,
+			Exchange:  exchange,
+			Message:   message,
+			Value:     value,
+			DedupKey:  strconv.FormatInt(rule.ID, 10) + ":" + exchange + ":" + strconv.FormatInt(now.UnixNano(), 10),
+			Timestamp: now,
+		},
+	}, true
+}
+
+func (e *AlertEngine) state(ruleID int64, exchange string) *ruleState {
+	key := strconv.FormatInt(ruleID, 10) + ":" + exchange
+	state, ok := e.states[key]
+	if !ok {
+		state = &ruleState{}
+		e.states[key] = state
+	}
+	return state
+}
+
+func (e *AlertEngine) enqueue(d alertDelivery) {
+	e.logger.Info("Alert fired", "rule", d.event.RuleName, "pair", d.event.Pair, "exchange", d.event.Exchange, "message", d.event.Message)
+	select {
+	case e.queue <- d:
+	default:
+		metrics.AlertsDropped.Inc()
+		e.logger.Warn("Alert queue full, dropping alert", "rule", d.event.RuleName)
+	}
+}
+
+// deliver раскладывает события по вебхукам. У каждого адреса своя очередь и горутина,
+// поэтому недоступный вебхук с его повторами не задерживает доставку на остальные.
+// Адресов не больше, чем правил, и горутины живут до остановки движка.
+func (e *AlertEngine) deliver(ctx context.Context) {
+	endpoints := make(map[string]chan alertDelivery)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-e.queue:
+			queue, ok := endpoints[d.url]
+			if !ok {
+				queue = make(chan alertDelivery, alertQueueSize)
+				endpoints[d.url] = queue
+				go e.deliverTo(ctx, queue)
+			}
+			select {
+			case queue <- d:
+			default:
+				metrics.AlertsDropped.Inc()
+				e.logger.Warn("Webhook queue full, dropping alert", "rule", d.event.RuleName)
+			}
+		}
+	}
+}
+
+// deliverTo доставляет события одного вебхука по порядку.
+func (e *AlertEngine) deliverTo(ctx context.Context, queue <-chan alertDelivery) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-queue:
+			e.send(ctx, d)
+		}
+	}
+}
+
+func (e *AlertEngine) send(ctx context.Context, d alertDelivery) {
+	backoff := e.delivery.Backoff
+	for attempt := 1; ; attempt++ {
+		err := e.notifier.Notify(ctx, d.url, d.event)
+		if err == nil {
+			metrics.AlertsDelivered.Inc()
+			return
+		}
+		if attempt >= e.delivery.MaxAttempts {
+			metrics.AlertsFailed.Inc()
+			e.logger.Error("Alert delivery failed", "rule", d.event.RuleName, "attempts", attempt, "error", err)
+			return
+		}
+
+		e.logger.Warn("Alert delivery failed, retrying", "rule", d.event.RuleName, "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (e *AlertEngine) ListRules(ctx context.Context) ([]models.AlertRule, error) {
+	return e.repo.ListAlertRules(ctx)
+}
+
+func (e *AlertEngine) GetRule(ctx context.Context, id int64) (models.AlertRule, error) {
+	return e.repo.GetAlertRule(ctx, id)
+}
+
+func (e *AlertEngine) CreateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	rule, err := e.prepare(rule)
+	if err != nil {
+		return rule, err
+	}
+	created, err := e.repo.CreateAlertRule(ctx, rule)
+	if err != nil {
+		return created, err
+	}
+	return created, e.reload(ctx)
+}
+
+func (e *AlertEngine) UpdateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	rule, err := e.prepare(rule)
+	if err != nil {
+		return rule, err
+	}
+	if err := e.repo.UpdateAlertRule(ctx, rule); err != nil {
+		return rule, err
+	}
+	e.resetStates(rule.ID)
+	return rule, e.reload(ctx)
+}
+
+// prepare нормализует правило и проверяет его, включая пару и хост вебхука.
+func (e *AlertEngine) prepare(rule models.AlertRule) (models.AlertRule, error) {
+	rule = rule.Normalize()
+	// Пара в том же виде, что и в тиках: "btc/usdt" и "BTC-USDT" — это BTCUSDT
+	if pair, ok := models.ParsePair(rule.Pair); ok {
+		rule.Pair = pair.Symbol()
+	} else if rule.Pair != "" {
+		return rule, fmt.Errorf("%w: symbol %q is not a trading pair", models.ErrInvalidRule, rule.Pair)
+	}
+	if err := rule.Validate(); err != nil {
+		return rule, fmt.Errorf("%w: %v", models.ErrInvalidRule, err)
+	}
+	if len(e.pairs) > 0 && !e.pairs[rule.Pair] {
+		return rule, fmt.Errorf("%w: symbol %s is not in TRACKED_PAIRS", models.ErrInvalidRule, rule.Pair)
+	}
+	if len(e.delivery.Hosts) > 0 {
+		host, _ := rule.WebhookHost()
+		if !slices.Contains(e.delivery.Hosts, host) {
+			return rule, fmt.Errorf("%w: webhook host %q is not in ALERT_WEBHOOK_HOSTS", models.ErrInvalidRule, host)
+		}
+	}
+	return rule, nil
+}
+
+func (e *AlertEngine) DeleteRule(ctx context.Context, id int64) error {
+	if err := e.repo.DeleteAlertRule(ctx, id); err != nil {
+		return err
+	}
+	e.resetStates(id)
+	return e.reload(ctx)
+}
+
+func (e *AlertEngine) resetStates(ruleID int64) {
+	prefix := strconv.FormatInt(ruleID, 10) + ":"
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for key := range e.states {
+		if strings.HasPrefix(key, prefix) {
+			delete(e.states, key)
+		}
+	}
+}
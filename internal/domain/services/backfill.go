@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+// BackfillStats — итог загрузки истории.
+type BackfillStats struct {
+	Read     int // прочитано тиков
+	Unknown  int // неизвестный символ
+	Rejected int // отклонено валидатором
+	Late     int // пришли после того, как окно уже записано
+	Windows  int // записано агрегатов
+	Kept     int // окна на краю источника, уже лежащие в базе: не перезаписаны неполными
+}
+
+type backfillBucket struct {
+	exchange string
+	pair     string
+	start    time.Time
+	prices   []models.Decimal
+}
+
+// Backfiller прогоняет исторические тики через ту же нормализацию, валидацию
+// и агрегацию по окнам, что и живой конвейер, и пишет агрегаты в market_data.
+// Запись идемпотентна: повторный прогон того же файла перезаписывает те же окна.
+//
+// Окна на краях источника — до его первого тика и после последнего — могут быть
+// покрыты не целиком: их тики лежат и в соседнем файле. Такое окно пишется, только
+// если его ещё нет в базе, иначе полный агрегат затёрся бы частью тиков.
+type Backfiller struct {
+	repo      output.MarketRepository
+	existing  output.AggregateReader
+	validator *TickValidator
+	symbols   *SymbolRegistry
+	window    time.Duration
+	logger    *slog.Logger
+}
+
+func NewBackfiller(repo output.MarketRepository, existing output.AggregateReader, validator *TickValidator, symbols *SymbolRegistry, window time.Duration, logger *slog.Logger) *Backfiller {
+	return &Backfiller{
+		repo:      repo,
+		existing:  existing,
+		validator: validator,
+		symbols:   symbols,
+		window:    window,
+		logger:    logger,
+	}
+}
+
+// Run читает тики из sources подряд, как один поток: окно, разрезанное между
+// соседними файлами, собирается целиком. Тики могут идти не строго по порядку:
+// окно закрывается, когда источник ушёл на одно окно дальше его конца.
+func (b *Backfiller) Run(ctx context.Context, sources ...output.TickReader) (BackfillStats, error) {
+	sources, err := byFirstTick(ctx, sources)
+	if err != nil {
+		return BackfillStats{}, err
+	}
+
+	var (
+		stats     BackfillStats
+		first     time.Time // самый ранний тик: покрытие источника — [first, watermark]
+		watermark time.Time
+		buckets   = make(map[string]*backfillBucket)
+		flushed   = make(map[string]time.Time) // exchange:pair -> начало последнего записанного окна
+	)
+
+	flush := func(all bool) error {
+		var ready []*backfillBucket
+		for key, bucket := range buckets {
+			if all || !bucket.start.Add(2*b.window).After(watermark) {
+				ready = append(ready, bucket)
+				delete(buckets, key)
+			}
+		}
+		sort.Slice(ready, func(i, j int) bool { return ready[i].start.Before(ready[j].start) })
+
+		for _, bucket := range ready {
+			agg, ok := models.NewAggregate(bucket.exchange, bucket.pair, bucket.prices, bucket.start)
+			if !ok {
+				continue
+			}
+			if bucket.start.Before(first) || watermark.Before(bucket.start.Add(b.window)) {
+				stored, err := b.stored(ctx, agg)
+				if err != nil {
+					return fmt.Errorf("read %s %s window %s: %w", bucket.exchange, bucket.pair, bucket.start.Format(time.RFC3339), err)
+				}
+				if stored {
+					flushed[bucket.exchange+":"+bucket.pair] = bucket.start
+					stats.Kept++
+					continue
+				}
+			}
+			if err := b.repo.InsertMarketData(ctx, agg); err != nil {
+				return fmt.Errorf("write %s %s window %s: %w", bucket.exchange, bucket.pair, bucket.start.Format(time.RFC3339), err)
+			}
+			flushed[bucket.exchange+":"+bucket.pair] = bucket.start
+			stats.Windows++
+		}
+		return nil
+	}
+
+	read := func(update models.PriceUpdate) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		stats.Read++
+
+		pair, ok := b.symbols.Normalize(update.Exchange, update.Pair)
+		if !ok {
+			stats.Unknown++
+			return nil
+		}
+		update.Pair = pair.Symbol()
+
+		if _, ok := b.validator.ValidateHistorical(update); !ok {
+			stats.Rejected++
+			return nil
+		}
+
+		start := update.Timestamp.Truncate(b.window)
+		series := update.Exchange + ":" + update.Pair
+		if last, ok := flushed[series]; ok && !start.After(last) {
+			stats.Late++
+			return nil
+		}
+
+		key := series + ":" + start.Format(time.RFC3339Nano)
+		bucket, ok := buckets[key]
+		if !ok {
+			bucket = &backfillBucket{exchange: update.Exchange, pair: update.Pair, start: start}
+			buckets[key] = bucket
+		}
+		bucket.prices = append(bucket.prices, update.Price)
+
+		if first.IsZero() || update.Timestamp.Before(first) {
+			first = update.Timestamp
+		}
+		if update.Timestamp.After(watermark) {
+			watermark = update.Timestamp
+			return flush(false)
+		}
+		return nil
+	}
+	for _, source := range sources {
+		if err := source.ReadTicks(ctx, read); err != nil {
+			return stats, err
+		}
+	}
+
+	if err := flush(true); err != nil {
+		return stats, err
+	}
+
+	b.logger.Info("Backfill finished",
+		"read", stats.Read,
+		"unknown", stats.Unknown,
+		"rejected", stats.Rejected,
+		"late", stats.Late,
+		"windows", stats.Windows,
+		"kept", stats.Kept,
+	)
+	return stats, nil
+}
+
+// errFirstTick прерывает чтение источника после первого тика.
+var errFirstTick = errors.New("first tick read")
+
+// byFirstTick упорядочивает источники по времени первого тика. Watermark общий
+// для всех источников: файл, переданный раньше более старого, закрыл бы окна
+// того файла до его чтения, и все его тики ушли бы в Late. Пустые источники — в конце.
+func byFirstTick(ctx context.Context, sources []output.TickReader) ([]output.TickReader, error) {
+	firsts := make([]time.Time, len(sources))
+	for i, source := range sources {
+		err := source.ReadTicks(ctx, func(update models.PriceUpdate) error {
+			firsts[i] = update.Timestamp
+			return errFirstTick
+		})
+		if err != nil && !errors.Is(err, errFirstTick) {
+			return nil, err
+		}
+	}
+
+	order := make([]int, len(sources))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := firsts[order[i]], firsts[order[j]]
+		return !a.IsZero() && (b.IsZero() || a.Before(b))
+	})
+	sorted := make([]output.TickReader, len(sources))
+	for i, k := range order {
+		sorted[i] = sources[k]
+	}
+	return sorted, nil
+}
+
+// stored проверяет, есть ли в market_data агрегат за окно agg.
+func (b *Backfiller) stored(ctx context.Context, agg models.Aggregate) (bool, error) {
+	found := false
+	err := b.existing.QueryAggregates(ctx, output.AggregateQuery{
+		Pair:       agg.Pair,
+		Exchange:   agg.Exchange,
+		From:       agg.Timestamp,
+		To:         agg.Timestamp.Add(b.window),
+		Resolution: b.window,
+	}, func(models.Aggregate) error {
+		found = true
+		return nil
+	})
+	return found, err
+}
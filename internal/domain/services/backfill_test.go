@@ -0,0 +1,167 @@
+package services_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/output/memory"
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/services"
+)
+
+var backfillT0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func historical(symbol, price string, offset time.Duration) models.PriceUpdate {
+	return models.PriceUpdate{Exchange: "ex", Pair: symbol, Price: models.MustDecimal(price), Timestamp: backfillT0.Add(offset)}
+}
+
+func newBackfiller(repo *memory.MarketRepo) *services.Backfiller {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return services.NewBackfiller(repo, repo,
+		services.NewTickValidator(services.ValidationConfig{Default: services.ValidationRules{RequirePositive: true}}, repo, logger),
+		services.NewSymbolRegistry(services.SymbolConfig{Tracked: []string{"BTCUSDT"}}, logger),
+		time.Minute, logger)
+}
+
+// counts возвращает число тиков в записанных окнах по минутам от backfillT0.
+func counts(repo *memory.MarketRepo) map[int]int {
+	got := make(map[int]int)
+	for _, agg := range repo.Aggregates() {
+		got[int(agg.Timestamp.Sub(backfillT0)/time.Minute)] = agg.Count
+	}
+	return got
+}
+
+func TestBackfillWatermark(t *testing.T) {
+	repo := memory.NewMarketRepo()
+	stats, err := newBackfiller(repo).Run(context.Background(), memory.NewTickReader(
+		historical("BTCUSDT", "100", 10*time.Second),
+		historical("BTCUSDT", "102", 50*time.Second),
+		historical("BTCUSDT", "104", 70*time.Second),
+		historical("BTCUSDT", "101", 55*time.Second), // не по порядку, окно ещё открыто
+		historical("XRPUSDT", "0.5", 80*time.Second),
+		historical("BTCUSDT", "-1", 90*time.Second),
+		historical("BTCUSDT", "106", 125*time.Second), // окно 12:00 закрывается
+		historical("BTCUSDT", "99", 59*time.Second),   // опоздал
+		historical("BTCUSDT", "108", 180*time.Second),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := services.BackfillStats{Read: 9, Unknown: 1, Rejected: 1, Late: 1, Windows: 4}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+	if got := counts(repo); !reflect.DeepEqual(got, map[int]int{0: 3, 1: 1, 2: 1, 3: 1}) {
+		t.Errorf("window tick counts = %v", got)
+	}
+	first := repo.Aggregates()[0]
+	if first.Min.String() != "100" || first.Max.String() != "102" || first.Average.String() != "101" {
+		t.Errorf("first window = %+v", first)
+	}
+}
+
+func TestBackfillIsIdempotent(t *testing.T) {
+	repo := memory.NewMarketRepo()
+	source := memory.NewTickReader(
+		historical("BTCUSDT", "100", 10*time.Second),
+		historical("BTCUSDT", "101", 70*time.Second),
+		historical("BTCUSDT", "102", 130*time.Second),
+		historical("BTCUSDT", "103", 190*time.Second),
+	)
+	if _, err := newBackfiller(repo).Run(context.Background(), source); err != nil {
+		t.Fatal(err)
+	}
+	before := repo.Aggregates()
+
+	stats, err := newBackfiller(repo).Run(context.Background(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repo.Aggregates(), before) {
+		t.Errorf("second run changed market_data:\n%+v\n%+v", repo.Aggregates(), before)
+	}
+	// Крайние окна уже записаны и не переписываются, внутренние — upsert теми же данными
+	if stats.Windows != 2 || stats.Kept != 2 {
+		t.Errorf("second run stats = %+v, want 2 written and 2 kept", stats)
+	}
+}
+
+// Окно 12:01 разрезано между двумя файлами.
+func TestBackfillWindowSplitAcrossFiles(t *testing.T) {
+	first := memory.NewTickReader(
+		historical("BTCUSDT", "100", 10*time.Second),
+		historical("BTCUSDT", "101", 65*time.Second),
+		historical("BTCUSDT", "102", 80*time.Second),
+	)
+	second := memory.NewTickReader(
+		historical("BTCUSDT", "103", 100*time.Second),
+		historical("BTCUSDT", "104", 130*time.Second),
+	)
+
+	repo := memory.NewMarketRepo()
+	if _, err := newBackfiller(repo).Run(context.Background(), first, second); err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]int{0: 1, 1: 3, 2: 1}
+	if got := counts(repo); !reflect.DeepEqual(got, want) {
+		t.Fatalf("window tick counts = %v, want %v", got, want)
+	}
+
+	// Повтор одного из файлов не затирает собранное окно его частью
+	for _, source := range []*memory.TickReader{second, first} {
+		stats, err := newBackfiller(repo).Run(context.Background(), source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Kept == 0 {
+			t.Errorf("stats = %+v, want the split window kept", stats)
+		}
+		if got := counts(repo); !reflect.DeepEqual(got, want) {
+			t.Errorf("after re-running one file window tick counts = %v, want %v", got, want)
+		}
+	}
+
+	// Файл, загруженный отдельно первым, даёт неполное окно, но совместный прогон его чинит
+	repo = memory.NewMarketRepo()
+	if _, err := newBackfiller(repo).Run(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newBackfiller(repo).Run(context.Background(), first, second); err != nil {
+		t.Fatal(err)
+	}
+	if got := counts(repo); !reflect.DeepEqual(got, want) {
+		t.Errorf("window tick counts = %v, want %v", got, want)
+	}
+}
+
+// Файлы переданы в обратном порядке: без сортировки второй закрыл бы окна первого.
+func TestBackfillFilesOutOfOrder(t *testing.T) {
+	older := memory.NewTickReader(
+		historical("BTCUSDT", "100", 10*time.Second),
+		historical("BTCUSDT", "101", 65*time.Second),
+		historical("BTCUSDT", "102", 80*time.Second),
+	)
+	newer := memory.NewTickReader(
+		historical("BTCUSDT", "103", 100*time.Second),
+		historical("BTCUSDT", "104", 130*time.Second),
+		historical("BTCUSDT", "105", 250*time.Second),
+	)
+
+	repo := memory.NewMarketRepo()
+	stats, err := newBackfiller(repo).Run(context.Background(), newer, memory.NewTickReader(), older)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Late != 0 || stats.Windows != 4 {
+		t.Errorf("stats = %+v, want 4 windows and no late ticks", stats)
+	}
+	if got, want := counts(repo), map[int]int{0: 1, 1: 3, 2: 1, 4: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("window tick counts = %v, want %v", got, want)
+	}
+}
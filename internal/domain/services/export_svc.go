@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+// Экспорт читает market_data потоком, но запрос всё равно сканирует весь диапазон,
+// поэтому from обязателен, а диапазон ограничен числом бакетов: 1m — почти два года,
+// 1h — больше ста лет.
+const (
+	maxExportResolution = 24 * time.Hour
+	maxExportBuckets    = 1_000_000
+)
+
+type ExportService struct {
+	repo   output.AggregateReader
+	window time.Duration
+}
+
+func NewExportService(repo output.AggregateReader, window time.Duration) *ExportService {
+	return &ExportService{repo: repo, window: window}
+}
+
+func (s *ExportService) ExportAggregates(ctx context.Context, q output.AggregateQuery, fn func(models.Aggregate) error) error {
+	q, err := s.Validate(q)
+	if err != nil {
+		return err
+	}
+	return s.repo.QueryAggregates(ctx, q, fn)
+}
+
+// Validate проверяет запрос и подставляет умолчания: resolution — окно агрегатора, to — сейчас.
+func (s *ExportService) Validate(q output.AggregateQuery) (output.AggregateQuery, error) {
+	if q.Pair == "" {
+		return q, fmt.Errorf("%w: pair is required", models.ErrInvalidQuery)
+	}
+	if q.Resolution == 0 {
+		q.Resolution = s.window
+	}
+	if q.Resolution < s.window || q.Resolution%s.window != 0 || q.Resolution > maxExportResolution {
+		return q, fmt.Errorf("%w: resolution must be a multiple of %s up to %s", models.ErrInvalidQuery, s.window, maxExportResolution)
+	}
+	if q.From.IsZero() {
+		return q, fmt.Errorf("%w: from is required", models.ErrInvalidQuery)
+	}
+	if q.To.IsZero() {
+		q.To = time.Now()
+	}
+	if !q.From.Before(q.To) {
+		return q, fmt.Errorf("%w: from must be before to", models.ErrInvalidQuery)
+	}
+	if q.To.Sub(q.From)/q.Resolution > maxExportBuckets {
+		return q, fmt.Errorf("%w: range covers more than %d buckets of %s, narrow it or raise resolution",
+			models.ErrInvalidQuery, maxExportBuckets, q.Resolution)
+	}
+	return q, nil
+}
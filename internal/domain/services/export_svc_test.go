@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+	"marketflow/internal/domain/services"
+)
+
+// noAggregates — market_data без строк.
+type noAggregates struct{}
+
+func (noAggregates) QueryAggregates(context.Context, output.AggregateQuery, func(models.Aggregate) error) error {
+	return nil
+}
+
+func TestExportRangeIsBounded(t *testing.T) {
+	svc := services.NewExportService(noAggregates{}, time.Minute)
+	now := time.Now()
+	noop := func(models.Aggregate) error { return nil }
+
+	for name, q := range map[string]output.AggregateQuery{
+		"no from":    {Pair: "BTCUSDT"},
+		"too long":   {Pair: "BTCUSDT", From: now.AddDate(-5, 0, 0)},
+		"from after": {Pair: "BTCUSDT", From: now.Add(time.Hour), To: now},
+		"bad window": {Pair: "BTCUSDT", From: now.Add(-time.Hour), Resolution: 90 * time.Second},
+		"below":      {Pair: "BTCUSDT", From: now.Add(-time.Hour), Resolution: 30 * time.Second},
+		"above cap":  {Pair: "BTCUSDT", From: now.AddDate(-1, 0, 0), Resolution: 48 * time.Hour},
+		"empty pair": {From: now.Add(-time.Hour)},
+	} {
+		if err := svc.ExportAggregates(context.Background(), q, noop); !errors.Is(err, models.ErrInvalidQuery) {
+			t.Errorf("%s: err = %v, want ErrInvalidQuery", name, err)
+		}
+	}
+
+	q := output.AggregateQuery{Pair: "BTCUSDT", From: now.AddDate(-5, 0, 0), Resolution: time.Hour}
+	if err := svc.ExportAggregates(context.Background(), q, noop); err != nil {
+		t.Errorf("5 years of 1h candles: %v", err)
+	}
+	q = output.AggregateQuery{Pair: "BTCUSDT", From: now.AddDate(-5, 0, 0), Resolution: 24 * time.Hour}
+	if err := svc.ExportAggregates(context.Background(), q, noop); err != nil {
+		t.Errorf("1d candles: %v", err)
+	}
+}
+
+// Предел — ровно миллион бакетов; один лишний уже отклоняется.
+func TestExportBucketLimit(t *testing.T) {
+	svc := services.NewExportService(noAggregates{}, time.Minute)
+	to := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	q := output.AggregateQuery{Pair: "BTCUSDT", From: to.Add(-1_000_000 * time.Minute), To: to}
+	if _, err := svc.Validate(q); err != nil {
+		t.Errorf("1000000 buckets: %v", err)
+	}
+	q.From = q.From.Add(-time.Minute)
+	if _, err := svc.Validate(q); !errors.Is(err, models.ErrInvalidQuery) {
+		t.Errorf("1000001 buckets: err = %v, want ErrInvalidQuery", err)
+	}
+}
+
+// Validate подставляет окно агрегатора и текущее время.
+func TestExportDefaults(t *testing.T) {
+	svc := services.NewExportService(noAggregates{}, 5*time.Minute)
+	q, err := svc.Validate(output.AggregateQuery{Pair: "BTCUSDT", From: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Resolution != 5*time.Minute {
+		t.Errorf("resolution = %s, want 5m", q.Resolution)
+	}
+	if time.Since(q.To) > time.Minute {
+		t.Errorf("to = %s, want now", q.To)
+	}
+}
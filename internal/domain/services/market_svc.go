@@ -2,15 +2,16 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
 
 	"marketflow/internal/domain/models"
 	"marketflow/internal/domain/ports/output"
+	"marketflow/pkg/metrics"
+	"marketflow/pkg/tracing"
 )
 
 type MarketServiceImpl struct {
@@ -20,13 +21,27 @@ type MarketServiceImpl struct {
 	dataChan       chan models.PriceUpdate
 	wg             sync.WaitGroup
 	ctx            context.Context
+	cancel         context.CancelFunc
 	logger         *slog.Logger
 	redisTTL       time.Duration
 	redisClient    output.RedisClient
 	db             output.MarketRepository
 	reconnectCh    chan models.ExchangeConfig
 	knownKeys      map[string]struct{}
+	tickLinks      map[int64][]string // начало окна (unix ns) -> traceparent записанных тиков, под mu
 	mu             sync.RWMutex
+	window         time.Duration
+	spreadMonitor  *SpreadMonitor
+	validator      *TickValidator
+	symbols        *SymbolRegistry
+	staleness      *StalenessMonitor
+	backpressure   models.BackpressureConfig
+
+	// Биржи, поток которых ещё не закончился (см. models.ErrFeedFinished), и момент,
+	// когда закончился последний. Под mu.
+	feeds       int
+	feedsDoneAt time.Time
+	queues      map[string]*tickQueue // очереди бирж по имени; сборщик отмечает в них записанные тики, под mu
 }
 
 // NEW METHOD - заменяет NewMarketDataProcessor
@@ -39,19 +54,36 @@ func NewMarketService(
 	redisClient output.RedisClient,
 	db output.MarketRepository,
 	redisTTL time.Duration,
+	window time.Duration,
+	spreadMonitor *SpreadMonitor,
+	validator *TickValidator,
+	symbols *SymbolRegistry,
+	staleness *StalenessMonitor,
+	backpressure models.BackpressureConfig,
 ) *MarketServiceImpl {
+	ctx, cancel := context.WithCancel(ctx)
 	return &MarketServiceImpl{
 		exchanges:      exchanges,
 		exchangeClient: exchangeClient,
 		pricePublisher: pricePublisher,
-		dataChan:       make(chan models.PriceUpdate, 1000),
+		dataChan:       make(chan models.PriceUpdate), // буферы — в очередях бирж
 		ctx:            ctx,
+		cancel:         cancel,
 		logger:         logger,
 		redisClient:    redisClient,
 		db:             db,
 		redisTTL:       redisTTL,
 		reconnectCh:    make(chan models.ExchangeConfig, 10),
 		knownKeys:      make(map[string]struct{}),
+		tickLinks:      make(map[int64][]string),
+		window:         window,
+		spreadMonitor:  spreadMonitor,
+		validator:      validator,
+		symbols:        symbols,
+		staleness:      staleness,
+		backpressure:   backpressure,
+		feeds:          len(exchanges),
+		queues:         make(map[string]*tickQueue),
 	}
 }
 
@@ -59,7 +91,21 @@ func NewMarketService(
 func (s *MarketServiceImpl) Start(ctx context.Context) error {
 	s.logger.Info("Starting MarketFlow Live Mode")
 
+	go s.staleness.Run(s.ctx)
+
+	// Карантин и монитор спредов дописывают очереди в базу до возврата из Start
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		s.validator.Run(s.ctx)
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.spreadMonitor.Run(s.ctx)
+	}()
+
 	// Start data collector (Fan-In pattern)
+	s.wg.Add(2)
 	go s.dataCollector()
 
 	go s.aggregator()
@@ -79,14 +125,17 @@ func (s *MarketServiceImpl) Start(ctx context.Context) error {
 	return nil
 }
 
+// Stop отменяет контекст сервиса и закрывает соединения с биржами, чтобы
+// слушатели не ждали таймаута чтения. Start вернётся, когда все горутины выйдут.
+// Каналы не закрываются: в них ещё могут писать слушатели.
 func (s *MarketServiceImpl) Stop() error {
 	s.logger.Info("Stopping MarketFlow")
-	close(s.dataChan)
-	close(s.reconnectCh)
-	return nil
+	s.cancel()
+	return s.exchangeClient.Close()
 }
 
 func (s *MarketServiceImpl) dataCollector() {
+	defer s.wg.Done()
 	s.logger.Info("Starting data collector")
 
 	for {
@@ -101,12 +150,40 @@ func (s *MarketServiceImpl) dataCollector() {
 				return
 			}
 
+			pair, ok := s.symbols.Normalize(update.Exchange, update.Pair)
+			if !ok {
+				metrics.TicksDropped.WithLabelValues(update.Exchange, "", metrics.DropUnknownSymbol).Inc()
+				s.tickDone(update.Exchange)
+				continue
+			}
+			update.Pair = pair.Symbol()
+			metrics.TicksParsed.WithLabelValues(update.Exchange, update.Pair).Inc()
+
+			if reason, ok := s.validator.Validate(update); !ok {
+				metrics.TicksDropped.WithLabelValues(update.Exchange, update.Pair, reason).Inc()
+				s.tickDone(update.Exchange)
+				continue
+			}
+
+			s.spreadMonitor.Observe(update)
+
 			key := update.Exchange + ":" + update.Pair
-			score := float64(time.Now().Unix())
+			now := time.Now()
+			score := float64(now.Unix())
 
 			// Сохраняем цену в Redis (ZSet)
-			if err := s.redisClient.ZAdd(s.ctx, key, score, update.Price); err != nil {
-				s.logger.Error("Failed to write to Redis ZSet", "error", err)
+			if err := s.storeTick(update, key, score, now); err != nil {
+				s.logger.Error("Failed to write to Redis ZSet", "exchange", update.Exchange, "pair", update.Pair, "error", err)
+			} else {
+				update.ProcessedAt = time.Now()
+				metrics.TicksStored.WithLabelValues(update.Exchange, update.Pair).Inc()
+				s.staleness.Observe(update)
+				s.linkTick(update, now)
+			}
+			s.tickDone(update.Exchange)
+
+			if err := s.pricePublisher.PublishTick(update); err != nil {
+				s.logger.Error("Failed to publish tick", "error", err)
 			}
 
 			// Добавляем ключ в список известных для агрегатора
@@ -114,88 +191,67 @@ func (s *MarketServiceImpl) dataCollector() {
 			s.knownKeys[key] = struct{}{}
 			s.mu.Unlock()
 
-			// Удаляем устаревшие данные старше 60 секунд
-			cutoff := time.Now().Add(-1 * time.Minute).Unix()
-			if err := s.redisClient.ZRemRangeByScore(s.ctx, key, "0", fmt.Sprintf("%d", cutoff)); err != nil {
+			// Удаляем данные старше срока хранения; граница не включается
+			cutoff := now.Add(-s.retention()).Unix()
+			if err := s.redisClient.ZRemRangeByScore(s.ctx, key, "0", fmt.Sprintf("(%d", cutoff)); err != nil {
 				s.logger.Error("Failed to clean old Redis data", "error", err)
 			}
 		}
 	}
 }
 
-func (s *MarketServiceImpl) aggregator() {
-	s.logger.Info("Aggregator started")
-	ticker := time.NewTicker(10 * time.Second) // временно для отладки
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-s.ctx.Done():
-			s.logger.Info("Aggregator stopped")
-			return
-		case <-ticker.C:
-			s.mu.RLock()
-			keys := make([]string, 0, len(s.knownKeys))
-			for k := range s.knownKeys {
-				keys = append(keys, k)
-			}
-			s.mu.RUnlock()
-
-			now := time.Now().Unix()
-			min := fmt.Sprintf("%d", now-60)
-			max := fmt.Sprintf("%d", now)
-
-			for _, key := range keys {
-				parts := strings.Split(key, ":")
-				if len(parts) != 2 {
-					continue
-				}
-				ex := parts[0]
-				pair := parts[1]
-
-				values, err := s.redisClient.ZRangeByScore(s.ctx, key, min, max)
-				if err != nil {
-					s.logger.Error("Aggregator Redis read error", "key", key, "error", err)
-					continue
-				}
-
-				var prices []float64
-				for _, v := range values {
-					price, err := strconv.ParseFloat(v, 64)
-					if err != nil {
-						s.logger.Error("Parse error", "value", v, "error", err)
-						continue
-					}
-					prices = append(prices, price)
-				}
-
-				if len(prices) == 0 {
-					s.logger.Info("No prices to write", "key", key)
-					continue
-				}
-
-				err = s.db.InsertMarketData(ex, pair, prices, time.Now())
-				if err != nil {
-					s.logger.Error("DB insert failed", "error", err)
-				} else {
-					s.logger.Info("Wrote to DB", "exchange", ex, "pair", pair, "count", len(prices))
-				}
-			}
-		}
+// tickDone отмечает в очереди биржи, что тик записан в Redis или отброшен.
+func (s *MarketServiceImpl) tickDone(exchange string) {
+	s.mu.RLock()
+	queue := s.queues[exchange]
+	s.mu.RUnlock()
+	if queue != nil {
+		queue.Done()
 	}
 }
 
+// storeTick пишет тик в ZSet под спаном collector.redis_write, продолжая трассу приёма.
+func (s *MarketServiceImpl) storeTick(update models.PriceUpdate, key string, score float64, now time.Time) error {
+	ctx, span := tracing.Start(tracing.WithParent(s.ctx, update.TraceParent), tracing.SpanRedisWrite,
+		tracing.String("exchange", update.Exchange),
+		tracing.String("pair", update.Pair),
+	)
+	err := s.redisClient.ZAdd(ctx, key, score, zsetMember(update, now))
+	tracing.End(span, err)
+	return err
+}
+
 // ИЗМЕНЕНО: теперь использует ExchangeClient интерфейс
 func (s *MarketServiceImpl) listenToExchange(exchange models.ExchangeConfig) {
 	defer s.wg.Done()
 
-	for {
+	// Слушатель пишет в свою очередь, очередь — в общий dataChan. Без буфера у
+	// dataChan биржи отдают тики сборщику по очереди, и всплеск на одной не
+	// занимает место других. Очередь переживает переподключения.
+	intake := make(chan models.PriceUpdate)
+	queue := newTickQueue(exchange.Name, s.backpressure.For(exchange.Name), s.symbols, s.logger)
+	s.mu.Lock()
+	s.queues[exchange.Name] = queue
+	s.mu.Unlock()
+	enqueued := make(chan struct{})
+	s.wg.Add(2)
+	go s.enqueue(intake, queue, enqueued)
+	go s.forward(queue)
+
+	for attempt := 0; ; attempt++ {
 		select {
 		case <-s.ctx.Done():
 			s.logger.Info("Exchange listener stopped", "exchange", exchange.Name)
 			return
 		default:
+			if attempt > 0 {
+				metrics.Reconnects.WithLabelValues(exchange.Name).Inc()
+			}
 			if err := s.exchangeClient.Connect(exchange); err != nil {
+				if errors.Is(err, models.ErrFeedFinished) {
+					s.finishFeed(exchange.Name, intake, enqueued, queue)
+					return
+				}
 				s.logger.Error("Connection failed", "exchange", exchange.Name, "error", err)
 
 				// Schedule reconnection
@@ -213,7 +269,12 @@ func (s *MarketServiceImpl) listenToExchange(exchange models.ExchangeConfig) {
 				}
 			} else {
 				// Listen for updates
-				if err := s.exchangeClient.Listen(s.ctx, s.dataChan, exchange); err != nil {
+				err := s.exchangeClient.Listen(s.ctx, intake, exchange)
+				if errors.Is(err, models.ErrFeedFinished) {
+					s.finishFeed(exchange.Name, intake, enqueued, queue)
+					return
+				}
+				if err != nil {
 					s.logger.Error("Listen failed", "exchange", exchange.Name, "error", err)
 				}
 			}
@@ -221,6 +282,76 @@ func (s *MarketServiceImpl) listenToExchange(exchange models.ExchangeConfig) {
 	}
 }
 
+// finishFeed вызывается, когда у биржи больше не будет тиков (воспроизведение
+// закончилось): слушатель не переподключается, а когда закончились все биржи,
+// агрегатор досчитывает последнее окно и останавливает сервис.
+func (s *MarketServiceImpl) finishFeed(exchange string, intake chan models.PriceUpdate, enqueued <-chan struct{}, queue *tickQueue) {
+	s.logger.Info("Exchange feed finished", "exchange", exchange)
+
+	// Тики биржи должны дойти до Redis раньше, чем зафиксируем время окончания.
+	// Слушатель больше не пишет в intake; когда enqueue выйдет, каждый принятый
+	// тик учтён в очереди, и остаётся дождаться, пока сборщик запишет их все.
+	close(intake)
+	select {
+	case <-s.ctx.Done():
+		return
+	case <-enqueued:
+	}
+	select {
+	case <-s.ctx.Done():
+		return
+	case <-queue.Idle():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feeds--
+	if s.feeds == 0 {
+		s.feedsDoneAt = time.Now()
+	}
+}
+
+// feedsFinishedBy сообщает, что потоки всех бирж закончились и окно с концом end
+// уже включает их последние тики.
+func (s *MarketServiceImpl) feedsFinishedBy(end time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.feeds == 0 && !s.feedsDoneAt.IsZero() && end.After(s.feedsDoneAt)
+}
+
+// enqueue перекладывает тики слушателя в очередь биржи по её политике, пока
+// intake не закрыт. Выходя, закрывает enqueued.
+func (s *MarketServiceImpl) enqueue(intake <-chan models.PriceUpdate, queue *tickQueue, enqueued chan<- struct{}) {
+	defer s.wg.Done()
+	defer close(enqueued)
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case update, ok := <-intake:
+			if !ok || !queue.Push(s.ctx, update) {
+				return
+			}
+		}
+	}
+}
+
+// forward отдаёт тики из очереди биржи сборщику.
+func (s *MarketServiceImpl) forward(queue *tickQueue) {
+	defer s.wg.Done()
+	for {
+		update, ok := queue.Pop(s.ctx)
+		if !ok {
+			return
+		}
+		select {
+		case s.dataChan <- update:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
 func (s *MarketServiceImpl) reconnectionHandler() {
 	s.logger.Info("Starting reconnection handler")
 
@@ -0,0 +1,273 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/output/memory"
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+	"marketflow/internal/domain/services"
+	"marketflow/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type testService struct {
+	*services.MarketServiceImpl
+	exchange *memory.ExchangeClient
+	repo     *memory.MarketRepo
+	done     chan error // результат Start
+}
+
+// newTestService запускает сервис с одной биржей "ex" и хранилищами в памяти.
+func newTestService(t *testing.T, window, ttl time.Duration) *testService {
+	t.Helper()
+	exchange := memory.NewExchangeClient()
+	s := startService(t, exchange, memory.NewRedisClient(), window, ttl)
+	s.exchange = exchange
+	return s
+}
+
+func startService(t *testing.T, client output.ExchangeClient, redis output.RedisClient, window, ttl time.Duration) *testService {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := memory.NewMarketRepo()
+	pairs := []string{"BTCUSDT"}
+
+	s := services.NewMarketService(
+		context.Background(),
+		client,
+		memory.NewPublisher(),
+		[]models.ExchangeConfig{{Name: "ex"}},
+		logger,
+		redis,
+		repo,
+		ttl,
+		window,
+		services.NewSpreadMonitor(services.SpreadConfig{ThresholdBps: 100, MaxQuoteAge: time.Minute}, repo, logger),
+		services.NewTickValidator(services.ValidationConfig{Default: services.ValidationRules{RequirePositive: true}}, repo, logger),
+		services.NewSymbolRegistry(services.SymbolConfig{Tracked: pairs}, logger),
+		services.NewStalenessMonitor(services.StalenessConfig{Threshold: time.Minute, CheckInterval: time.Second}, []string{"ex"}, pairs, logger),
+		models.BackpressureConfig{Default: models.QueueConfig{Policy: models.PolicyBlock, Buffer: 100}},
+	)
+	done := make(chan error, 1)
+	go func() { done <- s.Start(context.Background()) }()
+	t.Cleanup(func() {
+		s.Stop()
+		<-done
+	})
+	return &testService{MarketServiceImpl: s, repo: repo, done: done}
+}
+
+func (s *testService) send(t *testing.T, price string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.exchange.Send(ctx, "ex", models.PriceUpdate{Pair: "BTCUSDT", Price: models.MustDecimal(price)}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+}
+
+// waitAggregate ждёт агрегат окна start.
+func (s *testService) waitAggregate(t *testing.T, start time.Time, timeout time.Duration) models.Aggregate {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		for _, agg := range s.repo.Aggregates() {
+			if agg.Timestamp.Equal(start) {
+				return agg
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("no aggregate for window %s within %s", start.Format(time.TimeOnly), timeout)
+	return models.Aggregate{}
+}
+
+// untilWindowStart ждёт начала следующего окна и возвращает его.
+func untilWindowStart(window time.Duration) time.Time {
+	start := time.Now().Truncate(window).Add(window)
+	time.Sleep(time.Until(start) + 20*time.Millisecond)
+	return start
+}
+
+// Окно читается в end+1s. Тик из следующего окна, пришедший до этого, не должен
+// удалить начало ещё не посчитанного окна, даже при REDIS_TTL == AGGREGATOR_WINDOW.
+func TestTicksKeptUntilWindowIsAggregated(t *testing.T) {
+	const window = 2 * time.Second
+	s := newTestService(t, window, window)
+
+	start := untilWindowStart(window)
+	s.send(t, "100")
+	time.Sleep(time.Until(start.Add(window + 500*time.Millisecond)))
+	s.send(t, "200") // запускает очистку до агрегации окна start
+
+	if agg := s.waitAggregate(t, start, 3*window); agg.Count != 1 || agg.Max.String() != "100" {
+		t.Errorf("window aggregate = %+v, want the single tick 100", agg)
+	}
+}
+
+// finishedFeed — биржа, у которой один тик, после чего поток заканчивается.
+type finishedFeed struct{ *memory.ExchangeClient }
+
+func (f finishedFeed) Listen(ctx context.Context, updates chan<- models.PriceUpdate, exchange models.ExchangeConfig) error {
+	now := time.Now()
+	updates <- models.PriceUpdate{Exchange: exchange.Name, Pair: "BTCUSDT", Price: models.MustDecimal("100"), Timestamp: now, ReceivedAt: now}
+	return fmt.Errorf("replay: %w", models.ErrFeedFinished)
+}
+
+// Когда потоки всех бирж закончились, сервис досчитывает окно с последними
+// тиками и останавливается сам, без переподключений.
+func TestServiceStopsWhenFeedsFinish(t *testing.T) {
+	const window = time.Second
+	client := finishedFeed{memory.NewExchangeClient()}
+	s := startService(t, client, memory.NewRedisClient(), window, 10*window)
+
+	select {
+	case <-s.done:
+		s.done <- nil // для Cleanup
+	case <-time.After(5 * window):
+		t.Fatal("service did not stop after all feeds finished")
+	}
+
+	if got := len(s.repo.Aggregates()); got != 1 {
+		t.Errorf("aggregates = %d, want 1", got)
+	}
+	if got := client.Connects("ex"); got != 1 {
+		t.Errorf("connects = %d, want 1 (no reconnect after the feed finished)", got)
+	}
+}
+
+// replayFeed отдаёт тики в конце окна и заканчивается.
+type replayFeed struct {
+	*memory.ExchangeClient
+	window time.Duration
+	ticks  int
+}
+
+func (f replayFeed) Listen(ctx context.Context, updates chan<- models.PriceUpdate, exchange models.ExchangeConfig) error {
+	end := time.Now().Truncate(f.window).Add(f.window)
+	if time.Until(end) < f.window/2 {
+		end = end.Add(f.window)
+	}
+	time.Sleep(time.Until(end.Add(-f.window / 2)))
+	for i := range f.ticks {
+		now := time.Now()
+		updates <- models.PriceUpdate{Exchange: exchange.Name, Pair: "BTCUSDT", Price: models.MustDecimal(strconv.Itoa(100 + i)), Timestamp: now, ReceivedAt: now}
+	}
+	return fmt.Errorf("replay: %w", models.ErrFeedFinished)
+}
+
+// slowRedis — Redis, в котором запись тика занимает delay.
+type slowRedis struct {
+	*memory.RedisClient
+	delay time.Duration
+}
+
+func (r slowRedis) ZAdd(ctx context.Context, key string, score float64, member any) error {
+	time.Sleep(r.delay)
+	return r.RedisClient.ZAdd(ctx, key, score, member)
+}
+
+// Поток закончился, а сборщик ещё пишет его тики: последние из них попадают в
+// следующее окно, и сервис должен дождаться их записи, а не пустой очереди.
+func TestReplayEndWaitsForStoredTicks(t *testing.T) {
+	const (
+		window = time.Second
+		ticks  = 3
+	)
+	client := replayFeed{ExchangeClient: memory.NewExchangeClient(), window: window, ticks: ticks}
+	// тики пишутся 3*400ms: последний — уже после конца окна, в котором закончился поток
+	s := startService(t, client, slowRedis{memory.NewRedisClient(), 2 * window / 5}, window, 10*window)
+
+	select {
+	case <-s.done:
+		s.done <- nil // для Cleanup
+	case <-time.After(6 * window):
+		t.Fatal("service did not stop after the replay finished")
+	}
+
+	stored := 0
+	for _, agg := range s.repo.Aggregates() {
+		stored += agg.Count
+	}
+	if stored != ticks {
+		t.Errorf("aggregated %d ticks, want %d: %+v", stored, ticks, s.repo.Aggregates())
+	}
+}
+
+// Спан окна — корень своей трассы и ссылается на спаны приёма записанных
+// в окно тиков; тики, не попавшие в выборку, не упоминаются.
+func TestWindowSpanLinksTickSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	receive := func(sampled bool) (string, trace.SpanContext) {
+		sampler := sdktrace.NeverSample()
+		if sampled {
+			sampler = sdktrace.AlwaysSample()
+		}
+		_, span := sdktrace.NewTracerProvider(sdktrace.WithSampler(sampler)).Tracer("test").Start(context.Background(), tracing.SpanReceive)
+		defer span.End()
+		return tracing.TraceParent(span), span.SpanContext()
+	}
+
+	const window = time.Second
+	s := newTestService(t, window, 10*window)
+	start := untilWindowStart(window)
+	var want []trace.SpanContext
+	for i := range 3 {
+		traceparent, sc := receive(i != 1)
+		if i != 1 {
+			want = append(want, sc)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := s.exchange.Send(ctx, "ex", models.PriceUpdate{Pair: "BTCUSDT", Price: models.MustDecimal("100"), TraceParent: traceparent})
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.waitAggregate(t, start, 5*window)
+
+	span := waitWindowSpan(t, recorder, start, window)
+	if span.Parent().IsValid() {
+		t.Errorf("window span has parent %s", span.Parent().SpanID())
+	}
+	var got []trace.SpanContext
+	for _, link := range span.Links() {
+		got = append(got, link.SpanContext.WithRemote(false))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("window span links = %v, want %v", got, want)
+	}
+}
+
+// waitWindowSpan ждёт завершения спана окна start: агрегат пишется раньше, чем спан закрывается.
+func waitWindowSpan(t *testing.T, recorder *tracetest.SpanRecorder, start time.Time, timeout time.Duration) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		for _, span := range recorder.Ended() {
+			for _, kv := range span.Attributes() {
+				if span.Name() == tracing.SpanAggregateWindow && kv.Key == "window.start" && kv.Value.AsString() == start.Format(time.RFC3339) {
+					return span
+				}
+			}
+		}
+	}
+	t.Fatalf("no %s span for window %s", tracing.SpanAggregateWindow, start.Format(time.TimeOnly))
+	return nil
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+	"marketflow/pkg/metrics"
+)
+
+const defaultSinkBuffer = 1024
+
+// PublishFilter ограничивает, какие события получает приёмник. Пустые списки — без ограничений.
+type PublishFilter struct {
+	Pairs      []string
+	Exchanges  []string
+	Ticks      bool
+	Aggregates bool
+}
+
+type busEvent struct {
+	tick *models.PriceUpdate
+	agg  *models.Aggregate
+}
+
+type busSink struct {
+	name      string
+	sink      output.PricePublisher
+	pairs     map[string]bool
+	exchanges map[string]bool
+	ticks     bool
+	aggs      bool
+	block     bool // при полной очереди ждать, а не отбрасывать
+	queue     chan busEvent
+}
+
+func (s *busSink) accepts(exchange, pair string) bool {
+	return (len(s.pairs) == 0 || s.pairs[pair]) && (len(s.exchanges) == 0 || s.exchanges[exchange])
+}
+
+// PublisherBus раздаёт события конвейера всем зарегистрированным приёмникам.
+// У каждого приёмника своя очередь и горутина. При переполнении очереди событие
+// для этого приёмника отбрасывается (publisher_dropped_total), а для приёмников
+// из RegisterBlocking публикация ждёт места.
+type PublisherBus struct {
+	logger *slog.Logger
+	sinks  []*busSink
+	wg     sync.WaitGroup
+	done   <-chan struct{} // контекст Start: после отмены никто не ждёт места в очереди
+}
+
+func NewPublisherBus(logger *slog.Logger) *PublisherBus {
+	return &PublisherBus{logger: logger}
+}
+
+// Register добавляет приёмник. Вызывается до Start.
+func (b *PublisherBus) Register(name string, sink output.PricePublisher, filter PublishFilter, buffer int) {
+	b.register(name, sink, filter, buffer, false)
+}
+
+// RegisterBlocking добавляет приёмник, который не должен терять события: при полной
+// очереди публикация ждёт, поэтому приёмник должен обрабатывать события быстро.
+func (b *PublisherBus) RegisterBlocking(name string, sink output.PricePublisher, filter PublishFilter, buffer int) {
+	b.register(name, sink, filter, buffer, true)
+}
+
+func (b *PublisherBus) register(name string, sink output.PricePublisher, filter PublishFilter, buffer int, block bool) {
+	if buffer <= 0 {
+		buffer = defaultSinkBuffer
+	}
+	b.sinks = append(b.sinks, &busSink{
+		name:      name,
+		sink:      sink,
+		pairs:     toSet(filter.Pairs),
+		exchanges: toSet(filter.Exchanges),
+		ticks:     filter.Ticks,
+		aggs:      filter.Aggregates,
+		block:     block,
+		queue:     make(chan busEvent, buffer),
+	})
+	b.logger.Info("Registered publisher sink", "sink", name, "blocking", block)
+}
+
+// Start запускает доставку; при отмене контекста очереди дочитываются и горутины завершаются.
+func (b *PublisherBus) Start(ctx context.Context) {
+	b.done = ctx.Done()
+	for _, s := range b.sinks {
+		b.wg.Add(1)
+		go b.deliver(ctx, s)
+	}
+}
+
+// Wait ждёт завершения доставки после отмены контекста.
+func (b *PublisherBus) Wait() {
+	b.wg.Wait()
+}
+
+func (b *PublisherBus) PublishTick(update models.PriceUpdate) error {
+	for _, s := range b.sinks {
+		if s.ticks && s.accepts(update.Exchange, update.Pair) {
+			b.enqueue(s, busEvent{tick: &update})
+		}
+	}
+	return nil
+}
+
+func (b *PublisherBus) PublishAggregate(agg models.Aggregate) error {
+	for _, s := range b.sinks {
+		if s.aggs && s.accepts(agg.Exchange, agg.Pair) {
+			b.enqueue(s, busEvent{agg: &agg})
+		}
+	}
+	return nil
+}
+
+func (b *PublisherBus) enqueue(s *busSink, event busEvent) {
+	if s.block {
+		select {
+		case s.queue <- event:
+		case <-b.done:
+		}
+		return
+	}
+	select {
+	case s.queue <- event:
+	default:
+		metrics.PublisherDropped.WithLabelValues(s.name).Inc()
+	}
+}
+
+func (b *PublisherBus) deliver(ctx context.Context, s *busSink) {
+	defer b.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Отдаём то, что уже в очереди, и выходим
+			for {
+				select {
+				case event := <-s.queue:
+					b.send(s, event)
+				default:
+					return
+				}
+			}
+		case event := <-s.queue:
+			b.send(s, event)
+		}
+	}
+}
+
+func (b *PublisherBus) send(s *busSink, event busEvent) {
+	var err error
+	if event.tick != nil {
+		err = s.sink.PublishTick(*event.tick)
+	} else {
+		err = s.sink.PublishAggregate(*event.agg)
+	}
+	if err != nil {
+		metrics.PublisherErrors.WithLabelValues(s.name).Inc()
+		b.logger.Warn("Publisher sink failed", "sink", s.name, "error", err)
+	}
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
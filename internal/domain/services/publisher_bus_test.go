@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// sinkRecorder — приёмник шины; пока открыт gate, каждое событие ждёт.
+type sinkRecorder struct {
+	gate chan struct{}
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *sinkRecorder) record(event string) error {
+	if r.gate != nil {
+		<-r.gate
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *sinkRecorder) PublishTick(update models.PriceUpdate) error {
+	return r.record("tick " + update.Exchange + " " + update.Pair)
+}
+
+func (r *sinkRecorder) PublishAggregate(agg models.Aggregate) error {
+	return r.record("aggregate " + agg.Exchange + " " + agg.Pair)
+}
+
+func (r *sinkRecorder) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func newBus() *PublisherBus {
+	return NewPublisherBus(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func publishAll(bus *PublisherBus) {
+	for _, exchange := range []string{"exchange1", "exchange2"} {
+		for _, pair := range []string{"BTCUSDT", "ETHUSDT"} {
+			bus.PublishTick(models.PriceUpdate{Exchange: exchange, Pair: pair})
+			bus.PublishAggregate(models.Aggregate{Exchange: exchange, Pair: pair})
+		}
+	}
+}
+
+func TestPublisherBusFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter PublishFilter
+		want   int
+	}{
+		{"ticks only", PublishFilter{Ticks: true}, 4},
+		{"aggregates only", PublishFilter{Aggregates: true}, 4},
+		{"nothing", PublishFilter{}, 0},
+		{"one pair", PublishFilter{Ticks: true, Aggregates: true, Pairs: []string{"BTCUSDT"}}, 4},
+		{"one pair on one exchange", PublishFilter{Ticks: true, Pairs: []string{"ETHUSDT"}, Exchanges: []string{"exchange2"}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := newBus()
+			sink := &sinkRecorder{}
+			bus.Register(tt.name, sink, tt.filter, 0)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			bus.Start(ctx)
+			publishAll(bus)
+			cancel()
+			bus.Wait()
+
+			if got := sink.received(); len(got) != tt.want {
+				t.Errorf("received %d events %v, want %d", len(got), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublisherBusDropsForFullSink(t *testing.T) {
+	bus := newBus()
+	slow := &sinkRecorder{gate: make(chan struct{})}
+	fast := &sinkRecorder{}
+	bus.Register("test-slow", slow, PublishFilter{Ticks: true}, 2)
+	bus.Register("test-fast", fast, PublishFilter{Ticks: true}, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bus.Start(ctx)
+
+	before := testutil.ToFloat64(metrics.PublisherDropped.WithLabelValues("test-slow"))
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for range 10 {
+			bus.PublishTick(models.PriceUpdate{Exchange: "exchange1", Pair: "BTCUSDT"})
+		}
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publishing waited for a slow sink")
+	}
+
+	cancel()
+	close(slow.gate)
+	bus.Wait()
+
+	// Одно событие у медленного приёмника в работе, два в очереди, остальные отброшены
+	delivered := len(slow.received())
+	dropped := testutil.ToFloat64(metrics.PublisherDropped.WithLabelValues("test-slow")) - before
+	if delivered+int(dropped) != 10 || delivered > 3 {
+		t.Errorf("slow sink: delivered %d, dropped %v of 10", delivered, dropped)
+	}
+	if got := len(fast.received()); got != 10 {
+		t.Errorf("fast sink received %d of 10", got)
+	}
+}
+
+func TestPublisherBusBlockingSinkLosesNothing(t *testing.T) {
+	bus := newBus()
+	sink := &sinkRecorder{gate: make(chan struct{})}
+	bus.RegisterBlocking("test-blocking", sink, PublishFilter{Ticks: true}, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bus.Start(ctx)
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for range 10 {
+			bus.PublishTick(models.PriceUpdate{Exchange: "exchange1", Pair: "BTCUSDT"})
+		}
+	}()
+	select {
+	case <-published:
+		t.Fatal("publishing did not wait for a full blocking sink")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(sink.gate)
+	<-published
+	cancel()
+	bus.Wait()
+	if got := len(sink.received()); got != 10 {
+		t.Errorf("blocking sink received %d of 10", got)
+	}
+
+	// После остановки шины публикация не зависает
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 10 {
+			bus.PublishTick(models.PriceUpdate{Exchange: "exchange1", Pair: "BTCUSDT"})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishing after shutdown blocked")
+	}
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+)
+
+type SpreadConfig struct {
+	ThresholdBps float64       // порог спреда в базисных пунктах
+	MinDuration  time.Duration // сколько спред должен держаться выше порога
+	MaxQuoteAge  time.Duration // котировки старше этого не сравниваются
+}
+
+type quote struct {
+	price models.Decimal
+	at    time.Time
+}
+
+type spreadState struct {
+	since   time.Time
+	peakBps float64
+	emitted bool
+}
+
+// spreadQueueSize — сколько событий спреда ждут записи в Postgres.
+const spreadQueueSize = 256
+
+// SpreadMonitor сравнивает последние цены одной пары на разных биржах
+// и сообщает о спредах, которые держатся выше порога.
+// События пишутся в репозиторий из Run, чтобы медленная база не тормозила сборщик.
+type SpreadMonitor struct {
+	cfg    SpreadConfig
+	repo   output.SpreadRepository
+	logger *slog.Logger
+	events chan models.SpreadEvent
+
+	mu     sync.Mutex
+	quotes map[string]map[string]quote // pair -> exchange -> last quote
+	states map[string]*spreadState     // pair:exA:exB -> state
+}
+
+func NewSpreadMonitor(cfg SpreadConfig, repo output.SpreadRepository, logger *slog.Logger) *SpreadMonitor {
+	return &SpreadMonitor{
+		cfg:    cfg,
+		repo:   repo,
+		logger: logger,
+		events: make(chan models.SpreadEvent, spreadQueueSize),
+		quotes: make(map[string]map[string]quote),
+		states: make(map[string]*spreadState),
+	}
+}
+
+// Run записывает события спреда в репозиторий, пока не отменён контекст,
+// и дописывает оставшиеся в очереди перед выходом.
+func (m *SpreadMonitor) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-m.events:
+					m.store(event)
+				default:
+					return
+				}
+			}
+		case event := <-m.events:
+			m.store(event)
+		}
+	}
+}
+
+// Observe обновляет котировку и пересчитывает спреды пары со всеми остальными биржами.
+func (m *SpreadMonitor) Observe(update models.PriceUpdate) {
+	events := m.observe(update)
+	for _, event := range events {
+		m.emit(event)
+	}
+}
+
+func (m *SpreadMonitor) observe(update models.PriceUpdate) []models.SpreadEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := update.Timestamp
+	byExchange, ok := m.quotes[update.Pair]
+	if !ok {
+		byExchange = make(map[string]quote)
+		m.quotes[update.Pair] = byExchange
+	}
+	byExchange[update.Exchange] = quote{price: update.Price, at: now}
+
+	var events []models.SpreadEvent
+	for exchange, other := range byExchange {
+		if exchange == update.Exchange {
+			continue
+		}
+
+		exA, exB := update.Exchange, exchange
+		if exA > exB {
+			exA, exB = exB, exA
+		}
+		key := update.Pair + ":" + exA + ":" + exB
+
+		buyEx, buyPrice := exchange, other.price
+		sellEx, sellPrice := update.Exchange, update.Price
+		if buyPrice.Cmp(sellPrice) > 0 {
+			buyEx, sellEx = sellEx, buyEx
+			buyPrice, sellPrice = sellPrice, buyPrice
+		}
+
+		bps := spreadBps(buyPrice, sellPrice)
+		fresh := now.Sub(other.at) <= m.cfg.MaxQuoteAge
+		state := m.states[key]
+
+		event := models.SpreadEvent{
+			Pair:         update.Pair,
+			BuyExchange:  buyEx,
+			SellExchange: sellEx,
+			BuyPrice:     buyPrice,
+			SellPrice:    sellPrice,
+			SpreadBps:    bps,
+			Timestamp:    now,
+		}
+
+		if !fresh || bps < m.cfg.ThresholdBps {
+			if state == nil {
+				continue
+			}
+			delete(m.states, key)
+			if state.emitted {
+				event.Status = models.SpreadClosed
+				event.PeakBps = state.peakBps
+				event.StartedAt = state.since
+				event.Duration = now.Sub(state.since)
+				events = append(events, event)
+			}
+			continue
+		}
+
+		if state == nil {
+			state = &spreadState{since: now}
+			m.states[key] = state
+		}
+		if bps > state.peakBps {
+			state.peakBps = bps
+		}
+		if !state.emitted && now.Sub(state.since) >= m.cfg.MinDuration {
+			state.emitted = true
+			event.Status = models.SpreadOpened
+			event.PeakBps = state.peakBps
+			event.StartedAt = state.since
+			event.Duration = now.Sub(state.since)
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+func (m *SpreadMonitor) emit(event models.SpreadEvent) {
+	m.logger.Info("Spread "+event.Status,
+		"pair", event.Pair,
+		"buy_exchange", event.BuyExchange,
+		"sell_exchange", event.SellExchange,
+		"spread_bps", event.SpreadBps,
+		"peak_bps", event.PeakBps,
+		"duration", event.Duration,
+	)
+
+	select {
+	case m.events <- event:
+	default:
+		m.logger.Warn("Spread history queue full, dropping event", "pair", event.Pair, "status", event.Status)
+	}
+}
+
+func (m *SpreadMonitor) store(event models.SpreadEvent) {
+	if err := m.repo.InsertSpreadEvent(event); err != nil {
+		m.logger.Error("Failed to record spread event", "pair", event.Pair, "error", err)
+	}
+}
+
+// spreadBps возвращает разницу цен в базисных пунктах относительно средней цены.
+func spreadBps(lowPrice, highPrice models.Decimal) float64 {
+	low, high := lowPrice.Float64(), highPrice.Float64()
+	mid := (low + high) / 2
+	if mid <= 0 {
+		return 0
+	}
+	return (high - low) / mid * 10000
+}
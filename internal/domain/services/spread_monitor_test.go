@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+// spreadRecorder — репозиторий спредов; пока открыт gate, запись ждёт.
+type spreadRecorder struct {
+	gate chan struct{}
+
+	mu     sync.Mutex
+	events []models.SpreadEvent
+}
+
+func (r *spreadRecorder) InsertSpreadEvent(event models.SpreadEvent) error {
+	if r.gate != nil {
+		<-r.gate
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *spreadRecorder) stored() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+func newSpreadMonitor(repo *spreadRecorder) *SpreadMonitor {
+	return NewSpreadMonitor(SpreadConfig{ThresholdBps: 50, MinDuration: 5 * time.Second, MaxQuoteAge: 10 * time.Second},
+		repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func quoteAt(exchange, price string, at time.Time) models.PriceUpdate {
+	return models.PriceUpdate{Exchange: exchange, Pair: "BTCUSDT", Price: models.MustDecimal(price), Timestamp: at}
+}
+
+func TestSpreadOpensAfterMinDurationAndCloses(t *testing.T) {
+	m := newSpreadMonitor(&spreadRecorder{})
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		update models.PriceUpdate
+		want   string // статус события или "" — событий нет
+	}{
+		{quoteAt("ex1", "100", t0), ""},
+		{quoteAt("ex2", "101", t0), ""},                    // ~100 bps, но ещё не держится MinDuration
+		{quoteAt("ex2", "102", t0.Add(3*time.Second)), ""}, // ~198 bps
+		{quoteAt("ex1", "100", t0.Add(5*time.Second)), models.SpreadOpened},
+		{quoteAt("ex2", "101", t0.Add(6*time.Second)), ""}, // уже открыт — повторно не сообщаем
+		{quoteAt("ex2", "100.2", t0.Add(8*time.Second)), models.SpreadClosed},
+		{quoteAt("ex2", "102", t0.Add(9*time.Second)), ""}, // новый отсчёт MinDuration
+	}
+	for i, step := range steps {
+		events := m.observe(step.update)
+		got := ""
+		if len(events) > 0 {
+			got = events[0].Status
+		}
+		if len(events) > 1 || got != step.want {
+			t.Fatalf("step %d: events %+v, want status %q", i, events, step.want)
+		}
+	}
+
+	closed := m.observe(quoteAt("ex2", "100", t0.Add(20*time.Second)))
+	if len(closed) != 0 {
+		t.Errorf("spread shorter than MinDuration reported on close: %+v", closed)
+	}
+}
+
+func TestSpreadReportsPeakAndDuration(t *testing.T) {
+	m := newSpreadMonitor(&spreadRecorder{})
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m.observe(quoteAt("ex1", "100", t0))
+	m.observe(quoteAt("ex2", "103", t0))
+	opened := m.observe(quoteAt("ex2", "101", t0.Add(5*time.Second)))
+	if len(opened) != 1 || opened[0].BuyExchange != "ex1" || opened[0].SellExchange != "ex2" {
+		t.Fatalf("opened = %+v", opened)
+	}
+
+	closed := m.observe(quoteAt("ex2", "100", t0.Add(7*time.Second)))
+	if len(closed) != 1 {
+		t.Fatalf("closed = %+v", closed)
+	}
+	if e := closed[0]; e.PeakBps < 295 || e.PeakBps > 296 || e.Duration != 7*time.Second || !e.StartedAt.Equal(t0) {
+		t.Errorf("closed event peak %.2f, duration %s, started %s", e.PeakBps, e.Duration, e.StartedAt)
+	}
+}
+
+func TestSpreadIgnoresStaleQuotes(t *testing.T) {
+	m := newSpreadMonitor(&spreadRecorder{})
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m.observe(quoteAt("ex1", "100", t0))
+	if events := m.observe(quoteAt("ex2", "110", t0.Add(11*time.Second))); len(events) != 0 {
+		t.Fatalf("spread against a stale quote: %+v", events)
+	}
+	if len(m.states) != 0 {
+		t.Fatalf("stale quote started a spread: %v", m.states)
+	}
+
+	// Открытый спред закрывается, когда котировка другой биржи устарела
+	m.observe(quoteAt("ex1", "100", t0.Add(12*time.Second)))
+	if opened := m.observe(quoteAt("ex2", "110", t0.Add(17*time.Second))); len(opened) != 1 {
+		t.Fatalf("opened = %+v", opened)
+	}
+	closed := m.observe(quoteAt("ex2", "110", t0.Add(30*time.Second)))
+	if len(closed) != 1 || closed[0].Status != models.SpreadClosed {
+		t.Errorf("closed = %+v", closed)
+	}
+}
+
+func TestSpreadSlowRepoDoesNotBlockObserve(t *testing.T) {
+	repo := &spreadRecorder{gate: make(chan struct{})}
+	m := newSpreadMonitor(repo)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	observed := make(chan struct{})
+	go func() {
+		defer close(observed)
+		m.Observe(quoteAt("ex1", "100", t0))
+		for i := range 10 {
+			at := t0.Add(time.Duration(i) * 10 * time.Second)
+			m.Observe(quoteAt("ex2", "110", at))
+			m.Observe(quoteAt("ex2", "110", at.Add(5*time.Second)))
+			m.Observe(quoteAt("ex1", "100", at.Add(5*time.Second)))
+			m.Observe(quoteAt("ex2", "100", at.Add(6*time.Second)))
+			m.Observe(quoteAt("ex1", "100", at.Add(9*time.Second)))
+		}
+	}()
+	select {
+	case <-observed:
+	case <-time.After(time.Second):
+		t.Fatal("Observe waited for the repository")
+	}
+
+	// После остановки Run дописывает всё, что успело встать в очередь
+	cancel()
+	close(repo.gate)
+	<-done
+	if got := repo.stored(); got != 20 {
+		t.Errorf("stored %d events, want 20", got)
+	}
+}
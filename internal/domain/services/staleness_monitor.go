@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/metrics"
+)
+
+type StalenessConfig struct {
+	Threshold     time.Duration // пара устарела, если тиков нет дольше этого
+	CheckInterval time.Duration // как часто проверять
+}
+
+type stalenessState struct {
+	last  time.Time // ReceivedAt последнего сохранённого тика
+	stale bool
+}
+
+// StalenessMonitor следит, чтобы по каждой паре на каждой бирже приходили тики.
+// Молчащий фид — соединение живо, но пара не обновляется — не даёт ошибок
+// ни при чтении, ни при записи; монитор выставляет pair_stale и пишет в лог.
+type StalenessMonitor struct {
+	cfg    StalenessConfig
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	states map[[2]string]*stalenessState // [exchange, pair]
+}
+
+// NewStalenessMonitor заранее заводит все пары отслеживаемых бирж: пара,
+// по которой с запуска не пришло ни одного тика, тоже станет устаревшей.
+// Пары приводятся к каноническому символу, как их передаёт в Observe сборщик;
+// нераспознанные пропускаются (о них предупреждает SymbolRegistry).
+func NewStalenessMonitor(cfg StalenessConfig, exchanges, pairs []string, logger *slog.Logger) *StalenessMonitor {
+	m := &StalenessMonitor{
+		cfg:    cfg,
+		logger: logger,
+		states: make(map[[2]string]*stalenessState),
+	}
+	now := time.Now()
+	for _, symbol := range pairs {
+		pair, ok := models.ParsePair(symbol)
+		if !ok {
+			continue
+		}
+		for _, exchange := range exchanges {
+			m.states[[2]string{exchange, pair.Symbol()}] = &stalenessState{last: now}
+		}
+	}
+	return m
+}
+
+// Observe отмечает сохранённый тик и пишет задержки фида и обработки.
+func (m *StalenessMonitor) Observe(update models.PriceUpdate) {
+	if !update.ExchangeTime.IsZero() && !update.ReceivedAt.IsZero() {
+		metrics.FeedLatency.WithLabelValues(update.Exchange).Observe(max(update.ReceivedAt.Sub(update.ExchangeTime).Seconds(), 0))
+	}
+	if !update.ReceivedAt.IsZero() && !update.ProcessedAt.IsZero() {
+		metrics.ProcessingLatency.WithLabelValues(update.Exchange).Observe(update.ProcessedAt.Sub(update.ReceivedAt).Seconds())
+	}
+
+	at := update.ReceivedAt
+	if at.IsZero() {
+		at = update.Timestamp
+	}
+
+	key := [2]string{update.Exchange, update.Pair}
+	m.mu.Lock()
+	state, ok := m.states[key]
+	if !ok {
+		state = &stalenessState{}
+		m.states[key] = state
+	}
+	state.last = at
+	recovered := state.stale
+	state.stale = false
+	m.mu.Unlock()
+
+	if recovered {
+		metrics.PairStale.WithLabelValues(update.Exchange, update.Pair).Set(0)
+		m.logger.Info("Pair is fresh again", "exchange", update.Exchange, "pair", update.Pair)
+	}
+}
+
+// Run раз в CheckInterval обновляет возраст последних тиков и отмечает устаревшие пары.
+func (m *StalenessMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.check(now)
+		}
+	}
+}
+
+func (m *StalenessMonitor) check(now time.Time) {
+	type staleEntry struct {
+		exchange, pair string
+		age            time.Duration
+	}
+	var became []staleEntry
+
+	m.mu.Lock()
+	for key, state := range m.states {
+		age := now.Sub(state.last)
+		metrics.LastTickAge.WithLabelValues(key[0], key[1]).Set(age.Seconds())
+		if age > m.cfg.Threshold && !state.stale {
+			state.stale = true
+			became = append(became, staleEntry{key[0], key[1], age})
+		}
+	}
+	m.mu.Unlock()
+
+	for _, e := range became {
+		metrics.PairStale.WithLabelValues(e.exchange, e.pair).Set(1)
+		metrics.StaleEvents.WithLabelValues(e.exchange, e.pair).Inc()
+		m.logger.Warn("Pair is stale", "exchange", e.exchange, "pair", e.pair, "age", e.age.Round(time.Millisecond), "threshold", m.cfg.Threshold)
+	}
+}
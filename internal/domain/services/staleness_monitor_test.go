@@ -0,0 +1,187 @@
+package services
+
+import (
+	"bytes"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestStalenessTransitions(t *testing.T) {
+	var logs bytes.Buffer
+	m := NewStalenessMonitor(StalenessConfig{Threshold: 10 * time.Second}, []string{"stale-ex"}, []string{"BTCUSDT", "ETHUSDT"},
+		slog.New(slog.NewTextHandler(&logs, nil)))
+	t0 := time.Now()
+
+	stale := func(pair string) float64 {
+		return testutil.ToFloat64(metrics.PairStale.WithLabelValues("stale-ex", pair))
+	}
+	events := func(pair string) float64 {
+		return testutil.ToFloat64(metrics.StaleEvents.WithLabelValues("stale-ex", pair))
+	}
+	age := func(pair string) float64 {
+		return testutil.ToFloat64(metrics.LastTickAge.WithLabelValues("stale-ex", pair))
+	}
+
+	// Пары заведены с запуска: без единого тика они тоже устаревают
+	m.check(t0.Add(5 * time.Second))
+	if stale("BTCUSDT") != 0 || stale("ETHUSDT") != 0 {
+		t.Fatal("pairs stale before the threshold")
+	}
+	if got := age("ETHUSDT"); got < 5 || got > 6 {
+		t.Errorf("last tick age = %v, want about 5s", got)
+	}
+
+	m.check(t0.Add(11 * time.Second))
+	m.check(t0.Add(20 * time.Second)) // уже устаревшая пара не считается заново
+	for _, pair := range []string{"BTCUSDT", "ETHUSDT"} {
+		if stale(pair) != 1 || events(pair) != 1 {
+			t.Errorf("%s: pair_stale = %v, stale events = %v; want 1, 1", pair, stale(pair), events(pair))
+		}
+	}
+
+	m.Observe(models.PriceUpdate{Exchange: "stale-ex", Pair: "BTCUSDT", ReceivedAt: t0.Add(21 * time.Second)})
+	if stale("BTCUSDT") != 0 || stale("ETHUSDT") != 1 {
+		t.Errorf("after a tick pair_stale = %v, %v; want BTCUSDT 0, ETHUSDT 1", stale("BTCUSDT"), stale("ETHUSDT"))
+	}
+	m.check(t0.Add(25 * time.Second))
+	if got := age("BTCUSDT"); got != 4 {
+		t.Errorf("last tick age = %v, want 4s from the received time", got)
+	}
+
+	// Снова замолчала — второе событие
+	m.check(t0.Add(32 * time.Second))
+	if stale("BTCUSDT") != 1 || events("BTCUSDT") != 2 || events("ETHUSDT") != 1 {
+		t.Errorf("pair_stale = %v, stale events = %v, %v; want 1, 2, 1", stale("BTCUSDT"), events("BTCUSDT"), events("ETHUSDT"))
+	}
+
+	out := logs.String()
+	if got := strings.Count(out, "Pair is stale"); got != 3 {
+		t.Errorf("%d stale log records, want 3:\n%s", got, out)
+	}
+	if got := strings.Count(out, "Pair is fresh again"); got != 1 {
+		t.Errorf("%d recovery log records, want 1:\n%s", got, out)
+	}
+}
+
+// Пара, которой не было в TRACKED_PAIRS, отслеживается с первого тика.
+func TestStalenessTracksNewPairs(t *testing.T) {
+	m := NewStalenessMonitor(StalenessConfig{Threshold: time.Second}, []string{"stale-new"}, nil, slog.New(slog.DiscardHandler))
+	t0 := time.Now()
+
+	m.check(t0.Add(time.Hour))
+	m.Observe(models.PriceUpdate{Exchange: "stale-new", Pair: "SOLUSDT", Timestamp: t0})
+	m.check(t0.Add(2 * time.Second))
+	if got := testutil.ToFloat64(metrics.PairStale.WithLabelValues("stale-new", "SOLUSDT")); got != 1 {
+		t.Errorf("pair_stale = %v, want 1", got)
+	}
+}
+
+// TRACKED_PAIRS в любой записи заводит тот же ряд, что и тики сборщика.
+func TestStalenessNormalizesTrackedPairs(t *testing.T) {
+	m := NewStalenessMonitor(StalenessConfig{Threshold: 10 * time.Second}, []string{"stale-norm"},
+		[]string{"BTC/USDT", "ethusdt", "BTC"}, slog.New(slog.DiscardHandler))
+	t0 := time.Now()
+
+	m.Observe(models.PriceUpdate{Exchange: "stale-norm", Pair: "BTCUSDT", ReceivedAt: t0.Add(5 * time.Second)})
+	m.check(t0.Add(11 * time.Second))
+
+	m.mu.Lock()
+	var keys []string
+	for key := range m.states {
+		keys = append(keys, key[0]+":"+key[1])
+	}
+	m.mu.Unlock()
+	slices.Sort(keys)
+	want := []string{"stale-norm:BTCUSDT", "stale-norm:ETHUSDT"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("tracked series = %v, want %v", keys, want)
+	}
+	if got := testutil.ToFloat64(metrics.PairStale.WithLabelValues("stale-norm", "BTCUSDT")); got != 0 {
+		t.Errorf("BTCUSDT pair_stale = %v, want 0: the tick went to the seeded series", got)
+	}
+	if got := testutil.ToFloat64(metrics.PairStale.WithLabelValues("stale-norm", "ETHUSDT")); got != 1 {
+		t.Errorf("ETHUSDT pair_stale = %v, want 1", got)
+	}
+}
+
+func TestStalenessLatencyHistograms(t *testing.T) {
+	m := NewStalenessMonitor(StalenessConfig{Threshold: time.Minute}, nil, nil, slog.New(slog.DiscardHandler))
+	received := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		update     models.PriceUpdate
+		feed       []float64 // наблюдения feed_latency_seconds; nil — не пишется
+		processing []float64
+	}{
+		{
+			name:       "all timestamps",
+			update:     models.PriceUpdate{ExchangeTime: received.Add(-250 * time.Millisecond), ReceivedAt: received, ProcessedAt: received.Add(2 * time.Millisecond)},
+			feed:       []float64{0.25},
+			processing: []float64{0.002},
+		},
+		{
+			// Часы биржи спешат — задержка фида не уходит в минус
+			name:       "exchange clock ahead",
+			update:     models.PriceUpdate{ExchangeTime: received.Add(time.Second), ReceivedAt: received, ProcessedAt: received.Add(time.Millisecond)},
+			feed:       []float64{0},
+			processing: []float64{0.001},
+		},
+		{
+			name:       "no exchange time",
+			update:     models.PriceUpdate{ReceivedAt: received, ProcessedAt: received.Add(time.Millisecond)},
+			processing: []float64{0.001},
+		},
+		{
+			name:   "not stored",
+			update: models.PriceUpdate{ExchangeTime: received.Add(-time.Second), ReceivedAt: received},
+			feed:   []float64{1},
+		},
+		{
+			name:   "historical tick",
+			update: models.PriceUpdate{Timestamp: received},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.update.Exchange = "latency-" + strconv.Itoa(i)
+			tt.update.Pair = "BTCUSDT"
+			m.Observe(tt.update)
+
+			for _, h := range []struct {
+				name string
+				vec  *prometheus.HistogramVec
+				want []float64
+			}{{"feed", metrics.FeedLatency, tt.feed}, {"processing", metrics.ProcessingLatency, tt.processing}} {
+				count, sum := histogram(t, h.vec, tt.update.Exchange)
+				var wantSum float64
+				for _, v := range h.want {
+					wantSum += v
+				}
+				if count != uint64(len(h.want)) || sum < wantSum-1e-9 || sum > wantSum+1e-9 {
+					t.Errorf("%s latency: %d observations, sum %v; want %v", h.name, count, sum, h.want)
+				}
+			}
+		})
+	}
+}
+
+func histogram(t *testing.T, vec *prometheus.HistogramVec, exchange string) (uint64, float64) {
+	t.Helper()
+	var m dto.Metric
+	if err := vec.WithLabelValues(exchange).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
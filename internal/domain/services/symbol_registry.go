@@ -0,0 +1,115 @@
+package services
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"marketflow/internal/domain/models"
+)
+
+// AnyExchange — алиас действует для всех бирж.
+const AnyExchange = "*"
+
+// maxUnknownSymbols ограничивает число отдельно учитываемых неизвестных символов:
+// шумный фид не должен раздувать память. Остальные считаются под "<exchange>:*".
+const maxUnknownSymbols = 1000
+
+type SymbolConfig struct {
+	Tracked []string                     // пары в виде BTCUSDT или BTC/USDT
+	Aliases map[string]map[string]string // exchange -> символ биржи -> каноническая пара
+}
+
+// SymbolRegistry приводит символы бирж к каноническим парам и
+// пропускает дальше только пары из белого списка.
+type SymbolRegistry struct {
+	logger  *slog.Logger
+	tracked map[string]models.Pair       // нормализованный символ -> пара
+	aliases map[string]map[string]string // exchange -> нормализованный символ -> канонический символ
+
+	mu      sync.Mutex
+	unknown map[string]int64 // exchange:символ -> сколько раз отброшен, для редких записей в лог
+}
+
+func NewSymbolRegistry(cfg SymbolConfig, logger *slog.Logger) *SymbolRegistry {
+	r := &SymbolRegistry{
+		logger:  logger,
+		tracked: make(map[string]models.Pair),
+		aliases: make(map[string]map[string]string),
+		unknown: make(map[string]int64),
+	}
+
+	for _, symbol := range cfg.Tracked {
+		pair, ok := models.ParsePair(symbol)
+		if !ok {
+			logger.Warn("Cannot split tracked pair into base/quote, skipping", "pair", symbol)
+			continue
+		}
+		r.tracked[pair.Symbol()] = pair
+	}
+
+	for exchange, aliases := range cfg.Aliases {
+		normalized := make(map[string]string, len(aliases))
+		for from, to := range aliases {
+			normalized[normalizeSymbol(from)] = normalizeSymbol(to)
+		}
+		r.aliases[exchange] = normalized
+	}
+
+	return r
+}
+
+// Normalize возвращает каноническую пару для символа биржи.
+// Неизвестные и не отслеживаемые символы учитываются и отбрасываются.
+func (r *SymbolRegistry) Normalize(exchange, symbol string) (models.Pair, bool) {
+	if pair, ok := r.Lookup(exchange, symbol); ok {
+		return pair, true
+	}
+
+	unknownKey := exchange + ":" + symbol
+	r.mu.Lock()
+	if _, seen := r.unknown[unknownKey]; !seen && len(r.unknown) >= maxUnknownSymbols {
+		unknownKey, symbol = exchange+":"+AnyExchange, AnyExchange
+	}
+	r.unknown[unknownKey]++
+	count := r.unknown[unknownKey]
+	r.mu.Unlock()
+
+	// Логируем первое появление и дальше изредка, чтобы не заливать лог
+	if count == 1 || count%1000 == 0 {
+		r.logger.Warn("Dropping unknown symbol", "exchange", exchange, "symbol", symbol, "count", count)
+	}
+	return models.Pair{}, false
+}
+
+// Lookup — Normalize без учёта неизвестных символов: для меток и проверок,
+// которые не отбрасывают тик.
+func (r *SymbolRegistry) Lookup(exchange, symbol string) (models.Pair, bool) {
+	key := normalizeSymbol(symbol)
+	if alias, ok := r.aliases[exchange][key]; ok {
+		key = alias
+	} else if alias, ok := r.aliases[AnyExchange][key]; ok {
+		key = alias
+	}
+	pair, ok := r.tracked[key]
+	return pair, ok
+}
+
+// Pairs возвращает отслеживаемые пары.
+func (r *SymbolRegistry) Pairs() []models.Pair {
+	pairs := make([]models.Pair, 0, len(r.tracked))
+	for _, p := range r.tracked {
+		pairs = append(pairs, p)
+	}
+	return pairs
+}
+
+func normalizeSymbol(symbol string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '-', '_', ':', ' ', '.':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(symbol)))
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestSymbolRegistryUnknownIsBounded(t *testing.T) {
+	r := NewSymbolRegistry(SymbolConfig{Tracked: []string{"BTCUSDT"}}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for i := range 3 * maxUnknownSymbols {
+		if _, ok := r.Normalize("ex", fmt.Sprintf("JUNK%dUSDT", i)); ok {
+			t.Fatal("unknown symbol accepted")
+		}
+	}
+	if _, ok := r.Normalize("ex", "btc-usdt"); !ok {
+		t.Fatal("tracked symbol rejected")
+	}
+
+	r.mu.Lock()
+	counts := r.unknown
+	r.mu.Unlock()
+	if len(counts) > maxUnknownSymbols+1 {
+		t.Errorf("tracking %d unknown symbols, want at most %d", len(counts), maxUnknownSymbols+1)
+	}
+	if got := counts["ex:*"]; got != 2*maxUnknownSymbols {
+		t.Errorf("overflow count = %d, want %d", got, 2*maxUnknownSymbols)
+	}
+}
+
+func newSymbolRegistry(aliases map[string]map[string]string) *SymbolRegistry {
+	return NewSymbolRegistry(SymbolConfig{
+		Tracked: []string{"BTCUSDT", "ETH/USDT", "sol-usdc"},
+		Aliases: aliases,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// Регистр, пробелы и разделители не важны: все формы дают одну пару.
+func TestSymbolRegistryNormalizesSymbols(t *testing.T) {
+	r := newSymbolRegistry(nil)
+	for symbol, want := range map[string]string{
+		"BTCUSDT":    "BTC/USDT",
+		"btcusdt":    "BTC/USDT",
+		" BTC/USDT ": "BTC/USDT",
+		"btc-usdt":   "BTC/USDT",
+		"Btc_Usdt":   "BTC/USDT",
+		"btc:usdt":   "BTC/USDT",
+		"ETHUSDT":    "ETH/USDT",
+		"eth.usdt":   "ETH/USDT",
+		"SOLUSDC":    "SOL/USDC",
+	} {
+		pair, ok := r.Normalize("ex", symbol)
+		if !ok || pair.String() != want {
+			t.Errorf("Normalize(%q) = %v, %v; want %s", symbol, pair, ok, want)
+		}
+	}
+}
+
+func TestSymbolRegistryDropsUnknownSymbols(t *testing.T) {
+	r := newSymbolRegistry(nil)
+	// XBT — символ Kraken для BTC; без алиаса это другая пара
+	for _, symbol := range []string{"XRPUSDT", "BTCUSDC", "XBTUSDT", "BTC", "", "ETH/USDTX"} {
+		if pair, ok := r.Normalize("ex", symbol); ok {
+			t.Errorf("Normalize(%q) = %v, want dropped", symbol, pair)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if got := r.unknown["ex:XRPUSDT"]; got != 1 {
+		t.Errorf("unknown XRPUSDT count = %d, want 1", got)
+	}
+}
+
+// Алиас биржи важнее общего; общий действует для бирж без своего алиаса.
+func TestSymbolRegistryAliases(t *testing.T) {
+	r := newSymbolRegistry(map[string]map[string]string{
+		"kraken":    {"XBT/USDT": "BTCUSDT", "xdg-usdt": "DOGEUSDT"},
+		AnyExchange: {"XBTUSDT": "eth/usdt", "WETHUSDT": "ETHUSDT"},
+	})
+
+	tests := []struct {
+		exchange, symbol string
+		want             string // пусто — символ отброшен
+	}{
+		{"kraken", "XBTUSDT", "BTC/USDT"},
+		{"kraken", "xbt-usdt", "BTC/USDT"},
+		{"kraken", "WETH/USDT", "ETH/USDT"},
+		{"binance", "XBTUSDT", "ETH/USDT"},
+		{"binance", "weth_usdt", "ETH/USDT"},
+		{"kraken", "BTCUSDT", "BTC/USDT"},
+		{"kraken", "XDGUSDT", ""}, // алиас ведёт на пару вне белого списка
+	}
+	for _, tt := range tests {
+		pair, ok := r.Normalize(tt.exchange, tt.symbol)
+		got := ""
+		if ok {
+			got = pair.String()
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q, %q) = %q, want %q", tt.exchange, tt.symbol, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/metrics"
+)
+
+// dropLogInterval — как часто очередь пишет в лог о потерях; счётчик — в метрике.
+const dropLogInterval = 10 * time.Second
+
+// tickQueue — очередь тиков одной биржи между слушателем и сборщиком.
+// У каждой биржи своя очередь, поэтому всплеск на одной не вытесняет тики других.
+type tickQueue struct {
+	exchange string
+	cfg      models.QueueConfig
+	symbols  *SymbolRegistry // для метки pair у потерянных тиков; nil — без пары
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	buf      []models.PriceUpdate // кольцевой буфер на Buffer тиков
+	head     int                  // индекс самого старого
+	n        int
+	notEmpty chan struct{} // сигнал для Pop, ёмкость 1
+	notFull  chan struct{} // сигнал для Push в режиме block, ёмкость 1
+
+	// Потери с последней записи в лог и её время; под mu
+	drops       int
+	lastDropLog time.Time
+
+	// Тики, принятые Push и ещё не записанные в Redis и не отброшенные; под mu.
+	// idle закрыт, пока inFlight == 0.
+	inFlight int
+	idle     chan struct{}
+}
+
+func newTickQueue(exchange string, cfg models.QueueConfig, symbols *SymbolRegistry, logger *slog.Logger) *tickQueue {
+	if cfg.Buffer < 1 {
+		cfg.Buffer = 1
+	}
+	idle := make(chan struct{})
+	close(idle)
+	return &tickQueue{
+		exchange: exchange,
+		cfg:      cfg,
+		symbols:  symbols,
+		logger:   logger,
+		buf:      make([]models.PriceUpdate, cfg.Buffer),
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+		idle:     idle,
+	}
+}
+
+// Push ставит тик в очередь по политике и учитывает его до Done. false —
+// контекст отменён, пока Push ждал места.
+func (q *tickQueue) Push(ctx context.Context, update models.PriceUpdate) bool {
+	q.track(1)
+	var blockedSince time.Time
+	for {
+		q.mu.Lock()
+		if q.n < len(q.buf) {
+			q.buf[(q.head+q.n)%len(q.buf)] = update
+			q.n++
+			q.mu.Unlock()
+			q.signal(q.notEmpty)
+			q.depth()
+			if !blockedSince.IsZero() {
+				metrics.QueueBlocked.WithLabelValues(q.exchange).Add(time.Since(blockedSince).Seconds())
+			}
+			return true
+		}
+
+		switch q.cfg.Policy {
+		case models.PolicyBlock:
+			q.mu.Unlock()
+			if blockedSince.IsZero() {
+				blockedSince = time.Now()
+			}
+			select {
+			case <-q.notFull:
+				continue
+			case <-ctx.Done():
+				q.Done()
+				return false
+			}
+		case models.PolicyDropOldest:
+			lost := q.evictOldest(update)
+			q.mu.Unlock()
+			q.dropped(lost, metrics.DropEvicted)
+		case models.PolicyCoalesce:
+			var lost models.PriceUpdate
+			reason := metrics.DropEvicted
+			if i := q.pending(update.Pair); i >= 0 {
+				lost, q.buf[i] = q.buf[i], update
+				reason = metrics.DropCoalesced
+			} else {
+				lost = q.evictOldest(update)
+			}
+			q.mu.Unlock()
+			q.dropped(lost, reason)
+		default: // models.PolicyDropNewest
+			q.mu.Unlock()
+			q.dropped(update, metrics.DropQueueFull)
+		}
+		return true
+	}
+}
+
+// Pop отдаёт самый старый тик, дожидаясь его. false — контекст отменён.
+func (q *tickQueue) Pop(ctx context.Context) (models.PriceUpdate, bool) {
+	for {
+		q.mu.Lock()
+		if q.n > 0 {
+			update := q.buf[q.head]
+			q.buf[q.head] = models.PriceUpdate{}
+			q.head = (q.head + 1) % len(q.buf)
+			q.n--
+			q.mu.Unlock()
+			q.signal(q.notFull)
+			q.depth()
+			return update, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notEmpty:
+		case <-ctx.Done():
+			return models.PriceUpdate{}, false
+		}
+	}
+}
+
+// Done отмечает, что тик из очереди записан в Redis или отброшен сборщиком.
+func (q *tickQueue) Done() { q.track(-1) }
+
+// Idle закрыт, когда каждый принятый тик записан или отброшен.
+func (q *tickQueue) Idle() <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.idle
+}
+
+func (q *tickQueue) track(delta int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.inFlight == 0 && delta > 0 {
+		q.idle = make(chan struct{})
+	}
+	q.inFlight += delta
+	if q.inFlight == 0 && delta < 0 {
+		close(q.idle)
+	}
+}
+
+func (q *tickQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.n
+}
+
+// evictOldest вызывается под mu при полной очереди: новый тик занимает место
+// самого старого. Возвращает вытесненный тик.
+func (q *tickQueue) evictOldest(update models.PriceUpdate) models.PriceUpdate {
+	lost := q.buf[q.head]
+	q.buf[q.head] = update
+	q.head = (q.head + 1) % len(q.buf)
+	return lost
+}
+
+// pending — индекс в buf самого свежего ожидающего тика пары (символ биржи) или -1; под mu.
+func (q *tickQueue) pending(pair string) int {
+	for k := q.n - 1; k >= 0; k-- {
+		if i := (q.head + k) % len(q.buf); q.buf[i].Pair == pair {
+			return i
+		}
+	}
+	return -1
+}
+
+// dropped считает каждый потерянный тик в метрике, а в лог пишет не чаще
+// dropLogInterval — с числом потерь с прошлой записи. В очереди символы ещё
+// сырые, поэтому метка pair — каноническая пара, а для неизвестного символа
+// пустая, как у остальных потерь до нормализации: иначе шумный фид раздует
+// число рядов метрики.
+func (q *tickQueue) dropped(lost models.PriceUpdate, reason string) {
+	metrics.TicksDropped.WithLabelValues(q.exchange, q.pairLabel(lost.Pair), reason).Inc()
+	q.Done()
+
+	q.mu.Lock()
+	q.drops++
+	now := time.Now()
+	if now.Sub(q.lastDropLog) < dropLogInterval {
+		q.mu.Unlock()
+		return
+	}
+	drops := q.drops
+	q.drops, q.lastDropLog = 0, now
+	q.mu.Unlock()
+
+	q.logger.Warn("Exchange queue full, dropping ticks", "exchange", q.exchange, "policy", q.cfg.Policy, "reason", reason, "dropped", drops)
+}
+
+func (q *tickQueue) pairLabel(symbol string) string {
+	if q.symbols == nil {
+		return ""
+	}
+	if pair, ok := q.symbols.Lookup(q.exchange, symbol); ok {
+		return pair.Symbol()
+	}
+	return ""
+}
+
+func (q *tickQueue) depth() {
+	metrics.QueueDepth.WithLabelValues(q.exchange).Set(float64(q.Len()))
+}
+
+func (q *tickQueue) signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func tick(pair, price string) models.PriceUpdate {
+	return models.PriceUpdate{Exchange: "ex", Pair: pair, Price: models.MustDecimal(price)}
+}
+
+// drain возвращает "PAIR:price" всех ожидающих тиков по порядку.
+func drain(t *testing.T, q *tickQueue) []string {
+	t.Helper()
+	var got []string
+	for q.Len() > 0 {
+		update, _ := q.Pop(context.Background())
+		got = append(got, update.Pair+":"+update.Price.String())
+	}
+	return got
+}
+
+func TestTickQueuePolicies(t *testing.T) {
+	in := []models.PriceUpdate{
+		tick("BTC", "1"), tick("ETH", "10"), tick("BTC", "2"), tick("SOL", "100"), tick("BTC", "3"),
+	}
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{models.PolicyDropNewest, []string{"BTC:1", "ETH:10", "BTC:2"}},
+		{models.PolicyDropOldest, []string{"BTC:2", "SOL:100", "BTC:3"}},
+		// SOL вытесняет самый старый (BTC:1), BTC:3 заменяет ожидающий BTC:2
+		{models.PolicyCoalesce, []string{"ETH:10", "BTC:3", "SOL:100"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			q := newTickQueue("ex", models.QueueConfig{Policy: tt.policy, Buffer: 3}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			for _, update := range in {
+				if !q.Push(context.Background(), update) {
+					t.Fatal("Push returned false")
+				}
+			}
+			if got := drain(t, q); !slices.Equal(got, tt.want) {
+				t.Errorf("queue = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTickQueueBlock(t *testing.T) {
+	q := newTickQueue("ex", models.QueueConfig{Policy: models.PolicyBlock, Buffer: 1}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	q.Push(ctx, tick("BTC", "1"))
+
+	pushed := make(chan bool)
+	go func() { pushed <- q.Push(ctx, tick("BTC", "2")) }()
+	select {
+	case <-pushed:
+		t.Fatal("Push into a full queue did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if update, _ := q.Pop(ctx); update.Price.String() != "1" {
+		t.Errorf("Pop = %s, want 1", update.Price)
+	}
+	if !<-pushed {
+		t.Fatal("blocked Push returned false")
+	}
+	if got := drain(t, q); !slices.Equal(got, []string{"BTC:2"}) {
+		t.Errorf("queue = %v, want [BTC:2]", got)
+	}
+
+	// отмена контекста освобождает заблокированный Push
+	q.Push(ctx, tick("BTC", "3"))
+	cancelled, cancel := context.WithCancel(ctx)
+	go func() { pushed <- q.Push(cancelled, tick("BTC", "4")) }()
+	cancel()
+	if <-pushed {
+		t.Error("Push after cancel returned true")
+	}
+}
+
+func TestTickQueuePopWaits(t *testing.T) {
+	q := newTickQueue("ex", models.QueueConfig{Policy: models.PolicyDropNewest, Buffer: 2}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		q.Push(context.Background(), tick("BTC", "1"))
+	}()
+	update, ok := q.Pop(context.Background())
+	if !ok || update.Price.String() != "1" {
+		t.Errorf("Pop = %v, %t", update, ok)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, ok := q.Pop(ctx); ok {
+		t.Error("Pop on an empty queue returned ok after cancel")
+	}
+}
+
+func TestTickQueueDropLogIsRateLimited(t *testing.T) {
+	var out bytes.Buffer
+	q := newTickQueue("ex", models.QueueConfig{Policy: models.PolicyDropNewest, Buffer: 1}, nil, slog.New(slog.NewTextHandler(&out, nil)))
+	for range 100 {
+		q.Push(context.Background(), tick("BTC", "1"))
+	}
+	if n := strings.Count(out.String(), "dropping ticks"); n != 1 {
+		t.Fatalf("%d drop log records, want 1:\n%s", n, out.String())
+	}
+
+	// По истечении интервала запись говорит, сколько тиков потеряно с прошлой
+	q.mu.Lock()
+	q.lastDropLog = time.Now().Add(-dropLogInterval)
+	q.mu.Unlock()
+	out.Reset()
+	q.Push(context.Background(), tick("BTC", "2"))
+	if !strings.Contains(out.String(), "dropped=99") {
+		t.Errorf("drop log = %q, want dropped=99", out.String())
+	}
+}
+
+// Потерянный тик считается под своей канонической парой; неизвестный символ — под пустой.
+func TestTickQueueDropMetricHasPair(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	symbols := NewSymbolRegistry(SymbolConfig{Tracked: []string{"BTCUSDT", "ETHUSDT"}}, logger)
+	dropped := func(exchange, pair, reason string) float64 {
+		return testutil.ToFloat64(metrics.TicksDropped.WithLabelValues(exchange, pair, reason))
+	}
+	push := func(q *tickQueue, symbols ...string) {
+		for _, symbol := range symbols {
+			q.Push(context.Background(), models.PriceUpdate{Exchange: q.exchange, Pair: symbol, Price: models.MustDecimal("1")})
+		}
+	}
+
+	// вытесняются btc-usdt, JUNK, ETHUSDT — по одному
+	oldest := newTickQueue("drop-oldest-ex", models.QueueConfig{Policy: models.PolicyDropOldest, Buffer: 2}, symbols, logger)
+	push(oldest, "btc-usdt", "JUNK", "ETHUSDT", "BTCUSDT", "ETH/USDT")
+	for _, pair := range []string{"BTCUSDT", "", "ETHUSDT"} {
+		if got := dropped("drop-oldest-ex", pair, metrics.DropEvicted); got != 1 {
+			t.Errorf("drop-oldest %q evicted = %v, want 1", pair, got)
+		}
+	}
+
+	newest := newTickQueue("drop-newest-ex", models.QueueConfig{Policy: models.PolicyDropNewest, Buffer: 1}, symbols, logger)
+	push(newest, "BTCUSDT", "eth-usdt", "eth_usdt", "JUNK")
+	if got := dropped("drop-newest-ex", "ETHUSDT", metrics.DropQueueFull); got != 2 {
+		t.Errorf("drop-newest ETHUSDT = %v, want 2", got)
+	}
+	if got := dropped("drop-newest-ex", "", metrics.DropQueueFull); got != 1 {
+		t.Errorf("drop-newest unknown = %v, want 1", got)
+	}
+
+	coalesce := newTickQueue("coalesce-ex", models.QueueConfig{Policy: models.PolicyCoalesce, Buffer: 1}, symbols, logger)
+	push(coalesce, "BTCUSDT", "BTCUSDT")
+	if got := dropped("coalesce-ex", "BTCUSDT", metrics.DropCoalesced); got != 1 {
+		t.Errorf("coalesced BTCUSDT = %v, want 1", got)
+	}
+}
+
+// Очередь простаивает, когда каждый принятый тик отмечен Done или потерян.
+func TestTickQueueIdle(t *testing.T) {
+	q := newTickQueue("ex", models.QueueConfig{Policy: models.PolicyDropNewest, Buffer: 1}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	idle := func() bool {
+		select {
+		case <-q.Idle():
+			return true
+		default:
+			return false
+		}
+	}
+	if !idle() {
+		t.Fatal("new queue is not idle")
+	}
+
+	q.Push(context.Background(), tick("BTC", "1"))
+	q.Push(context.Background(), tick("BTC", "2")) // потерян: очередь полна
+	if idle() {
+		t.Fatal("idle with a tick in the queue")
+	}
+	q.Pop(context.Background())
+	if idle() {
+		t.Fatal("idle before the popped tick is done")
+	}
+	q.Done()
+	if !idle() {
+		t.Fatal("not idle after every tick is done or dropped")
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+	applog "marketflow/pkg/logger"
+)
+
+// После стольких отказов подряд по отклонению/скачку считаем, что рынок
+// действительно сдвинулся, и начинаем историю пары заново.
+const maxConsecutiveRejects = 20
+
+// ValidationRules задаёт правила для одной пары. Нулевое значение отключает правило.
+type ValidationRules struct {
+	RequirePositive bool
+	MaxDeviation    float64       // допустимое отклонение от скользящей медианы (доля, 0.1 = 10%)
+	MedianWindow    int           // сколько последних принятых цен учитывается в медиане
+	MaxJumpPerSec   float64       // допустимое изменение относительно прошлой цены за секунду (доля)
+	MaxTickAge      time.Duration // тики старше этого считаются устаревшими
+}
+
+type ValidationConfig struct {
+	Default ValidationRules
+	Pairs   map[string]ValidationRules
+}
+
+type tickHistory struct {
+	prices    []float64 // последние принятые цены, по кругу
+	next      int
+	lastPrice float64
+	lastAt    time.Time
+	rejects   int
+}
+
+// TickValidator отсекает битые тики до того, как они попадут в Redis и агрегаты.
+// Отклонённые тики сохраняются в карантин с кодом причины.
+type TickValidator struct {
+	cfg        ValidationConfig
+	repo       output.QuarantineRepository
+	logger     *slog.Logger
+	rejectLog  *slog.Logger // отказы идут пачками на каждый битый тик — семплируются
+	quarantine chan models.QuarantinedTick
+	stopped    chan struct{} // закрывается, когда Run вышел
+
+	mu      sync.Mutex
+	history map[string]*tickHistory // exchange:pair -> history
+}
+
+func NewTickValidator(cfg ValidationConfig, repo output.QuarantineRepository, logger *slog.Logger) *TickValidator {
+	return &TickValidator{
+		cfg:        cfg,
+		repo:       repo,
+		logger:     logger,
+		rejectLog:  applog.Sampled(logger),
+		quarantine: make(chan models.QuarantinedTick, 1000),
+		stopped:    make(chan struct{}),
+		history:    make(map[string]*tickHistory),
+	}
+}
+
+// Run записывает отклонённые тики в репозиторий, пока не отменён контекст,
+// и дописывает оставшиеся в очереди перед выходом.
+func (v *TickValidator) Run(ctx context.Context) {
+	defer close(v.stopped)
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case tick := <-v.quarantine:
+					v.store(tick)
+				default:
+					return
+				}
+			}
+		case tick := <-v.quarantine:
+			v.store(tick)
+		}
+	}
+}
+
+func (v *TickValidator) store(tick models.QuarantinedTick) {
+	if err := v.repo.InsertQuarantinedTick(tick); err != nil {
+		v.logger.Error("Failed to quarantine tick", "exchange", tick.Update.Exchange, "pair", tick.Update.Pair, "error", err)
+	}
+}
+
+// Validate возвращает ok=true, если тик можно пускать дальше по конвейеру,
+// иначе — код причины отказа.
+// Если очередь карантина полна, отклонённый тик не сохраняется.
+func (v *TickValidator) Validate(update models.PriceUpdate) (reason string, ok bool) {
+	return v.validate(update, time.Now(), false)
+}
+
+// ValidateHistorical проверяет тик из истории: возраст считается от его же времени,
+// поэтому правило устаревания не срабатывает, а скачки и отклонения — как в живом потоке.
+// Бэкфилл не спешит: при полной очереди карантина ждёт места, пока работает Run.
+func (v *TickValidator) ValidateHistorical(update models.PriceUpdate) (reason string, ok bool) {
+	return v.validate(update, update.Timestamp, true)
+}
+
+func (v *TickValidator) validate(update models.PriceUpdate, now time.Time, wait bool) (reason string, ok bool) {
+	reason, detail := v.check(update, now)
+	if reason == "" {
+		return "", true
+	}
+
+	v.rejectLog.Warn("Tick rejected",
+		"exchange", update.Exchange,
+		"pair", update.Pair,
+		"price", update.Price,
+		"reason", reason,
+		"detail", detail,
+	)
+
+	tick := models.QuarantinedTick{
+		Update:     update,
+		Reason:     reason,
+		Detail:     detail,
+		RejectedAt: time.Now(),
+	}
+	if wait {
+		select {
+		case v.quarantine <- tick:
+		case <-v.stopped:
+		}
+		return reason, false
+	}
+	select {
+	case v.quarantine <- tick:
+	default:
+		v.logger.Warn("Quarantine queue full, dropping rejected tick", "exchange", update.Exchange, "pair", update.Pair)
+	}
+	return reason, false
+}
+
+func (v *TickValidator) rules(pair string) ValidationRules {
+	if rules, ok := v.cfg.Pairs[pair]; ok {
+		return rules
+	}
+	return v.cfg.Default
+}
+
+func (v *TickValidator) check(update models.PriceUpdate, now time.Time) (reason, detail string) {
+	rules := v.rules(update.Pair)
+	price := update.Price.Float64()
+
+	if rules.RequirePositive && update.Price.Sign() <= 0 {
+		return models.RejectNonPositive, fmt.Sprintf("price %s", update.Price)
+	}
+	if rules.MaxTickAge > 0 {
+		// Возраст по времени биржи: задержка фида видна, даже если тик пришёл только что
+		if age := now.Sub(update.EventTime()); age > rules.MaxTickAge {
+			return models.RejectStale, fmt.Sprintf("age %s > %s", age, rules.MaxTickAge)
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := update.Exchange + ":" + update.Pair
+	h, ok := v.history[key]
+	if !ok {
+		h = &tickHistory{}
+		v.history[key] = h
+	}
+
+	if h.rejects >= maxConsecutiveRejects {
+		v.logger.Warn("Too many consecutive rejects, resetting price history", "key", key)
+		*h = tickHistory{}
+	}
+
+	if rules.MaxJumpPerSec > 0 && h.lastPrice > 0 {
+		elapsed := math.Max(update.Timestamp.Sub(h.lastAt).Seconds(), 1)
+		jump := math.Abs(price-h.lastPrice) / h.lastPrice
+		if jump > rules.MaxJumpPerSec*elapsed {
+			h.rejects++
+			return models.RejectJump, fmt.Sprintf("jump %.4f from %v in %.1fs", jump, h.lastPrice, elapsed)
+		}
+	}
+
+	if rules.MaxDeviation > 0 && rules.MedianWindow > 0 && len(h.prices) == rules.MedianWindow {
+		median := medianOf(h.prices)
+		if median > 0 {
+			deviation := math.Abs(price-median) / median
+			if deviation > rules.MaxDeviation {
+				h.rejects++
+				return models.RejectDeviation, fmt.Sprintf("deviation %.4f from median %v", deviation, median)
+			}
+		}
+	}
+
+	h.rejects = 0
+	h.lastPrice = price
+	h.lastAt = update.Timestamp
+	if rules.MedianWindow > 0 {
+		if len(h.prices) < rules.MedianWindow {
+			h.prices = append(h.prices, price)
+		} else {
+			h.prices[h.next] = price
+		}
+		h.next = (h.next + 1) % rules.MedianWindow
+	}
+
+	return "", ""
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+// quarantineRecorder — репозиторий карантина; пока открыт gate, запись ждёт.
+type quarantineRecorder struct {
+	gate chan struct{}
+
+	mu    sync.Mutex
+	ticks []models.QuarantinedTick
+}
+
+func (r *quarantineRecorder) InsertQuarantinedTick(tick models.QuarantinedTick) error {
+	if r.gate != nil {
+		<-r.gate
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ticks = append(r.ticks, tick)
+	return nil
+}
+
+func (r *quarantineRecorder) stored() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.ticks)
+}
+
+func newValidator(rules ValidationRules, repo *quarantineRecorder) *TickValidator {
+	return NewTickValidator(ValidationConfig{Default: rules}, repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func priceAt(price string, at time.Time) models.PriceUpdate {
+	return models.PriceUpdate{Exchange: "ex", Pair: "BTCUSDT", Price: models.MustDecimal(price), Timestamp: at}
+}
+
+func TestValidatorRules(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		rules ValidationRules
+		ticks []models.PriceUpdate
+		want  []string // причина отказа для каждого тика, "" — принят
+	}{
+		{
+			name:  "non-positive",
+			rules: ValidationRules{RequirePositive: true},
+			ticks: []models.PriceUpdate{priceAt("1", t0), priceAt("0", t0), priceAt("-1", t0)},
+			want:  []string{"", models.RejectNonPositive, models.RejectNonPositive},
+		},
+		{
+			name:  "jump scales with elapsed time",
+			rules: ValidationRules{MaxJumpPerSec: 0.01},
+			ticks: []models.PriceUpdate{
+				priceAt("100", t0),
+				priceAt("102", t0.Add(time.Second)),     // 2% за секунду
+				priceAt("101.5", t0.Add(time.Second)),   // 1.5% — меньше секунды считается за секунду
+				priceAt("102.5", t0.Add(3*time.Second)), // 2.5% за 3 с от последней принятой
+				priceAt("108", t0.Add(10*time.Second)),  // ~5.4% за 7 с
+			},
+			want: []string{"", models.RejectJump, models.RejectJump, "", ""},
+		},
+		{
+			name:  "deviation from median",
+			rules: ValidationRules{MaxDeviation: 0.1, MedianWindow: 3},
+			ticks: []models.PriceUpdate{
+				priceAt("100", t0),
+				priceAt("200", t0), // медиана ещё не набрана
+				priceAt("101", t0),
+				priceAt("150", t0), // медиана 101
+				priceAt("110", t0), // 8.9%
+				priceAt("90", t0),  // медиана 110, 18%
+			},
+			want: []string{"", "", "", models.RejectDeviation, "", models.RejectDeviation},
+		},
+		{
+			name:  "stale",
+			rules: ValidationRules{MaxTickAge: time.Minute},
+			ticks: []models.PriceUpdate{priceAt("1", time.Now().Add(-2*time.Minute)), priceAt("1", time.Now())},
+			want:  []string{models.RejectStale, ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newValidator(tt.rules, &quarantineRecorder{})
+			for i, tick := range tt.ticks {
+				reason, ok := v.Validate(tick)
+				if reason != tt.want[i] || ok != (tt.want[i] == "") {
+					t.Errorf("tick %d (%s): Validate = %q, %t; want %q", i, tick.Price, reason, ok, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidatorResetsAfterConsecutiveRejects(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	v := newValidator(ValidationRules{MaxJumpPerSec: 0.01}, &quarantineRecorder{})
+
+	v.Validate(priceAt("100", t0))
+	for i := range maxConsecutiveRejects {
+		if _, ok := v.Validate(priceAt("200", t0)); ok {
+			t.Fatalf("reject %d: jump accepted", i)
+		}
+	}
+	// Рынок действительно сдвинулся: история начинается заново с новой цены
+	if reason, ok := v.Validate(priceAt("200", t0)); !ok {
+		t.Fatalf("after %d rejects: %s", maxConsecutiveRejects, reason)
+	}
+}
+
+func TestValidatorHistoricalIgnoresAge(t *testing.T) {
+	v := newValidator(ValidationRules{MaxTickAge: time.Minute}, &quarantineRecorder{})
+	if reason, ok := v.ValidateHistorical(priceAt("1", time.Now().Add(-24*time.Hour))); !ok {
+		t.Errorf("historical tick rejected: %s", reason)
+	}
+}
+
+func TestValidatorQuarantine(t *testing.T) {
+	repo := &quarantineRecorder{gate: make(chan struct{})}
+	v := newValidator(ValidationRules{RequirePositive: true}, repo)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		v.Run(ctx)
+		close(done)
+	}()
+
+	// Исторические тики ждут места в очереди, а не теряются
+	const n = 1500
+	validated := make(chan struct{})
+	go func() {
+		defer close(validated)
+		for range n {
+			v.ValidateHistorical(priceAt("0", time.Now()))
+		}
+	}()
+	// Пока база стоит, очередь заполняется и проверка ждёт
+	time.AfterFunc(50*time.Millisecond, func() { close(repo.gate) })
+	<-validated
+
+	// После остановки Run дописывает очередь
+	cancel()
+	<-done
+	if got := repo.stored(); got != n {
+		t.Errorf("quarantined %d ticks, want %d", got, n)
+	}
+
+	// Run вышел — историческая проверка не зависает
+	if _, ok := v.ValidateHistorical(priceAt("0", time.Now())); ok {
+		t.Error("non-positive tick accepted")
+	}
+}
@@ -0,0 +1,114 @@
+// Package harness поднимает конвейер MarketFlow в тестах: фейковая биржа по TCP,
+// настоящий TCP-клиент и сервис, хранилища в памяти вместо Redis и Postgres.
+package harness
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain/models"
+)
+
+// ExchangeServer — биржа на 127.0.0.1 со случайным портом, говорящая строковым
+// протоколом: одна строка — один тик. Send рассылает строку всем подключённым.
+type ExchangeServer struct {
+	t        testing.TB
+	listener net.Listener
+
+	mu        sync.Mutex
+	conns     []net.Conn
+	connected chan struct{} // сигнал о каждом новом подключении
+	wg        sync.WaitGroup
+}
+
+func NewExchangeServer(t testing.TB) *ExchangeServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &ExchangeServer{
+		t:         t,
+		listener:  listener,
+		connected: make(chan struct{}, 16),
+	}
+	s.wg.Add(1)
+	go s.accept()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *ExchangeServer) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		select {
+		case s.connected <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Config — настройки биржи name для сервиса.
+func (s *ExchangeServer) Config(name string) models.ExchangeConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return models.ExchangeConfig{Name: name, Host: host, Port: port}
+}
+
+// WaitConnected ждёт подключения клиента.
+func (s *ExchangeServer) WaitConnected(timeout time.Duration) {
+	s.t.Helper()
+	select {
+	case <-s.connected:
+	case <-time.After(timeout):
+		s.t.Fatalf("exchange %s: no client connected within %s", s.listener.Addr(), timeout)
+	}
+}
+
+// Send пишет строки всем подключённым клиентам. Соединения, закрытые клиентом,
+// отбрасываются молча — строки в них теряются, как у настоящей биржи.
+func (s *ExchangeServer) Send(lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alive := s.conns[:0]
+	for _, conn := range s.conns {
+		w := bufio.NewWriter(conn)
+		for _, line := range lines {
+			w.WriteString(line)
+			w.WriteByte('\n')
+		}
+		if err := w.Flush(); err != nil {
+			conn.Close()
+			continue
+		}
+		alive = append(alive, conn)
+	}
+	s.conns = alive
+}
+
+// Disconnect обрывает текущие соединения; сервер продолжает принимать новые.
+func (s *ExchangeServer) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *ExchangeServer) Close() {
+	s.listener.Close()
+	s.Disconnect()
+	s.wg.Wait()
+}
@@ -0,0 +1,182 @@
+package harness
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/output/chaos"
+	"marketflow/internal/adapters/output/memory"
+	"marketflow/internal/adapters/output/tcp"
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/ports/output"
+	"marketflow/internal/domain/services"
+)
+
+// PipelineConfig — параметры конвейера под тест. Нулевые поля заменяются
+// значениями по умолчанию: окно 1s, пары BTCUSDT и ETHUSDT, только RequirePositive,
+// очереди бирж на 1000 тиков с политикой block.
+type PipelineConfig struct {
+	Window       time.Duration
+	Pairs        []string
+	Validation   services.ValidationConfig
+	Backpressure models.BackpressureConfig
+	Logger       *slog.Logger
+
+	// Сбои портов (chaos); нулевые значения — без сбоев.
+	ExchangeFaults  chaos.Faults
+	DisconnectEvery time.Duration
+	RedisFaults     chaos.Faults
+	RepoFaults      chaos.Faults
+	Seed            int64 // как в serve: биржа — Seed, Redis — Seed+1, репозиторий — Seed+2
+}
+
+// Pipeline — MarketServiceImpl с настоящим TCP-клиентом и хранилищами в памяти.
+type Pipeline struct {
+	Service   *services.MarketServiceImpl
+	Redis     *memory.RedisClient
+	Repo      *memory.MarketRepo
+	Publisher *memory.Publisher
+
+	t       testing.TB
+	done    chan error
+	inserts *insertCounter
+}
+
+// insertCounter считает попытки записи агрегатов, в том числе неудачные.
+type insertCounter struct {
+	output.MarketRepository
+	n atomic.Int64
+}
+
+func (c *insertCounter) InsertMarketData(ctx context.Context, agg models.Aggregate) error {
+	c.n.Add(1)
+	return c.MarketRepository.InsertMarketData(ctx, agg)
+}
+
+// InsertAttempts — сколько раз агрегатор пытался записать агрегат.
+func (p *Pipeline) InsertAttempts() int64 {
+	return p.inserts.n.Load()
+}
+
+func NewPipeline(t testing.TB, cfg PipelineConfig, exchanges ...models.ExchangeConfig) *Pipeline {
+	t.Helper()
+
+	if cfg.Window == 0 {
+		cfg.Window = time.Second
+	}
+	if len(cfg.Pairs) == 0 {
+		cfg.Pairs = []string{"BTCUSDT", "ETHUSDT"}
+	}
+	if cfg.Validation.Default == (services.ValidationRules{}) && cfg.Validation.Pairs == nil {
+		cfg.Validation.Default = services.ValidationRules{RequirePositive: true}
+	}
+	if cfg.Backpressure.Default == (models.QueueConfig{}) {
+		cfg.Backpressure.Default = models.QueueConfig{Policy: models.PolicyBlock, Buffer: 1000}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	names := make([]string, 0, len(exchanges))
+	for _, ex := range exchanges {
+		names = append(names, ex.Name)
+	}
+
+	p := &Pipeline{
+		Redis:     memory.NewRedisClient(),
+		Repo:      memory.NewMarketRepo(),
+		Publisher: memory.NewPublisher(),
+		t:         t,
+	}
+	var exchangeClient output.ExchangeClient = tcp.NewTCPExchangeClient(cfg.Logger, "")
+	if cfg.ExchangeFaults.Enabled() || cfg.DisconnectEvery > 0 {
+		exchangeClient = chaos.NewExchangeClient(exchangeClient, cfg.ExchangeFaults, cfg.DisconnectEvery, cfg.Seed)
+	}
+	var redisClient output.RedisClient = p.Redis
+	if cfg.RedisFaults.Enabled() {
+		redisClient = chaos.NewRedisClient(p.Redis, cfg.RedisFaults, cfg.Seed+1)
+	}
+	var repo output.MarketRepository = p.Repo
+	if cfg.RepoFaults.Enabled() {
+		repo = chaos.NewMarketRepository(p.Repo, cfg.RepoFaults, cfg.Seed+2)
+	}
+	p.inserts = &insertCounter{MarketRepository: repo}
+
+	p.Service = services.NewMarketService(
+		context.Background(),
+		exchangeClient,
+		p.Publisher,
+		exchanges,
+		cfg.Logger,
+		redisClient,
+		p.inserts,
+		cfg.Window, // REDIS_TTL == окну: тики окна не должны удаляться до агрегации
+		cfg.Window,
+		services.NewSpreadMonitor(services.SpreadConfig{ThresholdBps: 100, MaxQuoteAge: time.Minute}, p.Repo, cfg.Logger),
+		services.NewTickValidator(cfg.Validation, p.Repo, cfg.Logger),
+		services.NewSymbolRegistry(services.SymbolConfig{Tracked: cfg.Pairs}, cfg.Logger),
+		services.NewStalenessMonitor(services.StalenessConfig{Threshold: time.Minute, CheckInterval: time.Second}, names, cfg.Pairs, cfg.Logger),
+		cfg.Backpressure,
+	)
+	return p
+}
+
+// Start запускает сервис в фоне; Stop вызывается автоматически в конце теста.
+func (p *Pipeline) Start() {
+	p.done = make(chan error, 1)
+	go func() { p.done <- p.Service.Start(context.Background()) }()
+	p.t.Cleanup(p.Stop)
+}
+
+// Stop останавливает сервис и ждёт выхода всех горутин.
+func (p *Pipeline) Stop() {
+	if p.done == nil {
+		return
+	}
+	p.Service.Stop()
+	select {
+	case err := <-p.done:
+		if err != nil {
+			p.t.Errorf("service: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		p.t.Errorf("service did not stop within 5s")
+	}
+	p.done = nil
+}
+
+// WaitAggregates ждёт, пока cond не вернёт true на сохранённых агрегатах,
+// и возвращает их. По таймауту тест падает.
+func (p *Pipeline) WaitAggregates(timeout time.Duration, cond func([]models.Aggregate) bool) []models.Aggregate {
+	p.t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		aggs := p.Repo.Aggregates()
+		if cond(aggs) {
+			return aggs
+		}
+		if time.Now().After(deadline) {
+			p.t.Fatalf("aggregates not ready within %s, have %d", timeout, len(aggs))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Merged сводит агрегаты биржи и пары за все окна в один.
+func Merged(aggs []models.Aggregate, exchange, pair string) (models.Aggregate, bool) {
+	var matched []models.Aggregate
+	for _, agg := range aggs {
+		if agg.Exchange == exchange && agg.Pair == pair {
+			matched = append(matched, agg)
+		}
+	}
+	if len(matched) == 0 {
+		return models.Aggregate{}, false
+	}
+	return models.MergeAggregates(matched, matched[0].Timestamp)
+}
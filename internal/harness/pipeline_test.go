@@ -0,0 +1,232 @@
+package harness
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/output/chaos"
+	"marketflow/internal/domain/models"
+	"marketflow/internal/domain/services"
+	"marketflow/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const waitTimeout = 5 * time.Second
+
+// count — сколько тиков биржи и пары уже попало в агрегаты.
+func count(aggs []models.Aggregate, exchange, pair string) int {
+	merged, _ := Merged(aggs, exchange, pair)
+	return merged.Count
+}
+
+func TestPipelineAggregatesTicks(t *testing.T) {
+	ex1 := NewExchangeServer(t)
+	ex2 := NewExchangeServer(t)
+	p := NewPipeline(t, PipelineConfig{}, ex1.Config("ex1"), ex2.Config("ex2"))
+	p.Start()
+	ex1.WaitConnected(waitTimeout)
+	ex2.WaitConnected(waitTimeout)
+
+	ex1.Send(
+		`{"symbol":"BTCUSDT","price":"100"}`,
+		`{"symbol":"BTC-USDT","price":110.5}`,
+		`BTCUSDT:120`,
+		`ETHUSDT 2000`,
+		`{"symbol":"BTCUSDT","price":"-5"}`, // RequirePositive — в карантин
+		`DOGEUSDT:0.1`,                      // не отслеживается
+		`not a tick`,
+	)
+	ex2.Send(`BTCUSDT:99`, `BTCUSDT:101`)
+
+	aggs := p.WaitAggregates(waitTimeout, func(aggs []models.Aggregate) bool {
+		return count(aggs, "ex1", "BTCUSDT") == 3 &&
+			count(aggs, "ex1", "ETHUSDT") == 1 &&
+			count(aggs, "ex2", "BTCUSDT") == 2
+	})
+
+	tests := []struct {
+		exchange, pair string
+		min, max, avg  string
+	}{
+		{"ex1", "BTCUSDT", "100", "120", "110.16666667"},
+		{"ex1", "ETHUSDT", "2000", "2000", "2000"},
+		{"ex2", "BTCUSDT", "99", "101", "100"},
+	}
+	for _, tt := range tests {
+		got, _ := Merged(aggs, tt.exchange, tt.pair)
+		if got.Min.String() != tt.min || got.Max.String() != tt.max {
+			t.Errorf("%s %s: min/max = %s/%s, want %s/%s", tt.exchange, tt.pair, got.Min, got.Max, tt.min, tt.max)
+		}
+		if avg := models.MustDecimal(tt.avg); got.Average.Cmp(avg) != 0 {
+			t.Errorf("%s %s: avg = %s, want %s", tt.exchange, tt.pair, got.Average, tt.avg)
+		}
+	}
+	for _, agg := range aggs {
+		if agg.Pair == "DOGEUSDT" {
+			t.Errorf("untracked pair aggregated: %+v", agg)
+		}
+		if !agg.Timestamp.Equal(agg.Timestamp.Truncate(time.Second)) {
+			t.Errorf("window start %s not aligned to the window", agg.Timestamp)
+		}
+	}
+
+	if ticks := len(p.Publisher.Ticks()); ticks != 6 {
+		t.Errorf("published %d ticks, want 6", ticks)
+	}
+	waitFor(t, func() bool { return len(p.Repo.Quarantined()) == 1 })
+}
+
+// MAX_TICK_AGE отсекает тик по времени биржи из сообщения. У тика без него
+// возраст считается от разбора строки, и он не устаревает.
+func TestPipelineRejectsStaleTicks(t *testing.T) {
+	ex := NewExchangeServer(t)
+	p := NewPipeline(t, PipelineConfig{
+		Validation: services.ValidationConfig{Default: services.ValidationRules{RequirePositive: true, MaxTickAge: time.Minute}},
+	}, ex.Config("stale"))
+	p.Start()
+	ex.WaitConnected(waitTimeout)
+
+	now := time.Now()
+	ex.Send(
+		fmt.Sprintf(`{"symbol":"BTCUSDT","price":"100","ts":%d}`, now.Add(-10*time.Minute).UnixMilli()),
+		fmt.Sprintf(`{"symbol":"BTCUSDT","price":"101","timestamp":%q}`, now.Add(-2*time.Minute).Format(time.RFC3339)),
+		fmt.Sprintf(`{"symbol":"BTCUSDT","price":"102","ts":%d}`, now.UnixMilli()),
+		`BTCUSDT:103`,
+	)
+
+	aggs := p.WaitAggregates(waitTimeout, func(aggs []models.Aggregate) bool { return count(aggs, "stale", "BTCUSDT") == 2 })
+	if got, _ := Merged(aggs, "stale", "BTCUSDT"); got.Min.String() != "102" || got.Max.String() != "103" {
+		t.Errorf("aggregated %s..%s, want only the fresh ticks 102..103", got.Min, got.Max)
+	}
+	waitFor(t, func() bool { return len(p.Repo.Quarantined()) == 2 })
+	for _, q := range p.Repo.Quarantined() {
+		if q.Reason != models.RejectStale {
+			t.Errorf("quarantined %s for %q, want %q", q.Update.Price, q.Reason, models.RejectStale)
+		}
+	}
+}
+
+func TestPipelineReconnects(t *testing.T) {
+	ex := NewExchangeServer(t)
+	p := NewPipeline(t, PipelineConfig{}, ex.Config("ex1"))
+	p.Start()
+	ex.WaitConnected(waitTimeout)
+
+	ex.Send(`BTCUSDT:100`)
+	p.WaitAggregates(waitTimeout, func(aggs []models.Aggregate) bool {
+		return count(aggs, "ex1", "BTCUSDT") == 1
+	})
+
+	ex.Disconnect()
+	ex.WaitConnected(waitTimeout)
+	ex.Send(`BTCUSDT:200`)
+
+	aggs := p.WaitAggregates(waitTimeout, func(aggs []models.Aggregate) bool {
+		return count(aggs, "ex1", "BTCUSDT") == 2
+	})
+	if got, _ := Merged(aggs, "ex1", "BTCUSDT"); got.Max.String() != "200" {
+		t.Errorf("max after reconnect = %s, want 200", got.Max)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within timeout")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Запись в Redis выполняется, но сервис получает ошибку: тик не считается
+// сохранённым, а агрегатор всё равно видит его в ZSet.
+func TestPipelineRedisPartialWrites(t *testing.T) {
+	ex := NewExchangeServer(t)
+	p := NewPipeline(t, PipelineConfig{RedisFaults: chaos.Faults{PartialRate: 1}}, ex.Config("ex1"))
+	p.Start()
+	ex.WaitConnected(waitTimeout)
+
+	ex.Send(`BTCUSDT:100`, `BTCUSDT:300`)
+	aggs := p.WaitAggregates(waitTimeout, func(aggs []models.Aggregate) bool {
+		return count(aggs, "ex1", "BTCUSDT") == 2
+	})
+	if got, _ := Merged(aggs, "ex1", "BTCUSDT"); got.Average.String() != "200" {
+		t.Errorf("avg = %s, want 200", got.Average)
+	}
+}
+
+// Ошибки Postgres не останавливают конвейер: агрегаты не пишутся и не публикуются,
+// тики продолжают идти.
+func TestPipelineRepoErrors(t *testing.T) {
+	ex := NewExchangeServer(t)
+	p := NewPipeline(t, PipelineConfig{RepoFaults: chaos.Faults{ErrorRate: 1}}, ex.Config("ex1"))
+	p.Start()
+	ex.WaitConnected(waitTimeout)
+
+	ex.Send(`BTCUSDT:100`)
+	waitFor(t, func() bool { return len(p.Publisher.Ticks()) == 1 })
+	waitFor(t, func() bool { return p.InsertAttempts() >= 1 }) // окно тика закрыто и посчитано
+
+	ex.Send(`BTCUSDT:200`)
+	waitFor(t, func() bool { return len(p.Publisher.Ticks()) == 2 })
+	if aggs := p.Repo.Aggregates(); len(aggs) != 0 {
+		t.Errorf("stored %d aggregates despite insert errors", len(aggs))
+	}
+	if aggs := p.Publisher.Aggregates(); len(aggs) != 0 {
+		t.Errorf("published %d aggregates that were not stored", len(aggs))
+	}
+}
+
+// Внедрённые обрывы: сервис переподключается, тики после переподключения доходят.
+func TestPipelineInjectedDisconnects(t *testing.T) {
+	ex := NewExchangeServer(t)
+	p := NewPipeline(t, PipelineConfig{DisconnectEvery: 300 * time.Millisecond}, ex.Config("ex1"))
+	p.Start()
+	for range 3 {
+		ex.WaitConnected(waitTimeout)
+	}
+
+	// Строка в уже оборванную сессию теряется — шлём, пока тик не будет принят
+	waitFor(t, func() bool {
+		ex.Send(`BTCUSDT:100`)
+		time.Sleep(50 * time.Millisecond)
+		return len(p.Publisher.Ticks()) > 0
+	})
+}
+
+// Всплеск на одной бирже переполняет только её очередь: тики другой биржи доходят все,
+// лишние тики первой отбрасываются и считаются.
+func TestPipelineBackpressureIsolatesExchanges(t *testing.T) {
+	burst := NewExchangeServer(t)
+	calm := NewExchangeServer(t)
+	p := NewPipeline(t, PipelineConfig{
+		Backpressure: models.BackpressureConfig{Default: models.QueueConfig{Policy: models.PolicyDropNewest, Buffer: 5}},
+		RedisFaults:  chaos.Faults{Latency: 2 * time.Millisecond}, // медленный сборщик
+	}, burst.Config("burst"), calm.Config("calm"))
+	p.Start()
+	burst.WaitConnected(waitTimeout)
+	calm.WaitConnected(waitTimeout)
+
+	lines := make([]string, 500)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("BTCUSDT:%d", 100+i)
+	}
+	burst.Send(lines...)
+	calm.Send(`ETHUSDT:2000`, `ETHUSDT:2001`, `ETHUSDT:2002`)
+
+	aggs := p.WaitAggregates(waitTimeout, func(aggs []models.Aggregate) bool {
+		return count(aggs, "calm", "ETHUSDT") == 3 && count(aggs, "burst", "BTCUSDT") > 0
+	})
+	stored := count(aggs, "burst", "BTCUSDT")
+	dropped := testutil.ToFloat64(metrics.TicksDropped.WithLabelValues("burst", "BTCUSDT", metrics.DropQueueFull))
+	if stored >= len(lines) || dropped == 0 {
+		t.Errorf("burst: stored %d, dropped %g of %d; want drops", stored, dropped, len(lines))
+	}
+	if dropped := testutil.ToFloat64(metrics.TicksDropped.WithLabelValues("calm", "ETHUSDT", metrics.DropQueueFull)); dropped != 0 {
+		t.Errorf("calm: dropped %g ticks", dropped)
+	}
+}
@@ -0,0 +1,320 @@
+// Package mockexchange — локальная замена образам бирж: TCP-сервер, отдающий
+// тот же строковый протокол (строка — тик) со случайным блужданием цен и
+// управляемыми сбоями: битые строки, обрывы соединений, зависания фида.
+package mockexchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Форматы строк; все понимает exchangeproto.ParseMessage.
+const (
+	FormatJSON  = "json"  // {"symbol":"BTCUSDT","price":43250.5,"timestamp":1700000000123}
+	FormatColon = "colon" // BTCUSDT:43250.5
+	FormatSpace = "space" // BTCUSDT 43250.5
+)
+
+type PairConfig struct {
+	Symbol string
+	Price  float64 // начальная цена
+}
+
+type Config struct {
+	Name       string // имя для логов
+	Addr       string // адрес прослушивания, например :40101
+	Pairs      []PairConfig
+	Rate       float64 // тиков в секунду на пару
+	Volatility float64 // стандартное отклонение относительного изменения цены за тик
+	Format     string
+	Seed       int64 // 0 — от текущего времени
+
+	MalformedRatio float64 // доля строк, заменяемых битыми
+	// DisconnectEvery обрывает каждое соединение через этот интервал (0 — никогда).
+	DisconnectEvery time.Duration
+	// StallEvery и StallFor: раз в StallEvery фид молчит StallFor, соединения остаются открытыми.
+	StallEvery time.Duration
+	StallFor   time.Duration
+}
+
+// DefaultPairs — пары и цены, похожие на настоящие фиды.
+var DefaultPairs = []PairConfig{
+	{"BTCUSDT", 43250},
+	{"ETHUSDT", 2300},
+	{"SOLUSDT", 98},
+	{"DOGEUSDT", 0.08},
+	{"TONUSDT", 2.2},
+}
+
+var malformedLines = []string{
+	`{"symbol":"BTCUSDT","price":`,
+	`{"price":100}`,
+	`BTCUSDT:not-a-price`,
+	`garbage`,
+}
+
+// clientBuffer — строк в очереди клиента; медленный клиент теряет лишнее, как у биржи.
+const clientBuffer = 1024
+
+type Server struct {
+	cfg    Config
+	logger *slog.Logger
+	rnd    *rand.Rand // только в горутине генератора
+
+	mu       sync.Mutex
+	listener net.Listener
+	clients  map[net.Conn]chan string
+	wg       sync.WaitGroup
+}
+
+func NewServer(cfg Config, logger *slog.Logger) (*Server, error) {
+	if len(cfg.Pairs) == 0 {
+		return nil, errors.New("no pairs")
+	}
+	if cfg.Rate <= 0 {
+		return nil, fmt.Errorf("rate must be > 0, got %g", cfg.Rate)
+	}
+	switch cfg.Format {
+	case "":
+		cfg.Format = FormatJSON
+	case FormatJSON, FormatColon, FormatSpace:
+	default:
+		return nil, fmt.Errorf("unknown format %q", cfg.Format)
+	}
+	if cfg.MalformedRatio < 0 || cfg.MalformedRatio > 1 {
+		return nil, fmt.Errorf("malformed ratio %g out of range 0-1", cfg.MalformedRatio)
+	}
+	if cfg.StallEvery > 0 && cfg.StallFor <= 0 {
+		return nil, errors.New("stall duration must be > 0")
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Server{
+		cfg:     cfg,
+		logger:  logger.With("exchange", cfg.Name),
+		rnd:     rand.New(rand.NewSource(seed)),
+		clients: make(map[net.Conn]chan string),
+	}, nil
+}
+
+// Listen открывает порт; отдельно от Run, чтобы узнать адрес до запуска (порт 0).
+func (s *Server) Listen() error {
+	listener, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", s.cfg.Addr, err)
+	}
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Run принимает клиентов и рассылает тики до отмены ctx.
+func (s *Server) Run(ctx context.Context) error {
+	if s.Addr() == nil {
+		if err := s.Listen(); err != nil {
+			return err
+		}
+	}
+	s.logger.Info("Mock exchange listening", "address", s.Addr(), "pairs", len(s.cfg.Pairs), "rate", s.cfg.Rate, "format", s.cfg.Format)
+
+	go func() {
+		<-ctx.Done()
+		s.listener.Close()
+	}()
+
+	s.wg.Add(1)
+	go s.generate(ctx)
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			s.logger.Error("Accept failed", "error", err)
+			continue
+		}
+		s.wg.Add(1)
+		go s.serve(ctx, conn)
+	}
+
+	s.wg.Wait()
+	s.logger.Info("Mock exchange stopped")
+	return nil
+}
+
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	defer s.wg.Done()
+	s.logger.Info("Client connected", "remote", conn.RemoteAddr())
+
+	lines := make(chan string, clientBuffer)
+	s.mu.Lock()
+	s.clients[conn] = lines
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	var disconnect <-chan time.Time
+	if s.cfg.DisconnectEvery > 0 {
+		timer := time.NewTimer(s.cfg.DisconnectEvery)
+		defer timer.Stop()
+		disconnect = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-disconnect:
+			s.logger.Info("Dropping client (fault injection)", "remote", conn.RemoteAddr())
+			return
+		case line := <-lines:
+			if _, err := conn.Write([]byte(line + "\n")); err != nil {
+				s.logger.Info("Client disconnected", "remote", conn.RemoteAddr(), "error", err)
+				return
+			}
+		}
+	}
+}
+
+// generate раз в 1/Rate секунды двигает цену каждой пары и рассылает строки всем клиентам.
+func (s *Server) generate(ctx context.Context) {
+	defer s.wg.Done()
+
+	prices := make([]float64, len(s.cfg.Pairs))
+	for i, p := range s.cfg.Pairs {
+		prices[i] = p.Price
+	}
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / s.cfg.Rate))
+	defer ticker.Stop()
+
+	var stallAt, stallUntil time.Time
+	if s.cfg.StallEvery > 0 {
+		stallAt = time.Now().Add(s.cfg.StallEvery)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if !stallAt.IsZero() && !now.Before(stallAt) {
+				s.logger.Info("Stalling feed (fault injection)", "for", s.cfg.StallFor)
+				stallUntil = now.Add(s.cfg.StallFor)
+				stallAt = stallUntil.Add(s.cfg.StallEvery)
+			}
+			if now.Before(stallUntil) {
+				continue
+			}
+
+			for i, p := range s.cfg.Pairs {
+				prices[i] = s.step(prices[i])
+				s.broadcast(s.line(p.Symbol, prices[i], now))
+			}
+		}
+	}
+}
+
+// step — геометрическое случайное блуждание: цена остаётся положительной.
+func (s *Server) step(price float64) float64 {
+	if s.cfg.Volatility <= 0 {
+		return price
+	}
+	return price * math.Exp(s.rnd.NormFloat64()*s.cfg.Volatility)
+}
+
+func (s *Server) line(symbol string, price float64, now time.Time) string {
+	if s.cfg.MalformedRatio > 0 && s.rnd.Float64() < s.cfg.MalformedRatio {
+		return malformedLines[s.rnd.Intn(len(malformedLines))]
+	}
+	return FormatLine(s.cfg.Format, symbol, price, now)
+}
+
+func (s *Server) broadcast(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, lines := range s.clients {
+		select {
+		case lines <- line:
+		default:
+		}
+	}
+}
+
+// FormatLine — строка тика в формате биржи; время — unix в миллисекундах.
+func FormatLine(format, symbol string, price float64, at time.Time) string {
+	p := strconv.FormatFloat(price, 'f', decimals(price), 64)
+	switch format {
+	case FormatColon:
+		return symbol + ":" + p
+	case FormatSpace:
+		return symbol + " " + p
+	default:
+		return fmt.Sprintf(`{"symbol":%q,"price":%s,"timestamp":%d}`, symbol, p, at.UnixMilli())
+	}
+}
+
+// decimals — знаков после запятой: у дешёвых монет больше, чтобы шаг цены был заметен.
+func decimals(price float64) int {
+	if price <= 0 {
+		return 8
+	}
+	return min(max(6-int(math.Floor(math.Log10(price))), 2), 8)
+}
+
+// ParsePairs разбирает "BTCUSDT=43250,ETHUSDT". Цена по умолчанию берётся из
+// DefaultPairs, для неизвестных пар — 100.
+func ParsePairs(raw string) ([]PairConfig, error) {
+	var pairs []PairConfig
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		symbol, rawPrice, hasPrice := strings.Cut(item, "=")
+		pair := PairConfig{Symbol: strings.ToUpper(strings.TrimSpace(symbol)), Price: 100}
+		for _, p := range DefaultPairs {
+			if p.Symbol == pair.Symbol {
+				pair.Price = p.Price
+			}
+		}
+		if hasPrice {
+			price, err := strconv.ParseFloat(strings.TrimSpace(rawPrice), 64)
+			if err != nil || price <= 0 {
+				return nil, fmt.Errorf("invalid price for %s: %q", pair.Symbol, rawPrice)
+			}
+			pair.Price = price
+		}
+		pairs = append(pairs, pair)
+	}
+	if len(pairs) == 0 {
+		return nil, errors.New("no pairs")
+	}
+	return pairs, nil
+}
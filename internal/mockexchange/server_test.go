@@ -0,0 +1,115 @@
+package mockexchange
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/output/exchangeproto"
+)
+
+func startServer(t *testing.T, cfg Config) net.Conn {
+	t.Helper()
+	cfg.Addr = "127.0.0.1:0"
+	server, err := NewServer(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func TestServerLinesParse(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatColon, FormatSpace} {
+		t.Run(format, func(t *testing.T) {
+			conn := startServer(t, Config{
+				Pairs:      DefaultPairs,
+				Rate:       100,
+				Volatility: 0.01,
+				Format:     format,
+				Seed:       1,
+			})
+
+			scanner := bufio.NewScanner(conn)
+			seen := make(map[string]bool)
+			for i := 0; i < 3*len(DefaultPairs) && scanner.Scan(); i++ {
+				update, err := exchangeproto.ParseMessage(scanner.Text(), "mock")
+				if err != nil {
+					t.Fatalf("line %q: %v", scanner.Text(), err)
+				}
+				if update.Price.Sign() <= 0 {
+					t.Errorf("line %q: non-positive price", scanner.Text())
+				}
+				if format == FormatJSON && update.ExchangeTime.IsZero() {
+					t.Errorf("line %q: no exchange time", scanner.Text())
+				}
+				seen[update.Pair] = true
+			}
+			if len(seen) != len(DefaultPairs) {
+				t.Errorf("saw pairs %v, want all %d", seen, len(DefaultPairs))
+			}
+		})
+	}
+}
+
+func TestServerMalformedLines(t *testing.T) {
+	conn := startServer(t, Config{Pairs: DefaultPairs[:1], Rate: 100, MalformedRatio: 1})
+
+	scanner := bufio.NewScanner(conn)
+	for i := 0; i < 10 && scanner.Scan(); i++ {
+		if _, err := exchangeproto.ParseMessage(scanner.Text(), "mock"); err == nil {
+			t.Errorf("malformed line %q parsed", scanner.Text())
+		}
+	}
+}
+
+func TestServerDisconnects(t *testing.T) {
+	conn := startServer(t, Config{Pairs: DefaultPairs[:1], Rate: 100, DisconnectEvery: 100 * time.Millisecond})
+
+	started := time.Now()
+	if _, err := io.Copy(io.Discard, conn); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("connection closed after %s, want ~100ms", elapsed)
+	}
+}
+
+func TestParsePairs(t *testing.T) {
+	pairs, err := ParsePairs("btcusdt, XYZUSDT=1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PairConfig{{"BTCUSDT", 43250}, {"XYZUSDT", 1.5}}
+	if len(pairs) != len(want) || pairs[0] != want[0] || pairs[1] != want[1] {
+		t.Errorf("ParsePairs = %v, want %v", pairs, want)
+	}
+	for _, raw := range []string{"", "BTCUSDT=abc", "BTCUSDT=-1"} {
+		if _, err := ParsePairs(raw); err == nil {
+			t.Errorf("ParsePairs(%q) accepted", raw)
+		}
+	}
+}
@@ -1,12 +1,71 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
-	"os"
+	"strings"
+	"time"
 )
 
-func New() *slog.Logger {
-	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options: Level может быть *slog.LevelVar — тогда уровень меняется на лету.
+// SampleBurst > 0 включает семплирование для логгеров из Sampled: не больше
+// SampleBurst одинаковых записей (уровень + сообщение) за SampleInterval;
+// ошибки не семплируются.
+type Options struct {
+	Level          slog.Leveler
+	Format         string
+	SampleBurst    int
+	SampleInterval time.Duration
+}
+
+func New(w io.Writer, opts Options) *slog.Logger {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+
+	var h slog.Handler
+	if opts.Format == FormatJSON {
+		h = slog.NewJSONHandler(w, handlerOpts)
+	} else {
+		h = slog.NewTextHandler(w, handlerOpts)
+	}
+	if opts.SampleBurst > 0 && opts.SampleInterval > 0 {
+		h = newSampler(h, opts.SampleBurst, opts.SampleInterval)
+	}
+	return slog.New(h)
+}
+
+// Component возвращает логгер подсистемы: все её записи получают component=name.
+func Component(l *slog.Logger, name string) *slog.Logger {
+	return l.With("component", name)
+}
+
+// ParseLevel принимает debug, info, warn и error; пустая строка — info.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", s)
+}
+
+// ParseFormat принимает text и json; пустая строка — text.
+func ParseFormat(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("invalid log format %q: expected text or json", s)
 }
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// sampler ограничивает поток одинаковых записей: предупреждение о битом тике
+// на каждое сообщение биржи забивает лог. В интервал пропускается burst записей
+// с одним уровнем и сообщением, остальные отбрасываются; следующая пропущенная
+// запись несёт suppressed=N.
+//
+// Корневой обработчик только хранит настройки и пропускает всё: семплируются
+// лишь логгеры, полученные через Sampled, — редкие разные события вроде
+// "Pair is stale" по каждой паре не должны теряться за общим сообщением.
+type sampler struct {
+	next     slog.Handler
+	burst    int
+	interval time.Duration
+	state    *samplerState // общее для всех копий из WithAttrs/WithGroup; nil — не семплировать
+}
+
+type samplerState struct {
+	mu      sync.Mutex
+	buckets map[sampleKey]*sampleBucket
+}
+
+type sampleKey struct {
+	level slog.Level
+	msg   string
+}
+
+type sampleBucket struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+func newSampler(next slog.Handler, burst int, interval time.Duration) *sampler {
+	return &sampler{next: next, burst: burst, interval: interval}
+}
+
+// Sampled возвращает логгер для частого места вызова (битые сообщения биржи,
+// отклонённые тики): одинаковые записи через него семплируются по настройкам
+// из Options. У каждого вызова Sampled свои счётчики. Если семплирование
+// выключено, возвращается l.
+func Sampled(l *slog.Logger) *slog.Logger {
+	s, ok := l.Handler().(*sampler)
+	if !ok {
+		return l
+	}
+	return slog.New(&sampler{
+		next:     s.next,
+		burst:    s.burst,
+		interval: s.interval,
+		state:    &samplerState{buckets: make(map[sampleKey]*sampleBucket)},
+	})
+}
+
+func (s *sampler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.next.Enabled(ctx, level)
+}
+
+func (s *sampler) Handle(ctx context.Context, r slog.Record) error {
+	if s.state == nil || r.Level >= slog.LevelError {
+		return s.next.Handle(ctx, r)
+	}
+
+	suppressed, ok := s.state.allow(sampleKey{r.Level, r.Message}, r.Time, s.burst, s.interval)
+	if !ok {
+		return nil
+	}
+	if suppressed > 0 {
+		r = r.Clone()
+		r.AddAttrs(slog.Int("suppressed", suppressed))
+	}
+	return s.next.Handle(ctx, r)
+}
+
+func (s *sampler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sampler{next: s.next.WithAttrs(attrs), burst: s.burst, interval: s.interval, state: s.state}
+}
+
+func (s *sampler) WithGroup(name string) slog.Handler {
+	return &sampler{next: s.next.WithGroup(name), burst: s.burst, interval: s.interval, state: s.state}
+}
+
+// allow возвращает, пропускать ли запись, и сколько записей отброшено с прошлой пропущенной.
+func (st *samplerState) allow(key sampleKey, now time.Time, burst int, interval time.Duration) (int, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	b := st.buckets[key]
+	if b == nil {
+		b = &sampleBucket{start: now}
+		st.buckets[key] = b
+	}
+	if now.Sub(b.start) >= interval {
+		b.start, b.count = now, 0
+	}
+	b.count++
+	if b.count > burst {
+		b.suppressed++
+		return 0, false
+	}
+
+	suppressed := b.suppressed
+	b.suppressed = 0
+	return suppressed, true
+}
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplerAllow(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	type call struct {
+		at         time.Duration
+		ok         bool
+		suppressed int
+	}
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{"burst", []call{{0, true, 0}, {100 * time.Millisecond, true, 0}, {200 * time.Millisecond, true, 0}, {300 * time.Millisecond, false, 0}}},
+		{"interval reset carries suppressed", []call{
+			{0, true, 0}, {0, true, 0}, {0, true, 0},
+			{100 * time.Millisecond, false, 0},
+			{900 * time.Millisecond, false, 0},
+			{time.Second, true, 2},
+			{time.Second + time.Millisecond, true, 0},
+		}},
+		// Счётчик отброшенных переживает несколько интервалов без пропущенных записей
+		{"suppressed carried across intervals", []call{
+			{0, true, 0}, {0, true, 0}, {0, true, 0}, {0, false, 0},
+			{time.Second, true, 1}, {time.Second, true, 0}, {time.Second, true, 0}, {time.Second, false, 0}, {time.Second, false, 0},
+			{2 * time.Second, true, 2},
+		}},
+		{"exact interval boundary", []call{{0, true, 0}, {0, true, 0}, {0, true, 0}, {time.Second - 1, false, 0}, {time.Second, true, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &samplerState{buckets: make(map[sampleKey]*sampleBucket)}
+			key := sampleKey{slog.LevelWarn, "Failed to parse message"}
+			for i, c := range tt.calls {
+				suppressed, ok := st.allow(key, t0.Add(c.at), 3, time.Second)
+				if ok != c.ok || suppressed != c.suppressed {
+					t.Errorf("call %d at +%s: allow = %d, %v; want %d, %v", i, c.at, suppressed, ok, c.suppressed, c.ok)
+				}
+			}
+		})
+	}
+}
+
+func TestSamplerKeys(t *testing.T) {
+	st := &samplerState{buckets: make(map[sampleKey]*sampleBucket)}
+	now := time.Now()
+	warn := sampleKey{slog.LevelWarn, "Tick rejected"}
+	if _, ok := st.allow(warn, now, 1, time.Second); !ok {
+		t.Fatal("first record dropped")
+	}
+	if _, ok := st.allow(warn, now, 1, time.Second); ok {
+		t.Fatal("second record passed the burst")
+	}
+	for _, key := range []sampleKey{{slog.LevelInfo, "Tick rejected"}, {slog.LevelWarn, "Failed to parse message"}} {
+		if _, ok := st.allow(key, now, 1, time.Second); !ok {
+			t.Errorf("%v shares the bucket of %v", key, warn)
+		}
+	}
+}
+
+// Семплируются только логгеры из Sampled, остальные записи проходят все.
+func TestSampledIsOptIn(t *testing.T) {
+	var buf bytes.Buffer
+	root := Component(New(&buf, Options{Level: slog.LevelDebug, SampleBurst: 1, SampleInterval: time.Hour}), "staleness")
+	sampled := Sampled(root)
+
+	for range 3 {
+		root.Warn("Pair is stale", "pair", "BTCUSDT")
+		sampled.Warn("Failed to parse message")
+		sampled.Error("Failed to store tick")
+	}
+	out := buf.String()
+	for msg, want := range map[string]int{"Pair is stale": 3, "Failed to parse message": 1, "Failed to store tick": 3} {
+		if got := strings.Count(out, msg); got != want {
+			t.Errorf("%q logged %d times, want %d", msg, got, want)
+		}
+	}
+	if strings.Count(out, "component=staleness") != 7 {
+		t.Errorf("sampled logger lost the component attribute:\n%s", out)
+	}
+
+	// Без настроек семплирования Sampled возвращает тот же логгер
+	plain := New(&buf, Options{})
+	if Sampled(plain) != plain {
+		t.Error("Sampled wrapped a logger without sampling")
+	}
+}
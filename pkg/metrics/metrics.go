@@ -0,0 +1,169 @@
+// Package metrics — коллекторы Prometheus конвейера. Доменные сервисы импортируют
+// его напрямую, как slog: метрики не считаются портом (см. README, «Метрики»).
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "marketflow"
+
+// Причины, по которым тик не дошёл до Redis
+const (
+	DropParseError    = "parse_error"
+	DropUnknownSymbol = "unknown_symbol"
+	DropQueueFull     = "queue_full" // drop-newest: очередь биржи полна
+	DropEvicted       = "evicted"    // drop-oldest и coalesce: вытеснен самый старый тик
+	DropCoalesced     = "coalesced"  // coalesce: заменён более свежим тиком той же пары
+)
+
+var (
+	TicksReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ticks_received_total",
+		Help:      "Raw lines received from exchanges.",
+	}, []string{"exchange"})
+
+	TicksParsed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ticks_parsed_total",
+		Help:      "Parsed updates with a known symbol, by canonical pair.",
+	}, []string{"exchange", "pair"})
+
+	TicksDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ticks_dropped_total",
+		Help:      "Ticks dropped before storage, by reason. Pair is the canonical pair, empty for unknown or unparsed symbols.",
+	}, []string{"exchange", "pair", "reason"})
+
+	TicksStored = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ticks_stored_total",
+		Help:      "Ticks written to Redis.",
+	}, []string{"exchange", "pair"})
+
+	QueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "exchange_queue_depth",
+		Help:      "Number of updates waiting in the exchange queue before the collector.",
+	}, []string{"exchange"})
+
+	QueueBlocked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "exchange_queue_blocked_seconds_total",
+		Help:      "Time the exchange listener spent waiting for queue space (block policy).",
+	}, []string{"exchange"})
+
+	RedisLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_operation_duration_seconds",
+		Help:      "Latency of Redis operations.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+
+	PostgresLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "postgres_operation_duration_seconds",
+		Help:      "Latency of Postgres operations.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	AggregatorDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "aggregator_run_duration_seconds",
+		Help:      "Duration of one aggregator pass over all keys.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	PublisherDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "publisher_dropped_total",
+		Help:      "Events dropped because a publisher sink queue was full.",
+	}, []string{"sink"})
+
+	PublisherErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "publisher_errors_total",
+		Help:      "Events a publisher sink failed to deliver.",
+	}, []string{"sink"})
+
+	AlertsDelivered = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alerts_delivered_total",
+		Help:      "Alert events delivered to webhooks.",
+	})
+
+	AlertsFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alerts_failed_total",
+		Help:      "Alert events that failed delivery after all retries.",
+	})
+
+	AlertsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alerts_dropped_total",
+		Help:      "Alert events dropped because the delivery queue was full.",
+	})
+
+	// Задержка фида: от времени биржи до чтения строки из сокета.
+	// Только для бирж, присылающих время; отрицательная (расхождение часов) считается нулём.
+	FeedLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "feed_latency_seconds",
+		Help:      "Delay between the exchange timestamp of a tick and its arrival on the socket.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"exchange"})
+
+	ProcessingLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "processing_latency_seconds",
+		Help:      "Delay between reading a tick from the socket and storing it in Redis.",
+		Buckets:   []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"exchange"})
+
+	LastTickAge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_tick_age_seconds",
+		Help:      "Seconds since the last stored tick of a pair on an exchange.",
+	}, []string{"exchange", "pair"})
+
+	PairStale = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pair_stale",
+		Help:      "1 if no tick of the pair arrived from the exchange within the staleness threshold.",
+	}, []string{"exchange", "pair"})
+
+	StaleEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pair_stale_events_total",
+		Help:      "Times a pair went stale on an exchange.",
+	}, []string{"exchange", "pair"})
+
+	Reconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "exchange_reconnects_total",
+		Help:      "Reconnection attempts per exchange.",
+	}, []string{"exchange"})
+
+	ConnectionUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "exchange_connection_up",
+		Help:      "1 if the exchange connection is established.",
+	}, []string{"exchange"})
+
+	// Аптайм соединения считается как time() - connected_since.
+	ConnectedSince = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "exchange_connected_since_seconds",
+		Help:      "Unix time when the current exchange connection was established.",
+	}, []string{"exchange"})
+)
+
+// ObserveSince записывает время, прошедшее с start, в гистограмму операции.
+// Использование: defer metrics.ObserveSince(metrics.RedisLatency, "zadd", time.Now())
+func ObserveSince(h *prometheus.HistogramVec, op string, start time.Time) {
+	h.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
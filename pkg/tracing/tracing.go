@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "marketflow"
+
+// Имена спанов пайплайна: от строки в сокете биржи до агрегата в Postgres
+const (
+	SpanReceive         = "exchange.receive"      // строка из сокета: запись, разбор, постановка в очередь
+	SpanRedisWrite      = "collector.redis_write" // тик в ZSet; родитель — exchange.receive
+	SpanAggregateWindow = "aggregator.window"     // расчёт завершённого окна по всем парам; ссылки на приём тиков окна
+	SpanAggregatePair   = "aggregator.pair"       // чтение цен пары из Redis и расчёт агрегата
+	SpanPostgresInsert  = "postgres.insert"       // upsert агрегата
+)
+
+type Config struct {
+	Endpoint    string // host:port OTLP/HTTP коллектора
+	Insecure    bool   // без TLS — для локального коллектора
+	SampleRatio float64
+	ServiceName string
+}
+
+// Init настраивает экспорт спанов по OTLP/HTTP. Без Init otel использует no-op
+// провайдер: вызовы Start ничего не стоят, поэтому трассировка необязательна.
+// Семплер ParentBased: решение принимается на корневом спане (exchange.receive,
+// aggregator.window) с долей SampleRatio и наследуется дочерними.
+// Возвращённая функция дописывает буфер спанов; вызывать при остановке.
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Attr — атрибут спана.
+type Attr = attribute.KeyValue
+
+func String(key, value string) Attr { return attribute.String(key, value) }
+
+func Int(key string, value int) Attr { return attribute.Int(key, value) }
+
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartLinked открывает спан со ссылками на спаны из traceparents: агрегат окна
+// не продолжает трассу одного тика, но связан со всеми, из которых посчитан.
+func StartLinked(ctx context.Context, name string, traceparents []string, attrs ...Attr) (context.Context, trace.Span) {
+	links := make([]trace.Link, 0, len(traceparents))
+	for _, tp := range traceparents {
+		if sc := spanContext(tp); sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...), trace.WithLinks(links...))
+}
+
+// Контекст трассы переносится в доменных типах строкой W3C traceparent, а
+// атрибуты и статус спанов домен задаёт через String, Int и Fail: пакеты
+// internal/domain импортируют только этот пакет, но не otel.
+var propagator = propagation.TraceContext{}
+
+// TraceParent возвращает спан в формате traceparent; пусто, если спан невалиден
+// (трассировка выключена).
+func TraceParent(span trace.Span) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(trace.ContextWithSpan(context.Background(), span), carrier)
+	return carrier.Get("traceparent")
+}
+
+// WithParent продолжает трассу тика: спан из PriceUpdate.TraceParent
+// становится родителем спанов, открытых на возвращённом контексте.
+func WithParent(ctx context.Context, traceparent string) context.Context {
+	if traceparent == "" {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier{"traceparent": traceparent})
+}
+
+// Sampled сообщает, записывается ли спан traceparent: ссылаться на остальные
+// незачем, их нет в коллекторе.
+func Sampled(traceparent string) bool {
+	return spanContext(traceparent).IsSampled()
+}
+
+func spanContext(traceparent string) trace.SpanContext {
+	if traceparent == "" {
+		return trace.SpanContext{}
+	}
+	return trace.SpanContextFromContext(WithParent(context.Background(), traceparent))
+}
+
+// Fail отмечает спан ошибкой, не завершая его.
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// End завершает спан, отмечая ошибку, если она есть.
+func End(span trace.Span, err error) {
+	if err != nil {
+		Fail(span, err)
+	}
+	span.End()
+}
@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample())).Tracer("test")
+
+	_, parent := tracer.Start(context.Background(), SpanReceive)
+	traceparent := TraceParent(parent)
+	if traceparent == "" {
+		t.Fatal("empty traceparent for a sampled span")
+	}
+
+	_, child := tracer.Start(WithParent(context.Background(), traceparent), SpanRedisWrite)
+	got := child.(sdktrace.ReadOnlySpan)
+	if got.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+		t.Error("child span is not in the parent's trace")
+	}
+	if got.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("child span parent is not the receive span")
+	}
+}
+
+func TestTraceParentDisabled(t *testing.T) {
+	_, span := noop.NewTracerProvider().Tracer("test").Start(context.Background(), SpanReceive)
+	if got := TraceParent(span); got != "" {
+		t.Errorf("TraceParent(noop span) = %q, want empty", got)
+	}
+	ctx := WithParent(context.Background(), "")
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		t.Error("empty traceparent produced a parent span")
+	}
+}
+
+func TestSampled(t *testing.T) {
+	for _, tt := range []struct {
+		sampler sdktrace.Sampler
+		want    bool
+	}{{sdktrace.AlwaysSample(), true}, {sdktrace.NeverSample(), false}} {
+		_, span := sdktrace.NewTracerProvider(sdktrace.WithSampler(tt.sampler)).Tracer("test").Start(context.Background(), SpanReceive)
+		if got := Sampled(TraceParent(span)); got != tt.want {
+			t.Errorf("Sampled(%s span) = %v, want %v", tt.sampler.Description(), got, tt.want)
+		}
+	}
+	for _, traceparent := range []string{"", "00-garbage"} {
+		if Sampled(traceparent) {
+			t.Errorf("Sampled(%q) = true", traceparent)
+		}
+	}
+}
+
+func TestFailMarksSpan(t *testing.T) {
+	_, span := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample())).Tracer("test").Start(context.Background(), SpanAggregatePair)
+	Fail(span, errors.New("redis down"))
+	span.End()
+
+	got := span.(sdktrace.ReadOnlySpan)
+	if got.Status().Code != codes.Error || got.Status().Description != "redis down" {
+		t.Errorf("status = %+v, want error \"redis down\"", got.Status())
+	}
+	if len(got.Events()) != 1 || got.Events()[0].Name != "exception" {
+		t.Errorf("events = %v, want one recorded error", got.Events())
+	}
+}
+
+// Домен работает со спанами только через этот пакет.
+func TestDomainDoesNotImportOtel(t *testing.T) {
+	err := filepath.WalkDir("../../internal/domain", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, spec := range f.Imports {
+			if imp, _ := strconv.Unquote(spec.Path.Value); strings.HasPrefix(imp, "go.opentelemetry.io/") {
+				t.Errorf("%s imports %s", path, imp)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
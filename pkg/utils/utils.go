@@ -3,7 +3,8 @@ package utils
 import (
 	"bufio"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -26,7 +27,29 @@ func ValidTime(envKey string) (time.Duration, error) {
 	return time, nil
 }
 
-// LoadEnv читает .env файл по указанному пути и записывает KEY=VALUE в os.Environ
+// ParseEnvFloatDefault возвращает def, если переменная не задана
+func ParseEnvFloatDefault(envKey string, def float64) (float64, error) {
+	raw := os.Getenv(envKey)
+	if raw == "" {
+		return def, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s :%w", envKey, err)
+	}
+	return value, nil
+}
+
+// ValidTimeDefault возвращает def, если переменная не задана
+func ValidTimeDefault(envKey string, def time.Duration) (time.Duration, error) {
+	if os.Getenv(envKey) == "" {
+		return def, nil
+	}
+	return ValidTime(envKey)
+}
+
+// LoadEnv читает .env файл по указанному пути и записывает KEY=VALUE в os.Environ.
+// Переменные, уже заданные в окружении, не перезаписываются: окружение важнее файла.
 func LoadEnv(path string) error {
 	f, err := os.Open(path)
 	if err != nil {
@@ -35,7 +58,7 @@ func LoadEnv(path string) error {
 	defer f.Close()
 
 	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
+	for n := 1; scanner.Scan(); n++ {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
@@ -45,13 +68,48 @@ func LoadEnv(path string) error {
 		}
 		parts := strings.SplitN(line, "=", 2)
 		if len(parts) != 2 {
-			log.Printf("Пропущена строка: %s", line)
+			slog.Warn("Skipping malformed env line", "file", path, "line", n)
 			continue
 		}
 		key := strings.TrimSpace(parts[0])
 		val := strings.TrimSpace(parts[1])
 		val = strings.Trim(val, `"'`)
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
 		os.Setenv(key, val)
 	}
 	return scanner.Err()
 }
+
+// ParseTimestamp принимает RFC3339 или unix-время в секундах, миллисекундах,
+// микросекундах или наносекундах. Единица определяется по величине: каждая
+// граница — примерно 5000-й год в более крупной единице.
+func ParseTimestamp(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		switch {
+		case n > 1e17:
+			return time.Unix(0, n), nil
+		case n > 1e14:
+			return time.UnixMicro(n), nil
+		case n > 1e11:
+			return time.UnixMilli(n), nil
+		default:
+			return time.Unix(n, 0), nil
+		}
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		// ParseFloat принимает NaN и Inf, а int64 от них и от слишком больших
+		// значений не определён
+		if math.IsNaN(f) || math.Abs(f*1e6) >= math.MaxInt64 {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", raw)
+		}
+		return time.UnixMicro(int64(f * 1e6)), nil
+	}
+	ts, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", raw)
+	}
+	return ts, nil
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.UTC)
+
+	tests := []struct {
+		name string
+		raw  string
+		want time.Time
+	}{
+		{"seconds", "1709296245", want.Truncate(time.Second)},
+		{"fractional seconds", "1709296245.123456", want.Truncate(time.Microsecond)},
+		{"milliseconds", "1709296245123", want.Truncate(time.Millisecond)},
+		{"microseconds", "1709296245123456", want.Truncate(time.Microsecond)},
+		{"nanoseconds", "1709296245123456789", want},
+		{"rfc3339", "2024-03-01T12:30:45.123456789Z", want},
+		{"spaces", " 1709296245 ", want.Truncate(time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimestamp(tt.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseTimestamp(%q) = %s, want %s", tt.raw, got.UTC(), tt.want)
+			}
+		})
+	}
+
+	for _, raw := range []string{"yesterday", "", "NaN", "nan", "Inf", "+Inf", "-Inf", "infinity", "1e300", "-1e300"} {
+		if got, err := ParseTimestamp(raw); err == nil {
+			t.Errorf("ParseTimestamp(%q) = %s, want error", raw, got)
+		}
+	}
+}
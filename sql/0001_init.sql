@@ -10,8 +10,11 @@ CREATE TABLE IF NOT EXISTS market_data (
     average_price DECIMAL(20,8) NOT NULL,
     min_price DECIMAL(20,8) NOT NULL,
     max_price DECIMAL(20,8) NOT NULL,
+    tick_count INTEGER NOT NULL DEFAULT 0,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
+-- Таблица могла быть создана до появления tick_count: CREATE TABLE IF NOT EXISTS её не меняет
+ALTER TABLE market_data ADD COLUMN IF NOT EXISTS tick_count INTEGER NOT NULL DEFAULT 0;
 
 -- Создание индексов для оптимизации запросов
 CREATE INDEX IF NOT EXISTS idx_market_data_pair_exchange ON market_data(pair_name, exchange);
@@ -34,6 +37,56 @@ CREATE INDEX IF NOT EXISTS idx_raw_price_data_pair_exchange ON raw_price_data(pa
 CREATE INDEX IF NOT EXISTS idx_raw_price_data_timestamp ON raw_price_data(timestamp);
 CREATE INDEX IF NOT EXISTS idx_raw_price_data_received_at ON raw_price_data(received_at);
 
+-- История спредов между биржами (события открытия/закрытия арбитражного окна)
+CREATE TABLE IF NOT EXISTS spread_history (
+    id SERIAL PRIMARY KEY,
+    pair_name VARCHAR(20) NOT NULL,
+    buy_exchange VARCHAR(20) NOT NULL,
+    sell_exchange VARCHAR(20) NOT NULL,
+    buy_price DECIMAL(20,8) NOT NULL,
+    sell_price DECIMAL(20,8) NOT NULL,
+    spread_bps DECIMAL(12,4) NOT NULL,
+    peak_bps DECIMAL(12,4) NOT NULL,
+    status VARCHAR(10) NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_spread_history_pair_timestamp ON spread_history(pair_name, timestamp);
+
+-- Тики, отклонённые валидацией, с кодом причины
+CREATE TABLE IF NOT EXISTS quarantined_ticks (
+    id SERIAL PRIMARY KEY,
+    pair_name TEXT NOT NULL,
+    exchange VARCHAR(20) NOT NULL,
+    price DECIMAL(20,8) NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    detail TEXT NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    rejected_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_quarantined_ticks_rejected_at ON quarantined_ticks(rejected_at);
+
+-- Правила оповещений (управляются через API /alerts/rules)
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    pair_name VARCHAR(20) NOT NULL,
+    exchange VARCHAR(20) NOT NULL DEFAULT '',
+    direction VARCHAR(10) NOT NULL DEFAULT '',
+    price DECIMAL(20,8) NOT NULL DEFAULT 0,
+    percent DOUBLE PRECISION NOT NULL DEFAULT 0,
+    window_seconds INTEGER NOT NULL DEFAULT 0,
+    spread_bps DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max_age_seconds INTEGER NOT NULL DEFAULT 0,
+    cooldown_seconds INTEGER NOT NULL DEFAULT 0,
+    webhook_url TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Создание функции для очистки старых данных (старше 30 дней)
 CREATE OR REPLACE FUNCTION cleanup_old_data()
 RETURNS void AS $$
@@ -45,6 +98,10 @@ BEGIN
     -- Удаляем данные старше 7 дней из raw_price_data
     DELETE FROM raw_price_data 
     WHERE received_at < CURRENT_TIMESTAMP - INTERVAL '7 days';
+
+    -- Удаляем карантин старше 7 дней
+    DELETE FROM quarantined_ticks
+    WHERE rejected_at < CURRENT_TIMESTAMP - INTERVAL '7 days';
     
     -- Логируем количество удаленных записей
     RAISE NOTICE 'Cleanup completed at %', CURRENT_TIMESTAMP;
@@ -87,5 +144,3 @@ GROUP BY pair_name, exchange;
 -- CREATE USER marketflow_app WITH PASSWORD 'secure_password';
 -- GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO marketflow_app;
 -- GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO marketflow_app;
-
-COMMIT;
\ No newline at end of file
@@ -0,0 +1,13 @@
+\c marketflow;
+
+-- Одно окно агрегатора на биржу и пару: на нём держится идемпотентный upsert.
+-- В базах, созданных до индекса, окно могло записаться дважды (повторный прогон
+-- агрегатора, backfill) — оставляем последнюю запись, иначе индекс не создастся.
+DELETE FROM market_data older
+USING market_data newer
+WHERE older.exchange = newer.exchange
+  AND older.pair_name = newer.pair_name
+  AND older.timestamp = newer.timestamp
+  AND older.id < newer.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_market_data_window ON market_data(exchange, pair_name, timestamp);
@@ -0,0 +1,17 @@
+\c marketflow;
+
+-- До выровненных окон агрегатор каждые 10s писал скользящее среднее за последние 60s
+-- с отметкой времени расчёта: соседние строки перекрываются, и каждый тик входит
+-- примерно в шесть из них. Свечи, история и экспорт складывают окна, поэтому такие
+-- строки переносятся в market_data_rolling. Узнаются они по двум признакам: tick_count
+-- тогда не писался (0), а timestamp совпадает с created_at, тогда как окно пишется
+-- не раньше своего конца плюс задержка чтения.
+CREATE TABLE IF NOT EXISTS market_data_rolling (LIKE market_data INCLUDING DEFAULTS);
+
+WITH moved AS (
+    DELETE FROM market_data
+    WHERE tick_count = 0
+      AND created_at < timestamp + INTERVAL '1 second'
+    RETURNING *
+)
+INSERT INTO market_data_rolling SELECT * FROM moved;
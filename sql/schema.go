@@ -0,0 +1,11 @@
+// Package schema встраивает SQL-миграции в бинарник: их применяют `marketflow migrate`
+// и `marketflow serve` при старте.
+//
+// Файлы NNNN_name.sql применяются по порядку имён; этот же каталог монтируется
+// в docker-entrypoint-initdb.d, поэтому новый файл должен быть идемпотентным.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var Migrations embed.FS